@@ -0,0 +1,224 @@
+package apitest
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/context"
+	"github.com/miruken-go/miruken/creates"
+	"github.com/miruken-go/miruken/either"
+	"github.com/miruken-go/miruken/maps"
+	"github.com/miruken-go/miruken/setup"
+	"github.com/miruken-go/miruken/validates"
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	PlayerData struct {
+		Id   int32
+		Name string
+	}
+
+	TeamData struct {
+		Id      int32
+		Name    string
+		Players []PlayerData
+	}
+
+	// TeamMapper creates the fixtures from their type ids.
+	TeamMapper struct{}
+
+	// CodecSuite verifies values round trip through the formats
+	// of a codec.  Codec test suites embed it and add the cases
+	// specific to their encoding.  Batches enables the cases for
+	// the batch and scheduled result surrogates.
+	CodecSuite struct {
+		suite.Suite
+		Features []setup.Feature
+		To       *maps.Format
+		From     *maps.Format
+		Batches  bool
+	}
+)
+
+// TeamMapper
+
+func (m *TeamMapper) New(
+	_ *struct {
+		_ creates.It `key:"apitest.PlayerData"`
+		_ creates.It `key:"apitest.TeamData"`
+	  }, create *creates.It,
+) any {
+	switch create.Key() {
+	case "apitest.PlayerData":
+		return new(PlayerData)
+	case "apitest.TeamData":
+		return new(TeamData)
+	}
+	return nil
+}
+
+// CodecSuite
+
+// Setup creates a context with the codec Features and fixtures.
+func (suite *CodecSuite) Setup() *context.Context {
+	ctx, err := setup.New(suite.Features...).
+		Specs(&api.GoPolymorphism{}, &TeamMapper{}).
+		Context()
+	suite.Require().Nil(err)
+	return ctx
+}
+
+// RoundTrip encodes the value polymorphically and decodes it
+// back, replacing surrogates with the original value.
+func (suite *CodecSuite) RoundTrip(
+	handler miruken.Handler,
+	value   any,
+) any {
+	handler = miruken.BuildUp(handler, api.Polymorphic)
+	byt, _, _, err := maps.Out[[]byte](handler, value, suite.To)
+	suite.Nil(err)
+	late, _, _, err := maps.Out[api.Late](handler, byt, suite.From)
+	suite.Nil(err)
+	if sur, ok := late.Value.(api.Surrogate); ok {
+		late.Value, err = sur.Original(handler)
+		suite.Nil(err)
+	}
+	return late.Value
+}
+
+func (suite *CodecSuite) TestRoundTrip() {
+	team := TeamData{
+		Id:   1,
+		Name: "Liverpool",
+		Players: []PlayerData{
+			{1, "Mohamed Salah"},
+			{2, "Virgil van Dijk"},
+		},
+	}
+
+	suite.Run("Encode", func() {
+		handler := suite.Setup()
+		byt, _, _, err := maps.Out[[]byte](handler, team, suite.To)
+		suite.Nil(err)
+		var decoded TeamData
+		_, _, err = maps.Into(handler, byt, &decoded, suite.From)
+		suite.Nil(err)
+		suite.Equal(team, decoded)
+	})
+
+	suite.Run("Stream", func() {
+		handler := suite.Setup()
+		var b bytes.Buffer
+		stream := io.Writer(&b)
+		_, _, err := maps.Into(handler, team, &stream, suite.To)
+		suite.Nil(err)
+		var decoded TeamData
+		_, _, err = maps.Into(handler, io.Reader(&b), &decoded, suite.From)
+		suite.Nil(err)
+		suite.Equal(team, decoded)
+	})
+
+	suite.Run("Polymorphic", func() {
+		handler := suite.Setup()
+		suite.Equal(&team, suite.RoundTrip(handler, &team))
+	})
+
+	suite.Run("PolymorphicSlice", func() {
+		handler := suite.Setup()
+		players := []any{
+			&PlayerData{1, "Salah"},
+			&TeamData{Id: 2, Name: "Arsenal", Players: []PlayerData{{7, "Saka"}}},
+		}
+		suite.Equal(&players, suite.RoundTrip(handler, players))
+	})
+
+	suite.Run("Message", func() {
+		handler := miruken.BuildUp(suite.Setup(), api.Polymorphic)
+		sentAt := time.Date(2024, 5, 6, 7, 8, 9, 123456789, time.UTC)
+		msg := api.Message{
+			Payload: &team,
+			Headers: api.Headers{
+				MessageId: "m1",
+				Tenant:    "acme",
+				SentAt:    sentAt,
+				Custom:    map[string]string{"Priority": "high"},
+			},
+		}
+		var b bytes.Buffer
+		stream := io.Writer(&b)
+		_, _, err := maps.Into(handler, msg, &stream, suite.To)
+		suite.Nil(err)
+		decoded, _, _, err := maps.Out[api.Message](handler, io.Reader(&b), suite.From)
+		suite.Nil(err)
+		suite.Equal(&team, decoded.Payload)
+		suite.Equal("m1", decoded.Headers.MessageId)
+		suite.Equal("acme", decoded.Headers.Tenant)
+		suite.True(sentAt.Equal(decoded.Headers.SentAt))
+		suite.Equal("high", decoded.Headers.Get("Priority"))
+	})
+
+	suite.Run("Surrogates", func() {
+		suite.Run("Error", func() {
+			handler := suite.Setup()
+			err := suite.RoundTrip(handler, errors.New("something bad"))
+			suite.EqualError(err.(error), "something bad")
+		})
+
+		suite.Run("Outcome", func() {
+			handler := suite.Setup()
+			outcome := &validates.Outcome{}
+			outcome.AddError("Name", errors.New(`"Name" is required`))
+			outcome.AddError("Address.City", errors.New(`"City" is required`))
+			decoded := suite.RoundTrip(handler, outcome).(*validates.Outcome)
+			suite.Equal(outcome.Error(), decoded.Error())
+		})
+
+		if !suite.Batches {
+			return
+		}
+
+		suite.Run("Concurrent", func() {
+			handler := suite.Setup()
+			batch := api.ConcurrentBatch{
+				Requests:       []any{&PlayerData{1, "Salah"}},
+				MaxParallelism: 2,
+				FailFast:       true,
+				Timeout:        3 * time.Second,
+			}
+			suite.Equal(&batch, suite.RoundTrip(handler, batch))
+		})
+
+		suite.Run("Sequential", func() {
+			handler := suite.Setup()
+			batch := api.SequentialBatch{Requests: []any{&PlayerData{1, "Salah"}}}
+			suite.Equal(&batch, suite.RoundTrip(handler, batch))
+		})
+
+		suite.Run("ScheduledResult", func() {
+			handler := suite.Setup()
+			result := api.ScheduledResult{
+				Responses: []either.Monad[error, any]{
+					api.Success[any](&PlayerData{1, "Salah"}),
+					api.Failure(errors.New("player not found")),
+				},
+			}
+			decoded := suite.RoundTrip(handler, result).(*api.ScheduledResult)
+			suite.Len(decoded.Responses, 2)
+			either.Match(decoded.Responses[0], func(err error) {
+				suite.Fail("unexpected error", err)
+			}, func(res any) {
+				suite.Equal(&PlayerData{1, "Salah"}, res)
+			})
+			either.Match(decoded.Responses[1], func(err error) {
+				suite.EqualError(err, "player not found")
+			}, func(res any) {
+				suite.Fail("expected error")
+			})
+		})
+	})
+}
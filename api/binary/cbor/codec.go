@@ -0,0 +1,148 @@
+package cbor
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"reflect"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/miruken-go/miruken/api"
+	binary2 "github.com/miruken-go/miruken/api/binary"
+	"github.com/miruken-go/miruken/maps"
+)
+
+type (
+	// Codec encodes and decodes CBOR.
+	Codec struct{}
+
+	// typeContainer adapts the binary.TypeContainer to CBOR.
+	typeContainer struct {
+		*binary2.TypeContainer[cbor.RawMessage]
+	}
+)
+
+const (
+	majorArray = 4
+	majorMap   = 5
+)
+
+var (
+	// codec is the CBOR binary.Codec.
+	codec binary2.Codec[cbor.RawMessage] = Codec{}
+
+	// encMode encodes deterministically and preserves
+	// the precision of time.Time values.
+	encMode = must(cbor.EncOptions{
+		Sort: cbor.SortCanonical,
+		Time: cbor.TimeRFC3339Nano,
+	}.EncMode())
+
+	// decMode decodes maps with unknown types using string keys.
+	decMode = must(cbor.DecOptions{
+		DefaultMapType: reflect.TypeFor[map[string]any](),
+	}.DecMode())
+)
+
+// Codec
+
+func (c Codec) To() *maps.Format {
+	return api.ToCbor
+}
+
+func (c Codec) From() *maps.Format {
+	return api.FromCbor
+}
+
+func (c Codec) NewEncoder(writer io.Writer) binary2.Encoder {
+	return encMode.NewEncoder(writer)
+}
+
+func (c Codec) NewDecoder(reader io.Reader) binary2.Decoder {
+	return decMode.NewDecoder(reader)
+}
+
+func (c Codec) Marshal(v any) ([]byte, error) {
+	return encMode.Marshal(v)
+}
+
+func (c Codec) Unmarshal(data []byte, v any) error {
+	return decMode.Unmarshal(data, v)
+}
+
+func (c Codec) IsMap(data []byte) bool {
+	return data[0]>>5 == majorMap
+}
+
+func (c Codec) IsArray(data []byte) bool {
+	return data[0]>>5 == majorArray
+}
+
+func (c Codec) MapLen(data []byte) (int, int, error) {
+	n, size, ok := head(data)
+	if !ok {
+		return 0, 0, errors.New("cbor: indefinite length maps are not supported")
+	}
+	return int(n), size, nil
+}
+
+func (c Codec) AppendMapLen(b []byte, n int) []byte {
+	return appendHead(b, majorMap, uint64(n))
+}
+
+func (c Codec) Contain(
+	container *binary2.TypeContainer[cbor.RawMessage],
+) any {
+	return &typeContainer{container}
+}
+
+// typeContainer
+
+func (c *typeContainer) MarshalCBOR() ([]byte, error) {
+	return c.Marshal()
+}
+
+func (c *typeContainer) UnmarshalCBOR(data []byte) error {
+	return c.Unmarshal(data)
+}
+
+// head decodes the length and size of a data item head.
+// Returns false if the data item has an indefinite length.
+func head(data []byte) (n uint64, size int, ok bool) {
+	switch info := data[0] & 0x1f; {
+	case info < 24:
+		return uint64(info), 1, true
+	case info == 24 && len(data) >= 2:
+		return uint64(data[1]), 2, true
+	case info == 25 && len(data) >= 3:
+		return uint64(binary.BigEndian.Uint16(data[1:])), 3, true
+	case info == 26 && len(data) >= 5:
+		return uint64(binary.BigEndian.Uint32(data[1:])), 5, true
+	case info == 27 && len(data) >= 9:
+		return binary.BigEndian.Uint64(data[1:]), 9, true
+	}
+	return 0, 0, false
+}
+
+// appendHead appends the head of a data item of the major type and length.
+func appendHead(b []byte, major byte, n uint64) []byte {
+	major <<= 5
+	switch {
+	case n < 24:
+		return append(b, major|byte(n))
+	case n <= 0xff:
+		return append(b, major|24, byte(n))
+	case n <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(b, major|25), uint16(n))
+	case n <= 0xffffffff:
+		return binary.BigEndian.AppendUint32(append(b, major|26), uint32(n))
+	}
+	return binary.BigEndian.AppendUint64(append(b, major|27), n)
+}
+
+func must[T any](mode T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return mode
+}
@@ -0,0 +1,33 @@
+package cbor
+
+import (
+	"github.com/miruken-go/miruken/api/binary"
+	"github.com/miruken-go/miruken/setup"
+)
+
+// Installer configures CBOR support.
+type Installer struct{}
+
+func (i *Installer) DependsOn() []setup.Feature {
+	return []setup.Feature{
+		binary.Feature()}
+}
+
+func (i *Installer) Install(b *setup.Builder) error {
+	if b.Tag(&featureTag) {
+		b.Specs(&Mapper{}, &SurrogateMapper{})
+	}
+	return nil
+}
+
+func Feature(config ...func(*Installer)) setup.Feature {
+	installer := &Installer{}
+	for _, configure := range config {
+		if configure != nil {
+			configure(installer)
+		}
+	}
+	return installer
+}
+
+var featureTag byte
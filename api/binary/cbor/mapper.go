@@ -0,0 +1,56 @@
+package cbor
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/api/binary"
+	"github.com/miruken-go/miruken/args"
+	"github.com/miruken-go/miruken/maps"
+)
+
+// Mapper formats to and from CBOR.
+type Mapper struct{}
+
+func (m *Mapper) ToCbor(
+	_ *struct {
+		maps.Format `to:"application/cbor"`
+	  }, it *maps.It,
+	_ *struct {
+		args.Optional
+		args.FromOptions
+	  }, apiOptions api.Options,
+	ctx miruken.HandleContext,
+) (any, error) {
+	return binary.Format(codec, it, &apiOptions, ctx.Composer)
+}
+
+func (m *Mapper) FromBytes(
+	_ *struct {
+		maps.Format `from:"application/cbor"`
+	  }, byt []byte,
+	_ *struct {
+		args.Optional
+		args.FromOptions
+	  }, apiOptions api.Options,
+	it  *maps.It,
+	ctx miruken.HandleContext,
+) (any, error) {
+	return binary.Parse(codec, it, bytes.NewReader(byt), &apiOptions, ctx.Composer)
+}
+
+func (m *Mapper) FromReader(
+	_ *struct {
+		maps.Format `from:"application/cbor"`
+	  }, reader io.Reader,
+	_ *struct {
+		args.Optional
+		args.FromOptions
+	  }, apiOptions api.Options,
+	it  *maps.It,
+	ctx miruken.HandleContext,
+) (any, error) {
+	return binary.Parse(codec, it, reader, &apiOptions, ctx.Composer)
+}
@@ -0,0 +1,35 @@
+package cbor
+
+import (
+	"io"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/api/binary"
+	"github.com/miruken-go/miruken/maps"
+)
+
+// MessageSurrogate is a CBOR surrogate for api.Message.
+type MessageSurrogate = binary.Message[cbor.RawMessage]
+
+func (m *SurrogateMapper) EncodeMessage(
+	_ *struct {
+		maps.Format `to:"application/cbor"`
+	  }, msg api.Message,
+	it  *maps.It,
+	ctx miruken.HandleContext,
+) (io.Writer, error) {
+	return binary.EncodeMessage(codec, msg, it, ctx)
+}
+
+func (m *SurrogateMapper) DecodeMessage(
+	_ *struct {
+		maps.It
+		maps.Format `from:"application/cbor"`
+	  }, reader io.Reader,
+	it  *maps.It,
+	ctx miruken.HandleContext,
+) (api.Message, error) {
+	return binary.DecodeMessage(codec, reader, it, ctx)
+}
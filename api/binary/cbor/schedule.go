@@ -0,0 +1,35 @@
+package cbor
+
+import (
+	"github.com/fxamacker/cbor/v2"
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/api/binary"
+	"github.com/miruken-go/miruken/maps"
+)
+
+// ScheduledResult is a surrogate for api.ScheduledResult over CBOR.
+type ScheduledResult binary.ScheduledResult[cbor.RawMessage]
+
+// ScheduledResult
+
+func (s ScheduledResult) Original(composer miruken.Handler) (any, error) {
+	return binary.ScheduledResult[cbor.RawMessage](s).Decode(codec, composer)
+}
+
+// SurrogateMapper
+
+func (m *SurrogateMapper) ReplaceScheduledResult(
+	_ *struct {
+		maps.It
+		maps.Format `to:"application/cbor"`
+	  }, result api.ScheduledResult,
+	ctx miruken.HandleContext,
+) ([]byte, error) {
+	sur, err := binary.NewScheduledResult(codec, result, ctx)
+	if err != nil {
+		return nil, err
+	}
+	byt, _, _, err := maps.Out[[]byte](ctx, ScheduledResult(sur), api.ToCbor)
+	return byt, err
+}
@@ -0,0 +1,19 @@
+package cbor
+
+import "github.com/miruken-go/miruken/creates"
+
+// SurrogateMapper maps concepts to values that are more suitable
+// for transmission over a polymorphic CBOR api.
+type SurrogateMapper struct{}
+
+func (m *SurrogateMapper) New(
+	_ *struct {
+		creates.It `key:"cbor.ScheduledResult"`
+	  }, create *creates.It,
+) any {
+	switch create.Key() {
+	case "cbor.ScheduledResult":
+		return new(ScheduledResult)
+	}
+	return nil
+}
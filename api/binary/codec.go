@@ -0,0 +1,53 @@
+package binary
+
+import (
+	"io"
+
+	"github.com/miruken-go/miruken/maps"
+)
+
+type (
+	// Codec encodes and decodes the data items of a binary format
+	// such as MessagePack or CBOR.  R is the raw message type of
+	// the format used to defer encoding and decoding of values.
+	Codec[R ~[]byte] interface {
+		// To returns the format for mapping values to the codec.
+		To() *maps.Format
+
+		// From returns the format for mapping values from the codec.
+		From() *maps.Format
+
+		NewEncoder(writer io.Writer) Encoder
+		NewDecoder(reader io.Reader) Decoder
+
+		Marshal(v any) ([]byte, error)
+		Unmarshal(data []byte, v any) error
+
+		// IsMap reports whether the data item is a map.
+		IsMap(data []byte) bool
+
+		// IsArray reports whether the data item is an array.
+		IsArray(data []byte) bool
+
+		// MapLen returns the number of entries in the map data item
+		// and the size of its header.
+		MapLen(data []byte) (n int, size int, err error)
+
+		// AppendMapLen appends the header of a map with n entries.
+		AppendMapLen(b []byte, n int) []byte
+
+		// Contain returns the value serialized by the container
+		// using the marshaling hooks of the format.
+		Contain(c *TypeContainer[R]) any
+	}
+
+	// Encoder writes values to an output stream.
+	Encoder interface {
+		Encode(v any) error
+	}
+
+	// Decoder reads values from an input stream.
+	Decoder interface {
+		Decode(v any) error
+	}
+)
@@ -0,0 +1,33 @@
+package binary
+
+import (
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api/json"
+	"github.com/miruken-go/miruken/maps"
+	"github.com/miruken-go/miruken/validates"
+)
+
+func (m *SurrogateMapper) ReplaceOutcome(
+	_ *struct {
+		maps.It
+		maps.Format `to:"/^application/(msgpack|cbor)$/"`
+	  }, outcome *validates.Outcome,
+	it  *maps.It,
+	ctx miruken.HandleContext,
+) ([]byte, error) {
+	byt, _, _, err := maps.Out[[]byte](ctx, json.NewOutcome(outcome), it.Format())
+	return byt, err
+}
+
+func (m *SurrogateMapper) ReplaceError(
+	_ *struct {
+		maps.It
+		maps.Format `to:"/^application/(msgpack|cbor)$/"`
+	  }, err error,
+	it  *maps.It,
+	ctx miruken.HandleContext,
+) ([]byte, error) {
+	sur := json.Error{Message: err.Error()}
+	byt, _, _, err := maps.Out[[]byte](ctx, sur, it.Format())
+	return byt, err
+}
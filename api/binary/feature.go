@@ -0,0 +1,34 @@
+package binary
+
+import (
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/api/json"
+	"github.com/miruken-go/miruken/setup"
+)
+
+// Installer configures the surrogates shared by binary formats.
+type Installer struct{}
+
+func (i *Installer) DependsOn() []setup.Feature {
+	return []setup.Feature{
+		api.Feature()}
+}
+
+func (i *Installer) Install(b *setup.Builder) error {
+	if b.Tag(&featureTag) {
+		b.Specs(&json.SurrogateMapper{}, &SurrogateMapper{})
+	}
+	return nil
+}
+
+func Feature(config ...func(*Installer)) setup.Feature {
+	installer := &Installer{}
+	for _, configure := range config {
+		if configure != nil {
+			configure(installer)
+		}
+	}
+	return installer
+}
+
+var featureTag byte
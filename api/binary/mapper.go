@@ -0,0 +1,81 @@
+package binary
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/internal"
+	"github.com/miruken-go/miruken/maps"
+)
+
+// Format maps the source of the mapping into the target
+// bytes or writer using the codec.
+func Format[R ~[]byte](
+	codec      Codec[R],
+	it         *maps.It,
+	apiOptions *api.Options,
+	composer   miruken.Handler,
+) (out any, err error) {
+	switch t := it.Target().(type) {
+	case *[]byte:
+		var b bytes.Buffer
+		if err = encode(codec, it, &b, apiOptions, composer); err == nil {
+			*t = b.Bytes()
+			out = *t
+		}
+	case *io.Writer:
+		if internal.IsNil(*t) {
+			*t = new(bytes.Buffer)
+		}
+		if err = encode(codec, it, *t, apiOptions, composer); err == nil {
+			out = *t
+		}
+	}
+	return
+}
+
+// Parse maps the reader into the target of the mapping
+// using the codec.
+func Parse[R ~[]byte](
+	codec      Codec[R],
+	it         *maps.It,
+	reader     io.Reader,
+	apiOptions *api.Options,
+	composer   miruken.Handler,
+) (target any, err error) {
+	target = it.TargetForWrite()
+	dec := codec.NewDecoder(reader)
+	if apiOptions.Polymorphism == miruken.Set(api.PolymorphismRoot) {
+		err = dec.Decode(codec.Contain(&TypeContainer[R]{
+			codec:    codec,
+			v:        target,
+			composer: composer,
+		}))
+	} else {
+		err = dec.Decode(target)
+	}
+	return
+}
+
+func encode[R ~[]byte](
+	codec      Codec[R],
+	it         *maps.It,
+	writer     io.Writer,
+	apiOptions *api.Options,
+	composer   miruken.Handler,
+) error {
+	it.TargetForWrite()
+	src := it.Source()
+	if apiOptions.Polymorphism == miruken.Set(api.PolymorphismRoot) {
+		src = codec.Contain(&TypeContainer[R]{
+			codec:    codec,
+			v:        src,
+			typInfo:  apiOptions.TypeInfoFormat,
+			versions: apiOptions.Versions,
+			composer: composer,
+		})
+	}
+	return codec.NewEncoder(writer).Encode(src)
+}
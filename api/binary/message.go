@@ -0,0 +1,128 @@
+package binary
+
+import (
+	"io"
+	"time"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/maps"
+)
+
+// Headers is a surrogate for api.Headers over binary formats.
+type Headers struct {
+	MessageId     string            `msgpack:"messageId,omitempty" cbor:"messageId,omitempty"`
+	CorrelationId string            `msgpack:"correlationId,omitempty" cbor:"correlationId,omitempty"`
+	CausationId   string            `msgpack:"causationId,omitempty" cbor:"causationId,omitempty"`
+	Tenant        string            `msgpack:"tenant,omitempty" cbor:"tenant,omitempty"`
+	SentAt        *time.Time        `msgpack:"sentAt,omitempty" cbor:"sentAt,omitempty"`
+	Deadline      *time.Time        `msgpack:"deadline,omitempty" cbor:"deadline,omitempty"`
+	Custom        map[string]string `msgpack:"custom,omitempty" cbor:"custom,omitempty"`
+}
+
+// EncodeHeaders returns the surrogate for the api.Headers.
+func EncodeHeaders(headers api.Headers) *Headers {
+	sur := &Headers{
+		MessageId:     headers.MessageId,
+		CorrelationId: headers.CorrelationId,
+		CausationId:   headers.CausationId,
+		Tenant:        headers.Tenant,
+		Custom:        headers.Custom,
+	}
+	if sentAt := headers.SentAt; !sentAt.IsZero() {
+		sur.SentAt = &sentAt
+	}
+	if deadline := headers.Deadline; !deadline.IsZero() {
+		sur.Deadline = &deadline
+	}
+	return sur
+}
+
+// Decode returns the api.Headers of the surrogate.
+func (h *Headers) Decode() api.Headers {
+	headers := api.Headers{
+		MessageId:     h.MessageId,
+		CorrelationId: h.CorrelationId,
+		CausationId:   h.CausationId,
+		Tenant:        h.Tenant,
+		Custom:        h.Custom,
+	}
+	if h.SentAt != nil {
+		headers.SentAt = *h.SentAt
+	}
+	if h.Deadline != nil {
+		headers.Deadline = *h.Deadline
+	}
+	return headers
+}
+
+// Message is a surrogate for api.Message over binary formats.
+type Message[R ~[]byte] struct {
+	Payload R        `msgpack:"payload,omitempty" cbor:"payload,omitempty"`
+	Headers *Headers `msgpack:"headers,omitempty" cbor:"headers,omitempty"`
+}
+
+// EncodeMessage writes the api.Message to the target
+// writer of the mapping using the codec.
+func EncodeMessage[R ~[]byte](
+	codec Codec[R],
+	msg   api.Message,
+	it    *maps.It,
+	ctx   miruken.HandleContext,
+) (io.Writer, error) {
+	if writer, ok := it.Target().(*io.Writer); ok {
+		var sur Message[R]
+		if payload := msg.Payload; payload != nil {
+			pb, _, _, err := maps.Out[[]byte](ctx.Composer, payload, codec.To())
+			if err != nil {
+				return nil, err
+			}
+			sur.Payload = R(pb)
+		}
+		if headers := msg.Headers; !headers.IsZero() {
+			sur.Headers = EncodeHeaders(headers)
+		}
+		if err := codec.NewEncoder(*writer).Encode(sur); err != nil {
+			return nil, err
+		}
+		it.TargetForWrite()
+		return *writer, nil
+	}
+	return nil, nil
+}
+
+// DecodeMessage reads the api.Message from the reader
+// into the target of the mapping using the codec.
+func DecodeMessage[R ~[]byte](
+	codec  Codec[R],
+	reader io.Reader,
+	it     *maps.It,
+	ctx    miruken.HandleContext,
+) (msg api.Message, err error) {
+	if mp, ok := it.Target().(*api.Message); ok {
+		var sur Message[R]
+		if err = codec.NewDecoder(reader).Decode(&sur); err != nil {
+			return
+		}
+		if headers := sur.Headers; headers != nil {
+			mp.Headers = headers.Decode()
+		}
+		if payload := sur.Payload; payload != nil {
+			var late api.Late
+			composer := ctx.Composer
+			late, _, _, err = maps.Out[api.Late](composer, []byte(payload), codec.From())
+			if err != nil {
+				return
+			}
+			if sur, ok := late.Value.(api.Surrogate); ok {
+				if late.Value, err = sur.Original(composer); err != nil {
+					return
+				}
+			}
+			it.TargetForWrite()
+			mp.Payload = late.Value
+			msg = *mp
+		}
+	}
+	return
+}
@@ -0,0 +1,100 @@
+package msgpack
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/api/binary"
+	"github.com/miruken-go/miruken/maps"
+	"github.com/vmihailenco/msgpack/v5"
+	"github.com/vmihailenco/msgpack/v5/msgpcode"
+)
+
+type (
+	// Codec encodes and decodes MessagePack.
+	Codec struct{}
+
+	// typeContainer adapts the binary.TypeContainer to MessagePack.
+	typeContainer struct {
+		*binary.TypeContainer[msgpack.RawMessage]
+	}
+)
+
+// codec is the MessagePack binary.Codec.
+var codec binary.Codec[msgpack.RawMessage] = Codec{}
+
+// Codec
+
+func (c Codec) To() *maps.Format {
+	return api.ToMsgpack
+}
+
+func (c Codec) From() *maps.Format {
+	return api.FromMsgpack
+}
+
+// NewEncoder creates an Encoder that sorts map keys
+// to produce deterministic output.
+func (c Codec) NewEncoder(writer io.Writer) binary.Encoder {
+	enc := msgpack.NewEncoder(writer)
+	enc.SetSortMapKeys(true)
+	return enc
+}
+
+func (c Codec) NewDecoder(reader io.Reader) binary.Decoder {
+	return msgpack.NewDecoder(reader)
+}
+
+func (c Codec) Marshal(v any) ([]byte, error) {
+	var b bytes.Buffer
+	if err := c.NewEncoder(&b).Encode(v); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+func (c Codec) Unmarshal(data []byte, v any) error {
+	return msgpack.Unmarshal(data, v)
+}
+
+func (c Codec) IsMap(data []byte) bool {
+	code := data[0]
+	return msgpcode.IsFixedMap(code) || code == msgpcode.Map16 || code == msgpcode.Map32
+}
+
+func (c Codec) IsArray(data []byte) bool {
+	code := data[0]
+	return msgpcode.IsFixedArray(code) || code == msgpcode.Array16 || code == msgpcode.Array32
+}
+
+func (c Codec) MapLen(data []byte) (int, int, error) {
+	r := bytes.NewReader(data)
+	n, err := msgpack.NewDecoder(r).DecodeMapLen()
+	if err != nil {
+		return 0, 0, err
+	}
+	return n, len(data) - r.Len(), nil
+}
+
+func (c Codec) AppendMapLen(b []byte, n int) []byte {
+	buf := bytes.NewBuffer(b)
+	_ = msgpack.NewEncoder(buf).EncodeMapLen(n)
+	return buf.Bytes()
+}
+
+func (c Codec) Contain(
+	container *binary.TypeContainer[msgpack.RawMessage],
+) any {
+	return &typeContainer{container}
+}
+
+// typeContainer
+
+func (c *typeContainer) MarshalMsgpack() ([]byte, error) {
+	return c.Marshal()
+}
+
+func (c *typeContainer) UnmarshalMsgpack(data []byte) error {
+	return c.Unmarshal(data)
+}
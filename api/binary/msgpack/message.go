@@ -0,0 +1,35 @@
+package msgpack
+
+import (
+	"io"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/api/binary"
+	"github.com/miruken-go/miruken/maps"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// MessageSurrogate is a MessagePack surrogate for api.Message.
+type MessageSurrogate = binary.Message[msgpack.RawMessage]
+
+func (m *SurrogateMapper) EncodeMessage(
+	_ *struct {
+		maps.Format `to:"application/msgpack"`
+	  }, msg api.Message,
+	it  *maps.It,
+	ctx miruken.HandleContext,
+) (io.Writer, error) {
+	return binary.EncodeMessage(codec, msg, it, ctx)
+}
+
+func (m *SurrogateMapper) DecodeMessage(
+	_ *struct {
+		maps.It
+		maps.Format `from:"application/msgpack"`
+	  }, reader io.Reader,
+	it  *maps.It,
+	ctx miruken.HandleContext,
+) (api.Message, error) {
+	return binary.DecodeMessage(codec, reader, it, ctx)
+}
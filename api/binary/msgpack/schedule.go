@@ -0,0 +1,35 @@
+package msgpack
+
+import (
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/api/binary"
+	"github.com/miruken-go/miruken/maps"
+	"github.com/vmihailenco/msgpack/v5"
+)
+
+// ScheduledResult is a surrogate for api.ScheduledResult over MessagePack.
+type ScheduledResult binary.ScheduledResult[msgpack.RawMessage]
+
+// ScheduledResult
+
+func (s ScheduledResult) Original(composer miruken.Handler) (any, error) {
+	return binary.ScheduledResult[msgpack.RawMessage](s).Decode(codec, composer)
+}
+
+// SurrogateMapper
+
+func (m *SurrogateMapper) ReplaceScheduledResult(
+	_ *struct {
+		maps.It
+		maps.Format `to:"application/msgpack"`
+	  }, result api.ScheduledResult,
+	ctx miruken.HandleContext,
+) ([]byte, error) {
+	sur, err := binary.NewScheduledResult(codec, result, ctx)
+	if err != nil {
+		return nil, err
+	}
+	byt, _, _, err := maps.Out[[]byte](ctx, ScheduledResult(sur), api.ToMsgpack)
+	return byt, err
+}
@@ -0,0 +1,19 @@
+package msgpack
+
+import "github.com/miruken-go/miruken/creates"
+
+// SurrogateMapper maps concepts to values that are more suitable
+// for transmission over a polymorphic MessagePack api.
+type SurrogateMapper struct{}
+
+func (m *SurrogateMapper) New(
+	_ *struct {
+		creates.It `key:"msgpack.ScheduledResult"`
+	  }, create *creates.It,
+) any {
+	switch create.Key() {
+	case "msgpack.ScheduledResult":
+		return new(ScheduledResult)
+	}
+	return nil
+}
@@ -0,0 +1,211 @@
+package binary
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/creates"
+	"github.com/miruken-go/miruken/internal"
+	"github.com/miruken-go/miruken/maps"
+)
+
+type (
+	// TypeContainer customizes binary serialization to emit
+	// type field information needed to support polymorphism.
+	TypeContainer[R ~[]byte] struct {
+		codec    Codec[R]
+		v        any
+		typInfo  string
+		versions map[string]int
+		composer miruken.Handler
+	}
+)
+
+var (
+	// KnownTypeFields holds the list of map keys
+	// that can contain type discriminators.
+	KnownTypeFields = []string{"$type", "@type"}
+
+	// KnownValuesFields holds the list of map keys
+	// that can contain values for discriminated arrays.
+	KnownValuesFields = []string{"$values", "@values"}
+)
+
+func (c *TypeContainer[R]) typeInfo() *maps.Format {
+	if typeInfo := c.typInfo; len(typeInfo) > 0 {
+		return maps.To(typeInfo, nil)
+	}
+	return api.ToTypeInfo
+}
+
+// contain returns the codec value serializing v
+// with the type information of the container.
+func (c *TypeContainer[R]) contain(v any) any {
+	return c.codec.Contain(&TypeContainer[R]{
+		codec:    c.codec,
+		v:        v,
+		typInfo:  c.typInfo,
+		composer: c.composer,
+	})
+}
+
+func (c *TypeContainer[R]) Marshal() ([]byte, error) {
+	codec := c.codec
+	v := c.v
+	typ := reflect.TypeOf(v)
+	if typ != nil && typ.Kind() == reflect.Slice {
+		et := typ.Elem()
+		s := reflect.ValueOf(v)
+		arr := make([]R, 0, s.Len())
+		for i := range s.Len() {
+			elem := s.Index(i).Interface()
+			if internal.IsAny(et) || reflect.TypeOf(elem) != et {
+				elem = codec.Contain(&TypeContainer[R]{
+					codec:    codec,
+					v:        elem,
+					typInfo:  c.typInfo,
+					versions: c.versions,
+					composer: c.composer,
+				})
+			}
+			if raw, err := codec.Marshal(elem); err != nil {
+				return nil, fmt.Errorf("can't marshal array index %d: %w", i, err)
+			} else {
+				arr = append(arr, raw)
+			}
+		}
+		v = arr
+	} else if versions := c.versions; len(versions) > 0 {
+		var err error
+		if v, err = api.DowncastTo(c.composer, v, versions, c.typeInfo()); err != nil {
+			return nil, err
+		}
+	}
+	byt, err := codec.Marshal(v)
+	if err != nil || len(byt) == 0 {
+		return byt, err
+	}
+	if codec.IsMap(byt) {
+		typeInfo, _, _, err := maps.Out[api.TypeFieldInfo](c.composer, v, c.typeInfo())
+		if err != nil {
+			return nil, err
+		}
+		n, size, err := codec.MapLen(byt)
+		if err != nil {
+			return nil, err
+		}
+		// prepend the type field to the existing map entries
+		b := codec.AppendMapLen(nil, n+1)
+		if b, err = c.appendStrings(b, typeInfo.TypeField, typeInfo.TypeValue); err != nil {
+			return nil, err
+		}
+		byt = append(b, byt[size:]...)
+	} else if codec.IsArray(byt) {
+		typeInfo, _, _, err := maps.Out[api.TypeFieldInfo](c.composer, c.v, c.typeInfo())
+		if err != nil {
+			return nil, err
+		}
+		b := codec.AppendMapLen(nil, 2)
+		if b, err = c.appendStrings(b,
+			typeInfo.TypeField, typeInfo.TypeValue, typeInfo.ValuesField); err != nil {
+			return nil, err
+		}
+		byt = append(b, byt...)
+	}
+	return byt, nil
+}
+
+func (c *TypeContainer[R]) Unmarshal(data []byte) error {
+	codec := c.codec
+	if len(data) == 0 {
+		return errors.New(codec.From().Name() + ": empty data")
+	}
+	if codec.IsArray(data) {
+		var raw []R
+		if err := codec.Unmarshal(data, &raw); err != nil {
+			return err
+		}
+		var arr reflect.Value
+		typ := reflect.Indirect(reflect.ValueOf(c.v)).Type()
+		if typ.Kind() == reflect.Slice {
+			arr = reflect.MakeSlice(typ, len(raw), len(raw))
+		} else {
+			arr = reflect.ValueOf(make([]any, len(raw)))
+		}
+		for i, elem := range raw {
+			tc := c.contain(arr.Index(i).Addr().Interface()) // &arr[0]
+			if err := codec.Unmarshal(elem, tc); err != nil {
+				return fmt.Errorf("can't unmarshal array index %d: %w", i, err)
+			}
+		}
+		if late, ok := c.v.(*api.Late); ok {
+			late.Value = arr.Interface()
+		} else {
+			internal.CopyIndirect(arr.Interface(), c.v)
+		}
+		return nil
+	} else if !codec.IsMap(data) {
+		if late, ok := c.v.(*api.Late); ok {
+			return codec.Unmarshal(data, &late.Value)
+		}
+		return codec.Unmarshal(data, c.v)
+	}
+	var fields map[string]R
+	if err := codec.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	var (
+		field     string
+		typeIdRaw R
+	)
+	for _, field = range KnownTypeFields {
+		if typeIdRaw = fields[field]; typeIdRaw != nil {
+			break
+		}
+	}
+	if typeIdRaw == nil {
+		if late, ok := c.v.(*api.Late); ok {
+			return codec.Unmarshal(data, &late.Value)
+		}
+		return codec.Unmarshal(data, c.v)
+	}
+	var typeId string
+	if err := codec.Unmarshal(typeIdRaw, &typeId); err != nil {
+		return err
+	} else if typeId == "" {
+		return fmt.Errorf("empty type id for field %q", field)
+	}
+	v, _, err := creates.Key[any](c.composer, typeId)
+	if err != nil {
+		return &api.UnknownTypeIdError{TypeId: typeId, Cause: err}
+	}
+	vm := v
+	for _, field = range KnownValuesFields {
+		if values := fields[field]; values != nil {
+			data = []byte(values)
+			vm = c.contain(v)
+		}
+	}
+	if err := codec.Unmarshal(data, vm); err != nil {
+		return err
+	}
+	return api.AssignDecoded(c.composer, typeId, v, c.v)
+}
+
+// appendStrings appends the encoded strings to b.
+func (c *TypeContainer[R]) appendStrings(
+	b       []byte,
+	strings ...string,
+) ([]byte, error) {
+	for _, s := range strings {
+		byt, err := c.codec.Marshal(s)
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, byt...)
+	}
+	return b, nil
+}
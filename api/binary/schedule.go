@@ -0,0 +1,108 @@
+package binary
+
+import (
+	"errors"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/api/json"
+	"github.com/miruken-go/miruken/either"
+	"github.com/miruken-go/miruken/maps"
+)
+
+type (
+	// Either is a surrogate for either.Monad over binary formats.
+	Either[R ~[]byte] struct {
+		Left  bool `msgpack:"left" cbor:"left"`
+		Value R    `msgpack:"value" cbor:"value"`
+	}
+
+	// ScheduledResult is a surrogate for api.ScheduledResult
+	// over binary formats.
+	ScheduledResult[R ~[]byte] []Either[R]
+)
+
+// ScheduledResult
+
+// NewScheduledResult returns the surrogate for the api.ScheduledResult.
+func NewScheduledResult[R ~[]byte](
+	codec  Codec[R],
+	result api.ScheduledResult,
+	ctx    miruken.HandleContext,
+) (ScheduledResult[R], error) {
+	sur := make(ScheduledResult[R], len(result.Responses))
+	for i, resp := range result.Responses {
+		err := either.Fold(resp, func(e error) error {
+			byt, _, _, err := maps.Out[[]byte](ctx, e, codec.To())
+			if err == nil {
+				sur[i] = Either[R]{true, R(byt)}
+			}
+			return err
+		}, func(val any) error {
+			byt, _, _, err := maps.Out[[]byte](ctx, val, codec.To())
+			if err == nil {
+				sur[i] = Either[R]{false, R(byt)}
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return sur, nil
+}
+
+// Decode returns the api.ScheduledResult of the surrogate.
+func (s ScheduledResult[R]) Decode(
+	codec    Codec[R],
+	composer miruken.Handler,
+) (*api.ScheduledResult, error) {
+	responses := make([]either.Monad[error, any], len(s))
+	for i, resp := range s {
+		v, _, _, err := maps.Out[any](composer, []byte(resp.Value), codec.From())
+		if err != nil {
+			return nil, err
+		}
+		if sur, ok := v.(api.Surrogate); ok {
+			if v, err = sur.Original(composer); err != nil {
+				return nil, err
+			}
+		}
+		if resp.Left {
+			e, ok := v.(error)
+			if !ok {
+				return nil, errors.New("expected left of error")
+			}
+			responses[i] = either.Left(e)
+		} else {
+			responses[i] = either.Right(v)
+		}
+	}
+	return &api.ScheduledResult{Responses: responses}, nil
+}
+
+// SurrogateMapper
+
+func (m *SurrogateMapper) ReplaceConcurrent(
+	_ *struct {
+		maps.It
+		maps.Format `to:"/^application/(msgpack|cbor)$/"`
+	  }, batch api.ConcurrentBatch,
+	it  *maps.It,
+	ctx miruken.HandleContext,
+) (byt []byte, err error) {
+	byt, _, _, err = maps.Out[[]byte](ctx, json.NewConcurrent(batch), it.Format())
+	return
+}
+
+func (m *SurrogateMapper) ReplaceSequential(
+	_ *struct {
+		maps.It
+		maps.Format `to:"/^application/(msgpack|cbor)$/"`
+	  }, batch api.SequentialBatch,
+	it  *maps.It,
+	ctx miruken.HandleContext,
+) (byt []byte, err error) {
+	byt, _, _, err = maps.Out[[]byte](ctx, json.NewSequential(batch), it.Format())
+	return
+}
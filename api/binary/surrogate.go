@@ -0,0 +1,6 @@
+package binary
+
+// SurrogateMapper maps concepts to values that are more suitable
+// for transmission over a binary api such as MessagePack or CBOR.
+// The json surrogates are reused since they hold no json specifics.
+type SurrogateMapper struct{}
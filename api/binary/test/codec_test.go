@@ -0,0 +1,51 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/api/apitest"
+	"github.com/miruken-go/miruken/api/binary/cbor"
+	"github.com/miruken-go/miruken/api/binary/msgpack"
+	"github.com/miruken-go/miruken/maps"
+	"github.com/miruken-go/miruken/setup"
+	"github.com/stretchr/testify/suite"
+)
+
+type BinaryTestSuite struct {
+	apitest.CodecSuite
+}
+
+func (suite *BinaryTestSuite) TestBinary() {
+	suite.Run("PolymorphicTypeField", func() {
+		handler := suite.Setup()
+		team := &apitest.TeamData{Id: 1, Name: "Liverpool"}
+		byt, _, _, err := maps.Out[[]byte](
+			miruken.BuildUp(handler, api.Polymorphic), team, suite.To)
+		suite.Nil(err)
+		var fields map[string]any
+		_, _, err = maps.Into(handler, byt, &fields, suite.From)
+		suite.Nil(err)
+		suite.Equal("apitest.TeamData", fields["@type"])
+		suite.Equal("Liverpool", fields["Name"])
+	})
+}
+
+func TestMsgpackTestSuite(t *testing.T) {
+	suite.Run(t, &BinaryTestSuite{apitest.CodecSuite{
+		Features: []setup.Feature{msgpack.Feature()},
+		To:       api.ToMsgpack,
+		From:     api.FromMsgpack,
+		Batches:  true,
+	}})
+}
+
+func TestCborTestSuite(t *testing.T) {
+	suite.Run(t, &BinaryTestSuite{apitest.CodecSuite{
+		Features: []setup.Feature{cbor.Feature()},
+		To:       api.ToCbor,
+		From:     api.FromCbor,
+		Batches:  true,
+	}})
+}
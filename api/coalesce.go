@@ -0,0 +1,259 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/args"
+	"github.com/miruken-go/miruken/promise"
+	"github.com/miruken-go/miruken/provides"
+)
+
+type (
+	// Coalescing controls the time-windowed batching of a route.
+	// Routed messages arriving within Window of the first are
+	// sent as a single ConcurrentBatch.  The batch is sent early
+	// once Max messages are pending.
+	Coalescing struct {
+		Max    int
+		Window time.Duration
+	}
+
+	// CoalesceOptions enable automatic batching per route.
+	// Routes not present are never coalesced.
+	CoalesceOptions struct {
+		Routes map[string]Coalescing
+	}
+
+	// Coalescer is a setup.Bootstrap that gathers concurrent
+	// Routed messages outside an explicit miruken.Batch into a
+	// single batch per route and sender and fans the responses
+	// back to each caller.  The sender is the Handler a message
+	// was sent through so messages are only batched with others
+	// sharing the same context, headers and credentials.  The
+	// batch is sent through the sender.
+	Coalescer struct {
+		routes  map[string]Coalescing
+		windows map[windowKey]*window
+		lock    sync.Mutex
+	}
+
+	// windowKey identifies the window of a route and sender.
+	windowKey struct {
+		route  string
+		sender miruken.Handler
+	}
+
+	// window holds the messages pending for a route and sender.
+	window struct {
+		group []pending
+		timer *time.Timer
+	}
+)
+
+const (
+	defaultCoalesceMax    = 50
+	defaultCoalesceWindow = 5 * time.Millisecond
+)
+
+// Coalescing
+
+// UnmarshalText parses the Coalescing from a spec such as
+// max=50,window=5ms so it can be loaded from configuration.
+func (c *Coalescing) UnmarshalText(text []byte) error {
+	coalescing, err := ParseCoalescing(string(text))
+	if err != nil {
+		return err
+	}
+	*c = coalescing
+	return nil
+}
+
+func (c Coalescing) String() string {
+	return fmt.Sprintf("max=%d,window=%s", c.Max, c.Window)
+}
+
+// Coalescer
+
+func (c *Coalescer) Constructor(
+	_ *struct {
+		provides.It
+		provides.Single
+	  },
+	_ *struct {
+		args.Optional
+		args.FromOptions
+	  }, options CoalesceOptions,
+) {
+	c.routes  = make(map[string]Coalescing, len(options.Routes))
+	c.windows = make(map[windowKey]*window)
+	for route, coalescing := range options.Routes {
+		if coalescing.Max <= 0 {
+			coalescing.Max = defaultCoalesceMax
+		}
+		if coalescing.Window <= 0 {
+			coalescing.Window = defaultCoalesceWindow
+		}
+		c.routes[route] = coalescing
+	}
+}
+
+// Coalescing returns the Coalescing of a route if enabled.
+func (c *Coalescer) Coalescing(route string) (Coalescing, bool) {
+	coalescing, ok := c.routes[route]
+	return coalescing, ok
+}
+
+// Route adds a Routed message to the open window of its route
+// and sender.  Returns false if the route is not coalesced or
+// the message has no sender such as one with explicit Headers.
+func (c *Coalescer) Route(
+	routed   Routed,
+	publish  bool,
+	composer miruken.Handler,
+) (*promise.Promise[any], bool) {
+	route := routed.Route
+	coalescing, ok := c.routes[route]
+	if !ok {
+		return nil, false
+	}
+	sender := senderOf(composer)
+	if sender == nil {
+		return nil, false
+	}
+	key := windowKey{route, sender}
+
+	msg := routed.Message
+	if publish {
+		msg = Published{msg}
+	}
+	request := pending{
+		message:  msg,
+		deferred: promise.Defer[any](),
+	}
+
+	c.lock.Lock()
+	w := c.windows[key]
+	if w == nil {
+		w = &window{}
+		c.windows[key] = w
+		w.timer = time.AfterFunc(coalescing.Window, func() {
+			c.flush(key, w)
+		})
+	}
+	w.group = append(w.group, request)
+	full := len(w.group) >= coalescing.Max
+	c.lock.Unlock()
+
+	if full {
+		c.flush(key, w)
+	}
+	return request.deferred.Promise(), true
+}
+
+func (c *Coalescer) Startup(
+	ctx context.Context,
+	h   miruken.Handler,
+) *promise.Promise[struct{}] {
+	return promise.Empty()
+}
+
+// Shutdown sends the messages pending in all windows.
+func (c *Coalescer) Shutdown(
+	ctx context.Context,
+) *promise.Promise[struct{}] {
+	c.lock.Lock()
+	windows := make(map[windowKey]*window, len(c.windows))
+	for key, w := range c.windows {
+		windows[key] = w
+	}
+	c.lock.Unlock()
+	for key, w := range windows {
+		c.flush(key, w)
+	}
+	return promise.Empty()
+}
+
+// flush sends the messages in the window if still open.
+func (c *Coalescer) flush(key windowKey, w *window) {
+	c.lock.Lock()
+	if c.windows[key] != w {
+		c.lock.Unlock()
+		return
+	}
+	delete(c.windows, key)
+	w.timer.Stop()
+	c.lock.Unlock()
+	sendGroup(key.sender, key.route, w.group)
+}
+
+// ParseCoalescing parses a Coalescing spec of comma separated
+// settings such as max=50,window=5ms.  Omitted settings use
+// the defaults of 50 messages and a 5ms window.
+func ParseCoalescing(spec string) (Coalescing, error) {
+	coalescing := Coalescing{
+		Max:    defaultCoalesceMax,
+		Window: defaultCoalesceWindow,
+	}
+	for _, setting := range strings.Split(spec, ",") {
+		if setting = strings.TrimSpace(setting); setting == "" {
+			continue
+		}
+		key, value, ok := strings.Cut(setting, "=")
+		if !ok {
+			return Coalescing{}, fmt.Errorf("api: invalid coalescing setting %q", setting)
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "max":
+			limit, err := strconv.Atoi(strings.TrimSpace(value))
+			if err != nil || limit <= 0 {
+				return Coalescing{}, fmt.Errorf("api: invalid coalescing max %q", value)
+			}
+			coalescing.Max = limit
+		case "window":
+			window, err := time.ParseDuration(strings.TrimSpace(value))
+			if err != nil || window <= 0 {
+				return Coalescing{}, fmt.Errorf("api: invalid coalescing window %q", value)
+			}
+			coalescing.Window = window
+		default:
+			return Coalescing{}, fmt.Errorf("api: unknown coalescing setting %q", key)
+		}
+	}
+	return coalescing, nil
+}
+
+// senderOf returns the Handler the message being routed was
+// sent through.  Returns nil if unknown or not comparable.
+func senderOf(composer miruken.Handler) miruken.Handler {
+	if val, ok := StashGetKey(composer, senderKey{}); ok {
+		if sender, ok := val.(miruken.Handler); ok &&
+			reflect.TypeOf(sender).Comparable() {
+			return sender
+		}
+	}
+	return nil
+}
+
+// coalesce routes a message through the Coalescer if its
+// route has coalescing enabled.  Batches are never coalesced.
+func coalesce(
+	routed Routed,
+	ctx    *miruken.HandleContext,
+) (*promise.Promise[any], bool) {
+	switch routed.Message.(type) {
+	case ConcurrentBatch, SequentialBatch:
+		return nil, false
+	}
+	coalescer, _, ok, err := provides.Type[*Coalescer](ctx.Composer)
+	if !ok || err != nil || coalescer == nil {
+		return nil, false
+	}
+	return coalescer.Route(routed, ctx.Greedy, ctx.Composer)
+}
@@ -1,25 +1,83 @@
 package api
 
 import (
+	"errors"
+	"reflect"
+
 	"github.com/miruken-go/miruken/setup"
 )
 
 // Installer enables core api support.
-type Installer struct{}
+type Installer struct {
+	types     []any
+	typeIds   map[string]any
+	multipart *MultipartOptions
+}
 
 func (i *Installer) Install(b *setup.Builder) error {
 	if b.Tag(&featureTag) {
+		registry := &TypeRegistry{}
+		var errs error
+		for _, typ := range i.types {
+			if err := registry.RegisterType(reflect.TypeOf(typ)); err != nil {
+				errs = errors.Join(errs, err)
+			}
+		}
+		for typeId, typ := range i.typeIds {
+			if err := registry.Register(typeId, reflect.TypeOf(typ)); err != nil {
+				errs = errors.Join(errs, err)
+			}
+		}
+		if errs != nil {
+			return errs
+		}
 		b.Specs(
 			&Stash{},
 			&Scheduler{},
 			&PassThroughRouter{},
 			&batchRouter{},
-			&MultipartMapper{}).
+			&Coalescer{},
+			&Partitioner{},
+			&MultipartMapper{},
+			&typeFactory{}).
+			Observers(registry).
+			With(registry).
 			Handlers(NewStash(true))
+		if options := i.multipart; options != nil {
+			b.With(options)
+		}
 	}
 	return nil
 }
 
+// Types registers message types declaring a stable type id
+// through the TypeIdentifier interface or a typeId tag.
+func Types(types ...any) func(*Installer) {
+	return func(installer *Installer) {
+		installer.types = append(installer.types, types...)
+	}
+}
+
+// TypeIds registers message types with explicit stable type ids.
+func TypeIds(typeIds map[string]any) func(*Installer) {
+	return func(installer *Installer) {
+		if installer.typeIds == nil {
+			installer.typeIds = make(map[string]any, len(typeIds))
+		}
+		for typeId, typ := range typeIds {
+			installer.typeIds[typeId] = typ
+		}
+	}
+}
+
+// Multipart configures the spooling and size limits
+// of multipart messages.
+func Multipart(options MultipartOptions) func(*Installer) {
+	return func(installer *Installer) {
+		installer.multipart = &options
+	}
+}
+
 func Feature(config ...func(*Installer)) setup.Feature {
 	installer := &Installer{}
 	for _, configure := range config {
@@ -0,0 +1,234 @@
+package api
+
+import (
+	"maps"
+	"net/textproto"
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/internal"
+)
+
+// Headers is the envelope metadata of a Message.
+// Headers are assigned to every message sent through Post,
+// Send or Publish and can be injected into handlers as a
+// typed value.  Messages sent while handling another message
+// share its CorrelationId and use its MessageId as CausationId.
+type Headers struct {
+	MessageId     string
+	CorrelationId string
+	CausationId   string
+	Tenant        string
+	SentAt        time.Time
+	Deadline      time.Time
+	Custom        map[string]string
+}
+
+// Well known mime headers of the envelope.
+const (
+	HeaderMessageId     = "X-Message-Id"
+	HeaderCorrelationId = "X-Correlation-Id"
+	HeaderCausationId   = "X-Causation-Id"
+	HeaderTenant        = "X-Tenant"
+	HeaderSentAt        = "X-Sent-At"
+	HeaderDeadline      = "X-Deadline"
+	HeaderCustomPrefix  = "X-Header-"
+)
+
+// IsZero returns true if no headers are assigned.
+func (h Headers) IsZero() bool {
+	return h.MessageId == "" && h.CorrelationId == "" &&
+		h.CausationId == "" && h.Tenant == "" &&
+		h.SentAt.IsZero() && h.Deadline.IsZero() &&
+		len(h.Custom) == 0
+}
+
+// Get returns the custom header value for key.
+func (h Headers) Get(key string) string {
+	return h.Custom[key]
+}
+
+// Set assigns a custom header value.
+func (h *Headers) Set(key, value string) {
+	if h.Custom == nil {
+		h.Custom = make(map[string]string)
+	}
+	h.Custom[key] = value
+}
+
+// Expired returns true if the Deadline has passed.
+func (h Headers) Expired() bool {
+	return !h.Deadline.IsZero() && time.Now().After(h.Deadline)
+}
+
+// Child returns the headers of a message caused by
+// the message with these headers.
+func (h Headers) Child() Headers {
+	child := NewHeaders()
+	if id := h.CorrelationId; id != "" {
+		child.CorrelationId = id
+	} else if id = h.MessageId; id != "" {
+		child.CorrelationId = id
+	}
+	child.CausationId = h.MessageId
+	child.Tenant      = h.Tenant
+	child.Deadline    = h.Deadline
+	child.Custom      = maps.Clone(h.Custom)
+	return child
+}
+
+// Merge returns a copy of the headers with any
+// unassigned values taken from other.
+func (h Headers) Merge(other Headers) Headers {
+	h.MessageId     = internal.DefaultValue(h.MessageId, other.MessageId)
+	h.CorrelationId = internal.DefaultValue(h.CorrelationId, other.CorrelationId)
+	h.CausationId   = internal.DefaultValue(h.CausationId, other.CausationId)
+	h.Tenant        = internal.DefaultValue(h.Tenant, other.Tenant)
+	if h.SentAt.IsZero() {
+		h.SentAt = other.SentAt
+	}
+	if h.Deadline.IsZero() {
+		h.Deadline = other.Deadline
+	}
+	if len(other.Custom) > 0 {
+		custom := maps.Clone(other.Custom)
+		maps.Copy(custom, h.Custom)
+		h.Custom = custom
+	}
+	return h
+}
+
+// NewHeaders creates the headers of a message
+// that starts a new conversation.
+func NewHeaders() Headers {
+	id := uuid.NewString()
+	return Headers{
+		MessageId:     id,
+		CorrelationId: id,
+		SentAt:        time.Now().UTC(),
+	}
+}
+
+// CurrentHeaders returns the headers of the message
+// being handled, if any.
+func CurrentHeaders(handler miruken.Handler) (Headers, bool) {
+	return StashGet[Headers](handler)
+}
+
+// WriteHeaders writes the headers into a mime header.
+func WriteHeaders(
+	headers Headers,
+	header  textproto.MIMEHeader,
+) {
+	if header == nil {
+		panic("header cannot be nil")
+	}
+	setHeader(header, HeaderMessageId, headers.MessageId)
+	setHeader(header, HeaderCorrelationId, headers.CorrelationId)
+	setHeader(header, HeaderCausationId, headers.CausationId)
+	setHeader(header, HeaderTenant, headers.Tenant)
+	if !headers.SentAt.IsZero() {
+		header.Set(HeaderSentAt, headers.SentAt.Format(time.RFC3339Nano))
+	}
+	if !headers.Deadline.IsZero() {
+		header.Set(HeaderDeadline, headers.Deadline.Format(time.RFC3339Nano))
+	}
+	for key, value := range headers.Custom {
+		header.Set(HeaderCustomPrefix+key, value)
+	}
+}
+
+// ReadHeaders reads the headers from a mime header.
+// Custom header keys are returned in canonical form.
+func ReadHeaders(
+	header textproto.MIMEHeader,
+) (headers Headers, err error) {
+	headers.MessageId     = header.Get(HeaderMessageId)
+	headers.CorrelationId = header.Get(HeaderCorrelationId)
+	headers.CausationId   = header.Get(HeaderCausationId)
+	headers.Tenant        = header.Get(HeaderTenant)
+	if sentAt := header.Get(HeaderSentAt); sentAt != "" {
+		if headers.SentAt, err = time.Parse(time.RFC3339Nano, sentAt); err != nil {
+			return
+		}
+	}
+	if deadline := header.Get(HeaderDeadline); deadline != "" {
+		if headers.Deadline, err = time.Parse(time.RFC3339Nano, deadline); err != nil {
+			return
+		}
+	}
+	for key, values := range header {
+		if custom, ok := strings.CutPrefix(key, HeaderCustomPrefix); ok && custom != "" && len(values) > 0 {
+			headers.Set(custom, values[0])
+		}
+	}
+	return
+}
+
+// RemoveHeaders removes the envelope headers from a mime header.
+func RemoveHeaders(header textproto.MIMEHeader) {
+	for key := range header {
+		if isEnvelopeHeader(key) {
+			header.Del(key)
+		}
+	}
+}
+
+func isEnvelopeHeader(key string) bool {
+	switch key {
+	case HeaderMessageId, HeaderCorrelationId, HeaderCausationId,
+		HeaderTenant, HeaderSentAt, HeaderDeadline:
+		return true
+	}
+	return strings.HasPrefix(key, HeaderCustomPrefix)
+}
+
+func setHeader(header textproto.MIMEHeader, key, value string) {
+	if value != "" {
+		header.Set(key, value)
+	}
+}
+
+// envelope unwraps a Message and adds a Stash holding
+// the headers of the message to the handler.
+// Explicit Message headers are preferred over the headers
+// derived from the message currently being handled.
+// The handler is kept as the sender of messages without
+// explicit headers so they can be coalesced.
+func envelope(
+	handler miruken.Handler,
+	message any,
+) (miruken.Handler, any) {
+	var headers Headers
+	sender := handler
+	if parent, ok := CurrentHeaders(handler); ok {
+		headers = parent.Child()
+	} else {
+		headers = NewHeaders()
+	}
+	if msg, ok := message.(Message); ok {
+		message = msg.Payload
+		if explicit := msg.Headers; !explicit.IsZero() {
+			if explicit.MessageId != "" && explicit.CorrelationId == "" {
+				explicit.CorrelationId = explicit.MessageId
+			}
+			headers = explicit.Merge(headers)
+			sender  = nil
+		}
+	}
+	if internal.IsNil(message) {
+		panic("message cannot be nil")
+	}
+	stash := NewStash(false)
+	stash.data[headersKey] = headers
+	stash.data[senderKey{}] = sender
+	return miruken.AddHandlers(handler, stash), message
+}
+
+// senderKey stashes the Handler a message was sent through.
+type senderKey struct{}
+
+var headersKey = reflect.TypeFor[Headers]()
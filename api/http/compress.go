@@ -0,0 +1,190 @@
+package http
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/miruken-go/miruken"
+)
+
+type (
+	// Encoding compresses and decompresses http content
+	// identified by a Content-Encoding token.
+	Encoding interface {
+		Name() string
+		NewWriter(io.Writer) (io.WriteCloser, error)
+		NewReader(io.Reader) (io.ReadCloser, error)
+	}
+
+	gzipEncoding    struct{}
+	deflateEncoding struct{}
+
+	// decodedBody closes the decoder and the encoded body.
+	decodedBody struct {
+		io.ReadCloser
+		body io.ReadCloser
+	}
+)
+
+// DefaultMinCompressSize is the smallest content worth compressing.
+const DefaultMinCompressSize = 1024
+
+var (
+	// Gzip encodes content using gzip (RFC 1952).
+	Gzip Encoding = gzipEncoding{}
+
+	// Deflate encodes content using zlib deflate (RFC 1950).
+	Deflate Encoding = deflateEncoding{}
+)
+
+// gzipEncoding
+
+func (gzipEncoding) Name() string {
+	return "gzip"
+}
+
+func (gzipEncoding) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+
+func (gzipEncoding) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+// deflateEncoding
+
+func (deflateEncoding) Name() string {
+	return "deflate"
+}
+
+func (deflateEncoding) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return zlib.NewWriter(w), nil
+}
+
+func (deflateEncoding) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return zlib.NewReader(r)
+}
+
+// decodedBody
+
+func (b *decodedBody) Close() error {
+	err := b.ReadCloser.Close()
+	if cerr := b.body.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
+
+// Compress returns a Policy that compresses request bodies with
+// the first encoding and accepts responses compressed with any of
+// them.  Gzip and Deflate are used if no encodings are supplied.
+// Bodies smaller than DefaultMinCompressSize are sent unchanged.
+func Compress(encodings ...Encoding) Policy {
+	if len(encodings) == 0 {
+		encodings = []Encoding{Gzip, Deflate}
+	}
+	names := make([]string, len(encodings))
+	for i, encoding := range encodings {
+		names[i] = encoding.Name()
+	}
+	accept := strings.Join(names, ", ")
+	return PolicyFunc(func(
+		req *http.Request,
+		_   miruken.Handler,
+		next func() (*http.Response, error),
+	) (*http.Response, error) {
+		if err := compressRequest(req, encodings[0]); err != nil {
+			return nil, err
+		}
+		if req.Header.Get("Accept-Encoding") == "" {
+			req.Header.Set("Accept-Encoding", accept)
+		}
+		res, err := next()
+		if err != nil || res == nil {
+			return res, err
+		}
+		if err := decompressResponse(res, encodings); err != nil {
+			_ = res.Body.Close()
+			return nil, err
+		}
+		return res, nil
+	})
+}
+
+// EncodingNamed returns the encoding matching a Content-Encoding token.
+func EncodingNamed(
+	name      string,
+	encodings []Encoding,
+) Encoding {
+	name = strings.TrimSpace(name)
+	for _, encoding := range encodings {
+		if strings.EqualFold(encoding.Name(), name) {
+			return encoding
+		}
+	}
+	return nil
+}
+
+func compressRequest(
+	req      *http.Request,
+	encoding Encoding,
+) error {
+	if req.Body == nil || req.Body == http.NoBody ||
+		req.Header.Get("Content-Encoding") != "" {
+		return nil
+	}
+	if size := req.ContentLength; size >= 0 && size < DefaultMinCompressSize {
+		return nil
+	}
+	var b bytes.Buffer
+	w, err := encoding.NewWriter(&b)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(w, req.Body)
+	if cerr := w.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := req.Body.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return err
+	}
+	body := b.Bytes()
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	req.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	req.ContentLength = int64(len(body))
+	req.Header.Set("Content-Encoding", encoding.Name())
+	return nil
+}
+
+func decompressResponse(
+	res       *http.Response,
+	encodings []Encoding,
+) error {
+	name := res.Header.Get("Content-Encoding")
+	if name == "" {
+		return nil
+	}
+	encoding := EncodingNamed(name, encodings)
+	if encoding == nil {
+		return nil
+	}
+	body, err := encoding.NewReader(res.Body)
+	if err != nil {
+		return err
+	}
+	res.Body = &decodedBody{body, res.Body}
+	res.Header.Del("Content-Encoding")
+	res.Header.Del("Content-Length")
+	res.ContentLength = -1
+	res.Uncompressed = true
+	return nil
+}
@@ -17,7 +17,7 @@ func (i *Installer) DependsOn() []setup.Feature {
 
 func (i *Installer) Install(b *setup.Builder) error {
 	if b.Tag(&featureTag) {
-		b.Specs(&Router{})
+		b.Specs(&Router{}, &ProblemMapper{})
 	}
 	return nil
 }
@@ -0,0 +1,98 @@
+package test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/miruken-go/miruken/api/http/httpsrv"
+	"github.com/miruken-go/miruken/api/http/httpsrv/auth"
+	"github.com/miruken-go/miruken/security"
+	"github.com/miruken-go/miruken/security/login"
+	"github.com/miruken-go/miruken/security/principal"
+	x5092 "github.com/miruken-go/miruken/security/x509"
+	"github.com/miruken-go/miruken/setup"
+)
+
+func (suite *MiddlewareTestSuite) TestClientCertificate() {
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	suite.Require().Nil(err)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDer, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	suite.Require().Nil(err)
+	ca, err := x509.ParseCertificate(caDer)
+	suite.Require().Nil(err)
+
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	suite.Require().Nil(err)
+	clientDer, err := x509.CreateCertificate(rand.Reader, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject: pkix.Name{
+			CommonName:         "billing-service",
+			OrganizationalUnit: []string{"finance"},
+		},
+		DNSNames:    []string{"billing.internal"},
+		NotBefore:   time.Now().Add(-time.Minute),
+		NotAfter:    time.Now().Add(time.Hour),
+		KeyUsage:    x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, &clientKey.PublicKey, caKey)
+	suite.Require().Nil(err)
+	clientCert := tls.Certificate{Certificate: [][]byte{clientDer}, PrivateKey: clientKey}
+
+	ctx, _ := setup.New(x5092.Feature()).Context()
+	defer ctx.End(nil)
+
+	handler := httpsrv.Use(ctx,
+		func(w http.ResponseWriter, r *http.Request, sub security.Subject) {
+			user, _ := principal.First[principal.User](sub)
+			id, _ := principal.First[principal.Id](sub)
+			group, _ := principal.First[principal.Group](sub)
+			_, _ = fmt.Fprintf(w, "Hello %s (%s) in %s", user, id, group)
+		}, auth.WithFlow(login.Flow{{Module: "login.x509"}}).
+			ClientCertificate().
+			Required())
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	srv := httptest.NewUnstartedServer(handler)
+	srv.TLS = &tls.Config{ClientAuth: tls.VerifyClientCertIfGiven, ClientCAs: pool}
+	srv.StartTLS()
+	defer srv.Close()
+
+	suite.Run("Authenticate", func() {
+		transport := srv.Client().Transport.(*http.Transport).Clone()
+		transport.TLSClientConfig.Certificates = []tls.Certificate{clientCert}
+		client := &http.Client{Transport: transport}
+		resp, err := client.Get(srv.URL)
+		suite.Require().Nil(err)
+		defer func() { _ = resp.Body.Close() }()
+		suite.Equal(200, resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		suite.Equal("Hello billing-service (billing.internal) in finance", string(body))
+	})
+
+	suite.Run("Deny", func() {
+		resp, err := srv.Client().Get(srv.URL)
+		suite.Require().Nil(err)
+		_ = resp.Body.Close()
+		suite.Equal(401, resp.StatusCode)
+	})
+}
@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"net/http"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/security/login/callback"
+)
+
+// ClientCertificate is a http authentication Scheme that uses
+// the client certificate verified by mutual TLS to protect
+// resources.  Certificates not verified are ignored.
+type ClientCertificate struct{}
+
+func (c ClientCertificate) Accept(
+	r *http.Request,
+) (miruken.Handler, error, bool) {
+	if state := r.TLS; state != nil {
+		if chains := state.VerifiedChains; len(chains) > 0 && len(chains[0]) > 0 {
+			return callback.CertificateHandler{Certificate: chains[0][0]}, nil, true
+		}
+	}
+	return nil, nil, false
+}
+
+func (c ClientCertificate) Challenge(
+	w   http.ResponseWriter,
+	r   *http.Request,
+	err error,
+) int {
+	return http.StatusUnauthorized
+}
+
+// ClientCertificate configures an authentication flow to use
+// verified client certificates.
+func (b *FlowBuilder) ClientCertificate() *Authentication {
+	return b.Scheme(ClientCertificate{})
+}
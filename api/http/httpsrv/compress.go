@@ -0,0 +1,297 @@
+package httpsrv
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+
+	http2 "github.com/miruken-go/miruken/api/http"
+)
+
+type (
+	// Compression decompresses request bodies and compresses
+	// responses using the encodings preferred by the client.
+	// Decompressed request bodies larger than the maximum size
+	// are rejected with 413 Request Entity Too Large.
+	// Responses smaller than the minimum size or with a content
+	// type not allowed are written unchanged.
+	// Since Middleware cannot replace the http.ResponseWriter,
+	// Compression wraps the http.Handler instead.
+	Compression struct {
+		encodings    []http2.Encoding
+		minSize      int
+		maxSize      int64
+		contentTypes []string
+	}
+
+	// compressWriter buffers the response until it can decide
+	// whether to compress it.
+	compressWriter struct {
+		http.ResponseWriter
+		c          *Compression
+		encoding   http2.Encoding
+		buf        bytes.Buffer
+		writer     io.WriteCloser
+		statusCode int
+		decided    bool
+	}
+)
+
+// DefaultMaxDecompressSize is the largest decompressed request body.
+const DefaultMaxDecompressSize = 10 << 20
+
+// DefaultCompressContentTypes are the content types compressed by default.
+// Types ending in "/" match all subtypes.
+var DefaultCompressContentTypes = []string{
+	"application/json",
+	"application/x-ndjson",
+	"application/xml",
+	"application/yaml",
+	"text/",
+}
+
+// Compress builds Compression for the encodings in order of
+// preference.  Gzip and Deflate are used if none are supplied.
+func Compress(encodings ...http2.Encoding) *Compression {
+	if len(encodings) == 0 {
+		encodings = []http2.Encoding{http2.Gzip, http2.Deflate}
+	}
+	return &Compression{
+		encodings:    encodings,
+		minSize:      http2.DefaultMinCompressSize,
+		maxSize:      DefaultMaxDecompressSize,
+		contentTypes: DefaultCompressContentTypes,
+	}
+}
+
+// MinSize sets the smallest response to compress.
+func (c *Compression) MinSize(size int) *Compression {
+	c.minSize = size
+	return c
+}
+
+// MaxSize sets the largest request body after decompression.
+func (c *Compression) MaxSize(size int64) *Compression {
+	c.maxSize = size
+	return c
+}
+
+// ContentTypes sets the response content types to compress.
+func (c *Compression) ContentTypes(contentTypes ...string) *Compression {
+	c.contentTypes = contentTypes
+	return c
+}
+
+// Handler wraps the http.Handler with compression.
+func (c *Compression) Handler(handler http.Handler) http.Handler {
+	if handler == nil {
+		panic("handler cannot be nil")
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c.serve(w, r, handler)
+	})
+}
+
+func (c *Compression) serve(
+	w       http.ResponseWriter,
+	r       *http.Request,
+	handler http.Handler,
+) {
+	if name := r.Header.Get("Content-Encoding"); name != "" && !isIdentity(name) {
+		encoding := http2.EncodingNamed(name, c.encodings)
+		if encoding == nil {
+			w.Header().Set("Accept-Encoding", c.acceptEncoding())
+			http.Error(w, "415 unsupported 'Content-Encoding'", http.StatusUnsupportedMediaType)
+			return
+		}
+		body, err := encoding.NewReader(r.Body)
+		if err != nil {
+			http.Error(w, "400 invalid compressed body", http.StatusBadRequest)
+			return
+		}
+		defer func() { _ = body.Close() }()
+		r.Body = http.MaxBytesReader(w, body, c.maxSize)
+		r.ContentLength = -1
+		r.Header.Del("Content-Encoding")
+		r.Header.Del("Content-Length")
+	}
+
+	w.Header().Add("Vary", "Accept-Encoding")
+	encoding := c.negotiate(r.Header.Get("Accept-Encoding"))
+	if encoding == nil {
+		handler.ServeHTTP(w, r)
+		return
+	}
+
+	cw := &compressWriter{ResponseWriter: w, c: c, encoding: encoding}
+	defer cw.close()
+	handler.ServeHTTP(cw, r)
+}
+
+// negotiate selects the encoding with the highest quality
+// accepted by the client, preferring the server order on ties.
+func (c *Compression) negotiate(accept string) http2.Encoding {
+	if accept == "" {
+		return nil
+	}
+	var best http2.Encoding
+	var bestQ float64
+	for _, encoding := range c.encodings {
+		q, wildcard := -1.0, -1.0
+		for _, spec := range strings.Split(accept, ",") {
+			name, params, _ := strings.Cut(spec, ";")
+			name = strings.TrimSpace(name)
+			quality := 1.0
+			if qs, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+				if v, err := strconv.ParseFloat(qs, 64); err == nil {
+					quality = v
+				}
+			}
+			if strings.EqualFold(name, encoding.Name()) {
+				q = quality
+			} else if name == "*" {
+				wildcard = quality
+			}
+		}
+		if q < 0 {
+			q = wildcard
+		}
+		if q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+func (c *Compression) acceptEncoding() string {
+	names := make([]string, len(c.encodings))
+	for i, encoding := range c.encodings {
+		names[i] = encoding.Name()
+	}
+	return strings.Join(names, ", ")
+}
+
+// allows determines if the content type should be compressed.
+func (c *Compression) allows(contentType string) bool {
+	if contentType == "" {
+		return false
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	for _, allowed := range c.contentTypes {
+		if strings.HasSuffix(allowed, "/") {
+			if strings.HasPrefix(mediaType, allowed) {
+				return true
+			}
+		} else if mediaType == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// compressWriter
+
+func (w *compressWriter) WriteHeader(statusCode int) {
+	if w.statusCode == 0 {
+		w.statusCode = statusCode
+	}
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	if w.statusCode == 0 {
+		w.statusCode = http.StatusOK
+	}
+	if !w.decided {
+		if w.buf.Len()+len(p) < w.c.minSize {
+			return w.buf.Write(p)
+		}
+		if err := w.decide(true); err != nil {
+			return 0, err
+		}
+	}
+	if writer := w.writer; writer != nil {
+		return writer.Write(p)
+	}
+	return w.ResponseWriter.Write(p)
+}
+
+// Flush starts compressing since the final size is unknown.
+func (w *compressWriter) Flush() {
+	if !w.decided {
+		if w.statusCode == 0 {
+			w.statusCode = http.StatusOK
+		}
+		if err := w.decide(true); err != nil {
+			return
+		}
+	}
+	if flusher, ok := w.writer.(interface{ Flush() error }); ok {
+		_ = flusher.Flush()
+	}
+	_ = http.NewResponseController(w.ResponseWriter).Flush()
+}
+
+func (w *compressWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	return http.NewResponseController(w.ResponseWriter).Hijack()
+}
+
+func (w *compressWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// decide writes the header and buffered content, compressing
+// if the response is eligible.
+func (w *compressWriter) decide(compress bool) error {
+	w.decided = true
+	header := w.Header()
+	if compress && w.statusCode != http.StatusNoContent &&
+		w.statusCode != http.StatusNotModified &&
+		header.Get("Content-Encoding") == "" &&
+		w.c.allows(header.Get("Content-Type")) {
+		writer, err := w.encoding.NewWriter(w.ResponseWriter)
+		if err != nil {
+			return err
+		}
+		w.writer = writer
+		header.Set("Content-Encoding", w.encoding.Name())
+		header.Del("Content-Length")
+	}
+	w.ResponseWriter.WriteHeader(w.statusCode)
+	if w.buf.Len() > 0 {
+		var err error
+		if writer := w.writer; writer != nil {
+			_, err = writer.Write(w.buf.Bytes())
+		} else {
+			_, err = w.ResponseWriter.Write(w.buf.Bytes())
+		}
+		w.buf.Reset()
+		return err
+	}
+	return nil
+}
+
+// close completes the response, writing content below the
+// minimum size unchanged.
+func (w *compressWriter) close() {
+	if !w.decided {
+		if w.statusCode == 0 {
+			return
+		}
+		_ = w.decide(false)
+	}
+	if writer := w.writer; writer != nil {
+		_ = writer.Close()
+	}
+}
+
+func isIdentity(encoding string) bool {
+	return strings.EqualFold(strings.TrimSpace(encoding), "identity")
+}
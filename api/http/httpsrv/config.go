@@ -1,6 +1,7 @@
 package httpsrv
 
 import (
+	"crypto/tls"
 	"net/http"
 	"time"
 
@@ -15,19 +16,55 @@ type Config struct {
 	WriteTimeout      time.Duration
 	IdleTimeout       time.Duration
 	MaxHeaderBytes    int
+	TLS               *TLSConfig
 }
 
 // New creates a new http.Server with the provided configuration.
+// Panics if the TLS configuration is invalid.
 func New(
 	handler http.Handler,
 	config  *Config,
 ) *http.Server {
+	srv, err := newServer(handler, config)
+	if err != nil {
+		panic(err)
+	}
+	return srv
+}
+
+// ListenAndServe creates and starts a http.Server with the provided configuration.
+// The server uses https if TLS is configured.
+func ListenAndServe(
+	handler http.Handler,
+	config  *Config,
+) error {
+	srv, err := newServer(handler, config)
+	if err != nil {
+		return err
+	}
+	if srv.TLSConfig != nil {
+		return srv.ListenAndServeTLS("", "")
+	}
+	return srv.ListenAndServe()
+}
+
+func newServer(
+	handler http.Handler,
+	config  *Config,
+) (*http.Server, error) {
 	if handler == nil {
 		panic("handler cannot be nil")
 	}
 	if config == nil {
 		config = &Config{}
 	}
+	var tlsConfig *tls.Config
+	if t := config.TLS; t != nil {
+		var err error
+		if tlsConfig, err = t.Config(); err != nil {
+			return nil, err
+		}
+	}
 	return &http.Server{
 		Addr:              internal.DefaultValue(config.Addr, ":8080"),
 		Handler:           handler,
@@ -36,13 +73,6 @@ func New(
 		WriteTimeout:      internal.DefaultValue(config.WriteTimeout, 2*time.Second),
 		IdleTimeout:       internal.DefaultValue(config.IdleTimeout, 30*time.Second),
 		MaxHeaderBytes:    internal.DefaultValue(config.MaxHeaderBytes, 1024),
-	}
-}
-
-// ListenAndServe creates and starts a http.Server with the provided configuration.
-func ListenAndServe(
-	handler http.Handler,
-	config  *Config,
-) error {
-	return New(handler, nil).ListenAndServe()
+		TLSConfig:         tlsConfig,
+	}, nil
 }
\ No newline at end of file
@@ -0,0 +1,210 @@
+package httpsrv
+
+import (
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/args"
+	"github.com/miruken-go/miruken/config"
+	"github.com/miruken-go/miruken/internal/slices"
+	"github.com/miruken-go/miruken/provides"
+)
+
+type (
+	// CorsOptions configures cross-origin resource sharing.
+	// Origins may contain a * wildcard to match any characters
+	// up to the next "/", e.g. https://*.example.com.  A single
+	// "*" allows all origins but never with credentials since any
+	// site could then make credentialed requests.  Methods default
+	// to GET, HEAD and POST and Headers to Content-Type.
+	CorsOptions struct {
+		AllowedOrigins   []string
+		AllowedMethods   []string
+		AllowedHeaders   []string
+		ExposedHeaders   []string
+		AllowCredentials bool
+		MaxAge           time.Duration
+	}
+
+	// Cors is Middleware that answers preflight requests and
+	// adds the cross-origin headers to allowed requests.
+	// Preflight requests are answered before reaching the
+	// Handler so they are not rejected as unsupported methods.
+	Cors struct {
+		options   CorsOptions
+		anyOrigin bool
+		origins   []string
+		patterns  []*regexp.Regexp
+		anyHeader bool
+		methods   string
+		headers   string
+		exposed   string
+	}
+)
+
+var (
+	defaultCorsMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	defaultCorsHeaders = []string{"Content-Type"}
+)
+
+func (c *Cors) Constructor(
+	_ *struct {
+		provides.It
+		provides.Single
+	  },
+	_ *struct {
+		args.Optional
+		config.Load `path:"cors"`
+	  }, options CorsOptions,
+) {
+	c.init(options)
+}
+
+func (c *Cors) ServeHTTP(
+	w http.ResponseWriter,
+	r *http.Request,
+	h miruken.Handler,
+	n func(miruken.Handler),
+) {
+	origin := r.Header.Get("Origin")
+	if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+		c.preflight(w, r, origin)
+		return
+	}
+	if origin != "" {
+		header := w.Header()
+		header.Add("Vary", "Origin")
+		if c.allowsOrigin(origin) {
+			c.allowOrigin(header, origin)
+			if exposed := c.exposed; exposed != "" {
+				header.Set("Access-Control-Expose-Headers", exposed)
+			}
+		}
+	}
+	n(h)
+}
+
+func (c *Cors) preflight(
+	w      http.ResponseWriter,
+	r      *http.Request,
+	origin string,
+) {
+	header := w.Header()
+	header.Add("Vary", "Origin")
+	header.Add("Vary", "Access-Control-Request-Method")
+	header.Add("Vary", "Access-Control-Request-Headers")
+	method := r.Header.Get("Access-Control-Request-Method")
+	requested := requestedHeaders(r)
+	if origin == "" || !c.allowsOrigin(origin) ||
+		!slices.Contains(c.options.AllowedMethods, strings.ToUpper(method)) ||
+		!c.allowsHeaders(requested) {
+		w.WriteHeader(http.StatusForbidden)
+		return
+	}
+	c.allowOrigin(header, origin)
+	header.Set("Access-Control-Allow-Methods", c.methods)
+	if len(requested) > 0 {
+		if c.anyHeader {
+			header.Set("Access-Control-Allow-Headers", strings.Join(requested, ", "))
+		} else {
+			header.Set("Access-Control-Allow-Headers", c.headers)
+		}
+	}
+	if maxAge := c.options.MaxAge; maxAge > 0 {
+		header.Set("Access-Control-Max-Age", strconv.Itoa(int(maxAge.Seconds())))
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (c *Cors) allowOrigin(header http.Header, origin string) {
+	if c.anyOrigin {
+		header.Set("Access-Control-Allow-Origin", "*")
+	} else {
+		header.Set("Access-Control-Allow-Origin", origin)
+	}
+	if c.options.AllowCredentials {
+		header.Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (c *Cors) allowsOrigin(origin string) bool {
+	if c.anyOrigin {
+		return true
+	}
+	origin = strings.ToLower(origin)
+	if slices.Contains(c.origins, origin) {
+		return true
+	}
+	for _, pattern := range c.patterns {
+		if pattern.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func (c *Cors) allowsHeaders(headers []string) bool {
+	if c.anyHeader {
+		return true
+	}
+	for _, header := range headers {
+		if !slices.Contains(c.options.AllowedHeaders, http.CanonicalHeaderKey(header)) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Cors) init(options CorsOptions) {
+	if len(options.AllowedMethods) == 0 {
+		options.AllowedMethods = defaultCorsMethods
+	}
+	if len(options.AllowedHeaders) == 0 {
+		options.AllowedHeaders = defaultCorsHeaders
+	}
+	options.AllowedMethods = slices.Map[string, string](options.AllowedMethods, strings.ToUpper)
+	options.AllowedHeaders = slices.Map[string, string](options.AllowedHeaders, http.CanonicalHeaderKey)
+	c.anyHeader = slices.Contains(options.AllowedHeaders, "*")
+	for _, origin := range options.AllowedOrigins {
+		origin = strings.ToLower(strings.TrimSpace(origin))
+		switch {
+		case origin == "*":
+			c.anyOrigin = true
+		case strings.Contains(origin, "*"):
+			pattern := strings.ReplaceAll(regexp.QuoteMeta(origin), `\*`, `[^/]*`)
+			c.patterns = append(c.patterns, regexp.MustCompile("^"+pattern+"$"))
+		case origin != "":
+			c.origins = append(c.origins, origin)
+		}
+	}
+	if c.anyOrigin {
+		options.AllowCredentials = false
+	}
+	c.options = options
+	c.methods = strings.Join(options.AllowedMethods, ", ")
+	c.headers = strings.Join(options.AllowedHeaders, ", ")
+	c.exposed = strings.Join(options.ExposedHeaders, ", ")
+}
+
+// NewCors creates Cors Middleware from the options.
+func NewCors(options CorsOptions) *Cors {
+	c := &Cors{}
+	c.init(options)
+	return c
+}
+
+func requestedHeaders(r *http.Request) []string {
+	var headers []string
+	for _, value := range r.Header.Values("Access-Control-Request-Headers") {
+		for _, header := range strings.Split(value, ",") {
+			if header = strings.TrimSpace(header); header != "" {
+				headers = append(headers, header)
+			}
+		}
+	}
+	return headers
+}
@@ -35,6 +35,24 @@ func (s *StatusCodeMapper) UnknownTypeId(
 	return http.StatusBadRequest
 }
 
+func (s *StatusCodeMapper) ContentTooLarge(
+	_ *struct {
+		maps.It
+		maps.Format `to:"http:status-code"`
+	  }, _ *api.ContentTooLargeError,
+) int {
+	return http.StatusRequestEntityTooLarge
+}
+
+func (s *StatusCodeMapper) MaxBytes(
+	_ *struct {
+		maps.It
+		maps.Format `to:"http:status-code"`
+	  }, _ *http.MaxBytesError,
+) int {
+	return http.StatusRequestEntityTooLarge
+}
+
 func (s *StatusCodeMapper) Validation(
 	_ *struct {
 		maps.It
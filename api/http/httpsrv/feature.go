@@ -6,7 +6,10 @@ import (
 )
 
 // Installer configures http server support
-type Installer struct{}
+type Installer struct {
+	options *PolyOptions
+	host    *HostOptions
+}
 
 func (i *Installer) DependsOn() []setup.Feature {
 	return []setup.Feature{http.Feature()}
@@ -14,9 +17,20 @@ func (i *Installer) DependsOn() []setup.Feature {
 
 func (i *Installer) Install(b *setup.Builder) error {
 	if b.Tag(&featureTag) {
+		negotiator, routes := &Negotiator{}, &RouteTable{}
 		b.Specs(
 			&PolyHandler{},
-			&StatusCodeMapper{})
+			&StatusCodeMapper{},
+			&Cors{},
+			&HealthHandler{}).
+			Observers(negotiator, routes).
+			With(negotiator, routes)
+		if options := i.options; options != nil {
+			b.With(options)
+		}
+		if host := i.host; host != nil {
+			b.Specs(&Host{}).With(host)
+		}
 	}
 	return nil
 }
@@ -32,4 +46,37 @@ func Feature(config ...func(*Installer)) setup.Feature {
 	return installer
 }
 
+// StreamParallelism bounds the messages of a stream
+// processed at once by the PolyHandler.
+func StreamParallelism(limit int) func(*Installer) {
+	return func(installer *Installer) {
+		installer.polyOptions().StreamParallelism = limit
+	}
+}
+
+// Problems renders all errors as RFC 7807 problem details.
+// Without it, problem details are only rendered when
+// requested by the Accept header.
+func Problems() func(*Installer) {
+	return func(installer *Installer) {
+		installer.polyOptions().Problems = true
+	}
+}
+
+// Serve starts http servers when the application starts
+// and shuts them down gracefully when the root context ends.
+// Additional servers are loaded from the "http" configuration.
+func Serve(options HostOptions) func(*Installer) {
+	return func(installer *Installer) {
+		installer.host = &options
+	}
+}
+
+func (i *Installer) polyOptions() *PolyOptions {
+	if i.options == nil {
+		i.options = &PolyOptions{}
+	}
+	return i.options
+}
+
 var featureTag byte
@@ -0,0 +1,81 @@
+package httpsrv
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/args"
+	"github.com/miruken-go/miruken/health"
+)
+
+// HealthHandler is a Handler exposing the health.Service.
+// Requests to paths ending in /healthz report liveness and
+// /readyz report readiness as json.  Unhealthy reports are
+// answered with 503 Service Unavailable.  Requires the
+// health.Feature to be installed.
+//
+//	mux := http.NewServeMux()
+//	mux.Handle("/", httpsrv.Api(ctx))
+//	mux.Handle("/healthz", httpsrv.Use(ctx, httpsrv.H[*httpsrv.HealthHandler]()))
+//	mux.Handle("/readyz", httpsrv.Use(ctx, httpsrv.H[*httpsrv.HealthHandler]()))
+type HealthHandler struct {
+	service *health.Service
+	logger  logr.Logger
+}
+
+const (
+	LivenessPath  = "/healthz"
+	ReadinessPath = "/readyz"
+)
+
+func (h *HealthHandler) Constructor(
+	service *health.Service,
+	_ *struct{ args.Optional }, logger logr.Logger,
+) {
+	if logger == (logr.Logger{}) {
+		logger = logr.Discard()
+	}
+	h.service = service
+	h.logger  = logger
+}
+
+func (h *HealthHandler) ServeHTTP(
+	w        http.ResponseWriter,
+	r        *http.Request,
+	composer miruken.Handler,
+) {
+	path := strings.TrimSuffix(r.URL.Path, "/")
+	live, ready := strings.HasSuffix(path, LivenessPath), strings.HasSuffix(path, ReadinessPath)
+	if !live && !ready {
+		http.NotFound(w, r)
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		w.Header().Set("Allow", "GET, HEAD")
+		http.Error(w, "405 method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var report health.Report
+	if live {
+		report = h.service.Live(r.Context(), composer)
+	} else {
+		report = h.service.Ready(r.Context(), composer)
+	}
+	statusCode := http.StatusOK
+	if report.Status != health.StatusUp {
+		statusCode = http.StatusServiceUnavailable
+	}
+	header := w.Header()
+	header.Set("Content-Type", "application/json")
+	header.Set("Cache-Control", "no-store")
+	w.WriteHeader(statusCode)
+	if r.Method == http.MethodHead {
+		return
+	}
+	if err := json.NewEncoder(w).Encode(report); err != nil {
+		h.logger.Error(err, "unable to write health report")
+	}
+}
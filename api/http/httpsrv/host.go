@@ -0,0 +1,192 @@
+package httpsrv
+
+import (
+	context2 "context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/args"
+	"github.com/miruken-go/miruken/config"
+	"github.com/miruken-go/miruken/context"
+	"github.com/miruken-go/miruken/promise"
+	"github.com/miruken-go/miruken/provides"
+	"github.com/miruken-go/miruken/setup"
+)
+
+type (
+	// HostConfig lists the servers loaded from configuration.
+	HostConfig struct {
+		Servers []Config
+	}
+
+	// HostOptions configures the servers started by the Host.
+	// Servers are added to those loaded from configuration.
+	// The PolyHandler serves requests if no Handler is provided.
+	HostOptions struct {
+		Servers    []Config
+		Handler    any
+		Middleware []any
+	}
+
+	// Host is a setup.Bootstrap that starts the configured http
+	// servers.  The servers are shutdown gracefully when the root
+	// context starts ending so in-flight requests can complete.
+	Host struct {
+		configs  []Config
+		options  HostOptions
+		timeout  time.Duration
+		logger   logr.Logger
+		servers  []*http.Server
+		addrs    []net.Addr
+		serving  sync.WaitGroup
+		shutdown sync.Once
+		err      error
+		lock     sync.Mutex
+	}
+)
+
+var ErrHostRequiresContext = errors.New("httpsrv: the Host must be started with a context.Context")
+
+func (h *Host) Constructor(
+	_ *struct {
+		provides.It
+		provides.Single
+	  },
+	_ *struct {
+		args.Optional
+		config.Load `path:"http"`
+	  }, cfg HostConfig,
+	_ *struct{ args.Optional }, options *HostOptions,
+	_ *struct {
+		args.Optional
+		args.FromOptions
+	  }, setupOptions setup.Options,
+	_ *struct{ args.Optional }, logger logr.Logger,
+) {
+	if logger == (logr.Logger{}) {
+		logger = logr.Discard()
+	}
+	if options != nil {
+		h.options = *options
+	}
+	h.configs = append(cfg.Servers, h.options.Servers...)
+	if len(h.configs) == 0 {
+		h.configs = []Config{{}}
+	}
+	h.timeout = setupOptions.ShutdownTimeout
+	h.logger  = logger
+}
+
+// Addrs returns the addresses the servers are listening on.
+func (h *Host) Addrs() []net.Addr {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return append([]net.Addr(nil), h.addrs...)
+}
+
+func (h *Host) Startup(
+	ctx      context2.Context,
+	composer miruken.Handler,
+) *promise.Promise[struct{}] {
+	root, ok := composer.(*context.Context)
+	if !ok {
+		return promise.Reject[struct{}](ErrHostRequiresContext)
+	}
+	var handler http.Handler
+	if hh := h.options.Handler; hh != nil {
+		handler = Use(root, hh, h.options.Middleware...)
+	} else {
+		handler = Api(root, h.options.Middleware...)
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	var lc net.ListenConfig
+	listeners := make([]net.Listener, 0, len(h.configs))
+	fail := func(err error) *promise.Promise[struct{}] {
+		for _, ln := range listeners {
+			_ = ln.Close()
+		}
+		h.servers, h.addrs = nil, nil
+		return promise.Reject[struct{}](err)
+	}
+	for i := range h.configs {
+		srv, err := newServer(handler, &h.configs[i])
+		if err != nil {
+			return fail(err)
+		}
+		ln, err := lc.Listen(ctx, "tcp", srv.Addr)
+		if err != nil {
+			return fail(err)
+		}
+		listeners = append(listeners, ln)
+		h.servers = append(h.servers, srv)
+		h.addrs   = append(h.addrs, ln.Addr())
+	}
+	for i, srv := range h.servers {
+		h.serving.Add(1)
+		go func(srv *http.Server, ln net.Listener) {
+			defer h.serving.Done()
+			var err error
+			if srv.TLSConfig != nil {
+				err = srv.ServeTLS(ln, "", "")
+			} else {
+				err = srv.Serve(ln)
+			}
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				h.logger.Error(err, "http server failed", "addr", ln.Addr().String())
+			}
+		}(srv, listeners[i])
+	}
+	root.Observe(context.EndingObserverFunc(func(*context.Context, any) {
+		ctx := context2.Background()
+		if timeout := h.timeout; timeout > 0 {
+			var cancel context2.CancelFunc
+			ctx, cancel = context2.WithTimeout(ctx, timeout)
+			defer cancel()
+		}
+		if err := h.stop(ctx); err != nil {
+			h.logger.Error(err, "unable to gracefully shutdown http servers")
+		}
+	}))
+	return promise.Empty()
+}
+
+func (h *Host) Shutdown(
+	ctx context2.Context,
+) *promise.Promise[struct{}] {
+	return promise.New(ctx, func(
+		resolve func(struct{}), reject func(error), onCancel func(func()),
+	) {
+		if err := h.stop(ctx); err != nil {
+			reject(err)
+		} else {
+			resolve(struct{}{})
+		}
+	})
+}
+
+// stop shuts down the servers once waiting for in-flight
+// requests to complete.
+func (h *Host) stop(ctx context2.Context) error {
+	h.shutdown.Do(func() {
+		h.lock.Lock()
+		servers := h.servers
+		h.lock.Unlock()
+		var errs []error
+		for _, srv := range servers {
+			if err := srv.Shutdown(ctx); err != nil {
+				errs = append(errs, err)
+				_ = srv.Close()
+			}
+		}
+		h.serving.Wait()
+		h.err = errors.Join(errs...)
+	})
+	return h.err
+}
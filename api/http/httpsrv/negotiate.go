@@ -0,0 +1,166 @@
+package httpsrv
+
+import (
+	"reflect"
+	"sync"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/maps"
+	"github.com/timewasted/go-accept-headers"
+)
+
+// Negotiator selects response formats from the Accept header
+// and the formats registered to map an api.Message.
+// Formats are discovered when handlers are registered.
+type Negotiator struct {
+	formats []*maps.Format
+	lock    sync.RWMutex
+}
+
+func (n *Negotiator) BindingCreated(
+	policy      miruken.Policy,
+	handlerInfo *miruken.HandlerInfo,
+	binding     miruken.Binding,
+) {
+	if policy != mapsPolicy {
+		return
+	}
+	if key, ok := binding.Key().(miruken.DiKey); !ok || key.In != messageType {
+		return
+	}
+	for _, filter := range binding.Filters() {
+		if src, ok := filter.(miruken.ConstraintSource); ok {
+			for _, constraint := range src.Constraints() {
+				if format, ok := constraint.(*maps.Format); ok &&
+					format.Direction() == maps.DirectionTo {
+					n.add(format)
+				}
+			}
+		}
+	}
+}
+
+func (n *Negotiator) HandlerInfoCreated(
+	*miruken.HandlerInfo,
+) {
+}
+
+// Supported returns the media types available for responses.
+// Formats matching a pattern contribute the well-known media
+// types they match.
+func (n *Negotiator) Supported() []string {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+	var supported []string
+	add := func(name string) {
+		for _, s := range supported {
+			if s == name {
+				return
+			}
+		}
+		supported = append(supported, name)
+	}
+	for _, format := range n.formats {
+		switch format.Rule() {
+		case maps.FormatRuleEquals:
+			add(format.Name())
+		case maps.FormatRuleAll:
+		default:
+			for _, known := range knownMediaTypes {
+				if format.Satisfies(known, miruken.HandleContext{}) {
+					add(known.Name())
+				}
+			}
+		}
+	}
+	return supported
+}
+
+// Negotiate returns the formats acceptable to the Accept header
+// in order of preference.  Json is preferred if the header is
+// empty.  Returns no formats if none are acceptable.
+func (n *Negotiator) Negotiate(header string) []*maps.Format {
+	supported := n.Supported()
+	if header == "" {
+		for _, name := range supported {
+			if name == api.ToJson.Name() {
+				return []*maps.Format{api.ToJson}
+			}
+		}
+		if len(supported) > 0 {
+			return []*maps.Format{maps.To(supported[0], nil)}
+		}
+		return []*maps.Format{api.ToJson}
+	}
+	accepts := accept.Parse(header)
+	var names []string
+	add := func(name string) {
+		for _, a := range accepts {
+			if a.Q == 0 && a.Type+"/"+a.Subtype == name {
+				return
+			}
+		}
+		for _, existing := range names {
+			if existing == name {
+				return
+			}
+		}
+		names = append(names, name)
+	}
+	for _, a := range accepts {
+		if a.Q == 0 {
+			continue
+		}
+		if a.Type != "*" && a.Subtype != "*" {
+			if name := a.Type + "/" + a.Subtype; n.accepts(name) {
+				add(name)
+			}
+			continue
+		}
+		for _, name := range supported {
+			if accept.AcceptSlice([]accept.Accept{a}).Accepts(name) {
+				add(name)
+			}
+		}
+	}
+	formats := make([]*maps.Format, len(names))
+	for i, name := range names {
+		formats[i] = maps.To(name, nil)
+	}
+	return formats
+}
+
+// accepts determines if a registered format maps the media type.
+func (n *Negotiator) accepts(mediaType string) bool {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+	required := maps.To(mediaType, nil)
+	for _, format := range n.formats {
+		if format.Rule() != maps.FormatRuleAll &&
+			format.Satisfies(required, miruken.HandleContext{}) {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *Negotiator) add(format *maps.Format) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.formats = append(n.formats, format)
+}
+
+var (
+	mapsPolicy  = (&maps.It{}).Policy()
+	messageType = reflect.TypeFor[api.Message]()
+
+	// knownMediaTypes expand formats matching a pattern.
+	knownMediaTypes = []*maps.Format{
+		api.ToJson,
+		api.ToXml,
+		api.ToYaml,
+		api.ToMsgpack,
+		api.ToCbor,
+	}
+)
@@ -229,10 +229,112 @@ func (i *Installer) BindingCreated(
 				},
 			}
 			ap.paths.Set("/process/"+strings.ToLower(inputName), path)
+
+			if route, ok := httpsrv.RouteOf(binding); ok {
+				i.generateRoute(ap, spec, route, inType, inputName, schema, binding)
+			}
 		}
 	}
 }
 
+// generateRoute describes a Route with the request values
+// as parameters and the bare message and result as bodies.
+func (i *Installer) generateRoute(
+	ap        *apiProfile,
+	spec      miruken.HandlerSpec,
+	route     httpsrv.Route,
+	inType    reflect.Type,
+	inputName string,
+	schema    *openapi3.SchemaRef,
+	binding   miruken.Binding,
+) {
+	op := &openapi3.Operation{
+		OperationID: strings.ToLower(route.Method) + inputName,
+		Description: fmt.Sprintf("Handled by %s", spec),
+		Tags:        []string{inType.PkgPath()},
+	}
+
+	var bound []string
+	for _, param := range httpsrv.RouteParams(inType) {
+		var parameter *openapi3.Parameter
+		switch param.In {
+		case "path":
+			parameter = openapi3.NewPathParameter(param.Name)
+			bound = append(bound, param.Name)
+		case "query":
+			parameter = openapi3.NewQueryParameter(param.Name)
+		case "header":
+			parameter = openapi3.NewHeaderParameter(param.Name)
+		}
+		zero := reflect.Zero(param.Field.Type).Interface()
+		if ps, err := ap.generator.NewSchemaRefForValue(zero, ap.schemas); err == nil {
+			parameter.Schema = ps
+		}
+		op.AddParameter(parameter)
+	}
+	for _, name := range route.PathParams() {
+		if !slices.Contains(bound, name) {
+			op.AddParameter(openapi3.NewPathParameter(name).
+				WithSchema(openapi3.NewStringSchema()))
+		}
+	}
+
+	switch route.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		op.RequestBody = &openapi3.RequestBodyRef{
+			Value: openapi3.NewRequestBody().
+				WithDescription("Request to process").
+				WithRequired(true).
+				WithJSONSchemaRef(&openapi3.SchemaRef{Value: schema.Value}),
+		}
+	}
+
+	status := route.Status
+	response := openapi3.NewResponse().WithDescription("Successful Response")
+	if outType := binding.LogicalOutputType(); outType != nil {
+		if outType.Kind() == reflect.Ptr {
+			outType = outType.Elem()
+		}
+		if schema, _, _ := i.generateTypeSchema(ap, outType, true); schema != nil {
+			response.WithJSONSchemaRef(schema)
+		}
+	}
+	if response.Content == nil {
+		response.WithDescription("No Content")
+		if status == 0 {
+			status = http.StatusNoContent
+		}
+	} else if status == 0 {
+		status = http.StatusOK
+	}
+	op.Responses = openapi3.NewResponses(
+		openapi3.WithStatus(status, &openapi3.ResponseRef{Value: response}),
+		openapi3.WithStatus(http.StatusBadRequest, &openapi3.ResponseRef{
+			Value: openapi3.NewResponse().WithDescription("Invalid Parameters"),
+		}),
+		openapi3.WithStatus(http.StatusUnprocessableEntity, &openapi3.ResponseRef{
+			Ref: "#/components/responses/ValidationError",
+		}),
+		openapi3.WithStatus(http.StatusUnauthorized, &openapi3.ResponseRef{
+			Ref: "#/components/responses/UnauthorizedError",
+		}),
+		openapi3.WithStatus(http.StatusForbidden, &openapi3.ResponseRef{
+			Ref: "#/components/responses/ForbiddenError",
+		}),
+		openapi3.WithStatus(http.StatusInternalServerError, &openapi3.ResponseRef{
+			Ref: "#/components/responses/GenericError",
+		}),
+	)
+
+	path := route.Path()
+	item := ap.paths.Value(path)
+	if item == nil {
+		item = &openapi3.PathItem{}
+		ap.paths.Set(path, item)
+	}
+	item.SetOperation(route.Method, op)
+}
+
 func (i *Installer) HandlerInfoCreated(
 	_ *miruken.HandlerInfo,
 ) {
@@ -390,12 +492,17 @@ func (i *Installer) generateExampleJson(
 						props := schema.Value.Properties
 						if props == nil {
 							props = openapi3.Schemas{}
+							schema.Value.Properties = props
 						}
 						props["@type"] = &openapi3.SchemaRef{
 							Value: &openapi3.Schema{
 								Type:    &openapi3.Types{"string"},
+								Enum:    []any{typ},
 								Default: typ,
 							}}
+						schema.Value.Discriminator = &openapi3.Discriminator{
+							PropertyName: "@type",
+						}
 					}
 				}
 				schema.Value.Example = json.RawMessage(b)
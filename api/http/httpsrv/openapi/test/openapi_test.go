@@ -39,6 +39,7 @@ type (
 	}
 
 	CreatePlayer struct {
+		_         struct{} `typeId:"team.CreatePlayer"`
 		Name      string
 		BirthDate time.Time
 		Address   Address
@@ -51,6 +52,17 @@ type (
 		Address   Address
 	}
 
+	GetPlayer struct {
+		Id     int32    `path:"id"`
+		Fields []string `query:"field"`
+		Tenant string   `header:"X-Tenant"`
+	}
+
+	RenamePlayer struct {
+		Id   int32 `path:"id"`
+		Name string
+	}
+
 	PlayerResult struct {
 		Id      int32
 		Version int32
@@ -93,6 +105,34 @@ func (p *PlayerHandler) UpdatePlayer(
 	}
 }
 
+func (p *PlayerHandler) GetPlayer(
+	_ *struct {
+		handles.It
+		httpsrv.Route `http:"GET /players/{id}"`
+	}, get *GetPlayer,
+) (*PlayerData, error) {
+	if player, ok := p.store[get.Id]; ok {
+		return player, nil
+	}
+	return nil, fmt.Errorf("player with id %v not found", get.Id)
+}
+
+func (p *PlayerHandler) RenamePlayer(
+	_ *struct {
+		handles.It
+		httpsrv.Route `http:"PUT /players/{id}"`
+	}, rename RenamePlayer,
+) *promise.Promise[PlayerResult] {
+	if player, ok := p.store[rename.Id]; !ok {
+		nf := fmt.Errorf("player with id %v not found", rename.Id)
+		return promise.Reject[PlayerResult](nf)
+	} else {
+		player.Name = rename.Name
+		player.Version++
+		return promise.Resolve(PlayerResult{player.Id, player.Version})
+	}
+}
+
 type OpenApiTestSuite struct {
 	suite.Suite
 	openapi *openapi.Installer
@@ -148,6 +188,47 @@ func (suite *OpenApiTestSuite) TestOpenApi() {
 		docs := suite.openapi.Docs()
 		suite.Len(docs, 1)
 	})
+
+	suite.Run("Discriminator", func() {
+		for _, doc := range suite.openapi.Docs() {
+			request := doc.Components.RequestBodies["CreatePlayerRequest"]
+			suite.NotNil(request)
+			schema := request.Value.Content.Get("application/json").Schema.
+				Value.Properties["payload"].Value
+			suite.Equal("@type", schema.Discriminator.PropertyName)
+			suite.Equal([]any{"team.CreatePlayer"}, schema.Properties["@type"].Value.Enum)
+		}
+	})
+
+	suite.Run("Routes", func() {
+		for _, doc := range suite.openapi.Docs() {
+			item := doc.Paths.Value("/players/{id}")
+			suite.NotNil(item)
+
+			get := item.Get
+			suite.NotNil(get)
+			suite.Nil(get.RequestBody)
+			suite.Len(get.Parameters, 3)
+			id := get.Parameters.GetByInAndName("path", "id")
+			suite.NotNil(id)
+			suite.True(id.Required)
+			suite.True(id.Schema.Value.Type.Is("integer"))
+			field := get.Parameters.GetByInAndName("query", "field")
+			suite.NotNil(field)
+			suite.True(field.Schema.Value.Type.Is("array"))
+			suite.NotNil(get.Parameters.GetByInAndName("header", "X-Tenant"))
+			ok := get.Responses.Status(200)
+			suite.NotNil(ok)
+			suite.Equal("#/components/schemas/PlayerData", ok.Value.Content.Get("application/json").Schema.Ref)
+
+			put := item.Put
+			suite.NotNil(put)
+			suite.NotNil(put.RequestBody)
+			suite.NotNil(put.Parameters.GetByInAndName("path", "id"))
+			schema := put.RequestBody.Value.Content.Get("application/json").Schema.Value
+			suite.Contains(schema.Properties, "name")
+		}
+	})
 }
 
 func TestOpenApiTestSuite(t *testing.T) {
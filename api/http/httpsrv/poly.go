@@ -2,38 +2,74 @@ package httpsrv
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
+	"mime"
 	"net/http"
 	"net/textproto"
+	"reflect"
 	"runtime"
 	"strings"
 
 	"github.com/go-logr/logr"
 	"github.com/miruken-go/miruken"
 	"github.com/miruken-go/miruken/api"
+	http2 "github.com/miruken-go/miruken/api/http"
 	"github.com/miruken-go/miruken/args"
 	"github.com/miruken-go/miruken/internal"
-	"github.com/miruken-go/miruken/internal/slices"
 	"github.com/miruken-go/miruken/maps"
 	"github.com/miruken-go/miruken/provides"
-	"github.com/timewasted/go-accept-headers"
 )
 
-// PolyHandler is a Handler for processing polymorphic http requests.
-type PolyHandler struct {
-	logger logr.Logger
-}
+type (
+	// PolyHandler is a Handler for processing polymorphic http requests.
+	// Newline delimited json bodies are processed as a stream of messages
+	// and answered with one result line per message in the same order.
+	PolyHandler struct {
+		logger      logr.Logger
+		negotiator  *Negotiator
+		routes      *RouteTable
+		parallelism int
+		problems    bool
+	}
+
+	// PolyOptions configures a PolyHandler.
+	// StreamParallelism bounds the messages of a stream processed at once.
+	// Problems renders all errors as RFC 7807 problem details instead
+	// of only when requested by the Accept header.
+	PolyOptions struct {
+		StreamParallelism int
+		Problems          bool
+	}
+)
+
+const defaultStreamParallelism = 16
+
+var errMissingPayload = errors.New("httpsrv: missing payload")
 
 func (a *PolyHandler) Constructor(
+	negotiator *Negotiator,
+	routes     *RouteTable,
 	_ *struct{ args.Optional }, logger logr.Logger,
+	_ *struct{ args.Optional }, options *PolyOptions,
 ) {
+	a.negotiator = negotiator
+	a.routes = routes
 	if logger == a.logger {
 		a.logger = logr.Discard()
 	} else {
 		a.logger = logger
 	}
+	a.parallelism = defaultStreamParallelism
+	if options != nil {
+		if options.StreamParallelism > 0 {
+			a.parallelism = options.StreamParallelism
+		}
+		a.problems = options.Problems
+	}
 }
 
 func (a *PolyHandler) ServeHTTP(
@@ -43,6 +79,10 @@ func (a *PolyHandler) ServeHTTP(
 ) {
 	defer a.handlePanic(w)
 
+	if a.routes.serve(w, r, h, a) {
+		return
+	}
+
 	accepted, from, publish := a.acceptRequest(w, r)
 	if !accepted {
 		return
@@ -50,9 +90,14 @@ func (a *PolyHandler) ServeHTTP(
 
 	h = miruken.BuildUp(h, api.Polymorphic, provides.With(r.Context()))
 
+	if from.Name() == api.FromNdjson.Name() {
+		a.serveStream(w, r, h, from, publish)
+		return
+	}
+
 	msg, _, _, err := maps.Out[api.Message](h, r.Body, from)
 	if err != nil {
-		a.encodeError(err, http.StatusUnsupportedMediaType, w, h)
+		a.encodeError(err, http.StatusUnsupportedMediaType, r, w, h)
 		return
 	}
 
@@ -62,6 +107,13 @@ func (a *PolyHandler) ServeHTTP(
 		return
 	}
 
+	headers, err := api.ReadHeaders(textproto.MIMEHeader(r.Header))
+	if err != nil {
+		http.Error(w, "400 invalid message headers", http.StatusBadRequest)
+		return
+	}
+	headers = msg.Headers.Merge(headers)
+
 	if c, ok := payload.(api.Content); ok {
 		if payload = c.Body(); internal.IsNil(payload) {
 			http.Error(w, "400 missing content body", http.StatusBadRequest)
@@ -70,29 +122,177 @@ func (a *PolyHandler) ServeHTTP(
 		h = miruken.BuildUp(h, provides.With(c))
 	}
 
-	if publish {
-		if pv, err := api.Publish(h, payload); err != nil {
-			a.encodeError(err, 0, w, h)
-		} else if pv == nil {
-			a.encodeResult(nil, r, w, h)
-		} else if _, err = pv.Await(); err == nil {
-			a.encodeResult(nil, r, w, h)
-		} else {
-			a.encodeError(err, 0, w, h)
-		}
+	msg = api.Message{Payload: payload, Headers: headers}
+
+	if res, err := a.dispatch(msg, publish, h); err != nil {
+		a.encodeError(err, 0, r, w, h)
 	} else {
-		if res, pr, err := api.Send[any](h, payload); err != nil {
-			a.encodeError(err, 0, w, h)
-		} else if pr == nil {
-			a.encodeResult(res, r, w, h)
-		} else if res, err = pr.Await(); err == nil {
-			a.encodeResult(res, r, w, h)
+		a.encodeResult(res, r, w, h)
+	}
+}
+
+// serveRoute binds the message of a Route from the request and
+// encodes the bare result using the negotiated format.
+func (a *PolyHandler) serveRoute(
+	w     http.ResponseWriter,
+	r     *http.Request,
+	h     miruken.Handler,
+	route *RouteDescriptor,
+) {
+	h = miruken.BuildUp(h, provides.With(r.Context()))
+
+	msg := route.NewMessage()
+	if route.HasBody() && r.ContentLength != 0 && r.Body != http.NoBody {
+		contentType := r.Header.Get("Content-Type")
+		if contentType == "" {
+			http.Error(w, "400 missing 'Content-Type' header", http.StatusBadRequest)
+			return
+		}
+		from, err := api.ParseMediaType(contentType, maps.DirectionFrom)
+		if err != nil {
+			http.Error(w, "415 invalid 'Content-Type' header", http.StatusUnsupportedMediaType)
+			return
+		}
+		target := msg.Interface()
+		if _, _, err := maps.Into(h, r.Body, &target, from); err != nil {
+			a.encodeError(err, http.StatusUnsupportedMediaType, r, w, h)
+			return
+		}
+	}
+
+	if err := route.Bind(r, msg); err != nil {
+		http.Error(w, "400 "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	headers, err := api.ReadHeaders(textproto.MIMEHeader(r.Header))
+	if err != nil {
+		http.Error(w, "400 invalid message headers", http.StatusBadRequest)
+		return
+	}
+
+	payload := msg.Interface()
+	if route.Input.Kind() != reflect.Pointer {
+		payload = msg.Elem().Interface()
+	}
+
+	res, err := a.dispatch(api.Message{Payload: payload, Headers: headers}, false, h)
+	if err != nil {
+		a.encodeError(err, 0, r, w, h)
+		return
+	}
+	if internal.IsNil(res) {
+		if route.Status > 0 {
+			w.WriteHeader(route.Status)
 		} else {
-			a.encodeError(err, 0, w, h)
+			w.WriteHeader(http.StatusNoContent)
+		}
+		return
+	}
+	formats := a.negotiator.Negotiate(r.Header.Get("Accept"))
+	if len(formats) == 0 {
+		a.notAcceptable(w)
+		return
+	}
+	a.encode(res, formats, route.Status, r, w, h)
+}
+
+// serveStream processes each message of a stream with bounded
+// concurrency and writes the results in the order received.
+func (a *PolyHandler) serveStream(
+	w       http.ResponseWriter,
+	r       *http.Request,
+	h       miruken.Handler,
+	from    *maps.Format,
+	publish bool,
+) {
+	stream, _, _, err := maps.Out[api.MessageStream](h, r.Body, from)
+	if err != nil {
+		a.encodeError(err, http.StatusUnsupportedMediaType, r, w, h)
+		return
+	}
+
+	headers, err := api.ReadHeaders(textproto.MIMEHeader(r.Header))
+	if err != nil {
+		http.Error(w, "400 invalid message headers", http.StatusBadRequest)
+		return
+	}
+
+	// results are written while the request body is still being read
+	_ = http.NewResponseController(w).EnableFullDuplex()
+
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	// at most parallelism messages are dispatched before their results are written
+	pending := make(chan chan api.Message, a.parallelism-1)
+	go func() {
+		defer close(pending)
+		for {
+			msg, err := stream.Next()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+			result := make(chan api.Message, 1)
+			select {
+			case pending <- result:
+			case <-ctx.Done():
+				return
+			}
+			if err != nil {
+				result <- api.Message{Payload: err}
+				continue
+			}
+			go func() {
+				if msg.Payload == nil {
+					result <- api.Message{Payload: errMissingPayload}
+					return
+				}
+				msg.Headers = msg.Headers.Merge(headers)
+				if res, err := a.dispatch(msg, publish, h); err != nil {
+					result <- api.Message{Payload: err}
+				} else {
+					result <- api.Message{Payload: res}
+				}
+			}()
+		}
+	}()
+
+	results := api.MessageStreamFunc(func() (api.Message, error) {
+		if result, ok := <-pending; ok {
+			return <-result, nil
 		}
+		return api.Message{}, io.EOF
+	})
+
+	w.Header().Set("Content-Type", api.ToNdjson.Name())
+	out := io.Writer(w)
+	if _, _, err := maps.Into(h, results, &out, api.ToNdjson); err != nil {
+		a.logger.Error(err, "unable to write stream response")
 	}
 }
 
+// dispatch sends or publishes the message and awaits the result.
+func (a *PolyHandler) dispatch(
+	msg     api.Message,
+	publish bool,
+	h       miruken.Handler,
+) (any, error) {
+	if publish {
+		pv, err := api.Publish(h, msg)
+		if err != nil || pv == nil {
+			return nil, err
+		}
+		_, err = pv.Await()
+		return nil, err
+	}
+	res, pr, err := api.Send[any](h, msg)
+	if err != nil || pr == nil {
+		return res, err
+	}
+	return pr.Await()
+}
+
 func (a *PolyHandler) acceptRequest(
 	w http.ResponseWriter,
 	r *http.Request,
@@ -142,96 +342,147 @@ func (a *PolyHandler) encodeResult(
 				result = content.Body()
 			}
 		} else {
-			a.encodeError(err, 0, w, handler)
+			a.encodeError(err, 0, r, w, handler)
 			return
 		}
 		api.MergeHeader(textproto.MIMEHeader(header), content.Metadata())
-	} else if hdr := r.Header.Get("Accept"); hdr != "" {
-		if fs := accept.Parse(hdr); len(fs) > 0 {
-			formats = slices.Map[accept.Accept, *maps.Format](fs, formatAccept)
-		}
-	}
-	if len(formats) == 0 {
-		formats = []*maps.Format{api.ToJson}
+	} else if formats = a.negotiator.Negotiate(r.Header.Get("Accept")); len(formats) == 0 {
+		a.notAcceptable(w)
+		return
 	}
-	msg := api.Message{Payload: result}
-	if len(formats) == 1 && formats[0].Rule() == maps.FormatRuleEquals {
+	a.encode(api.Message{Payload: result}, formats, 0, r, w, handler)
+}
+
+// encode writes the body in the first format that can map it.
+func (a *PolyHandler) encode(
+	body       any,
+	formats    []*maps.Format,
+	statusCode int,
+	r          *http.Request,
+	w          http.ResponseWriter,
+	handler    miruken.Handler,
+) {
+	header := w.Header()
+	if len(formats) == 1 {
 		format := formats[0]
 		header.Set("Content-Type", format.Name())
+		if statusCode > 0 {
+			w.WriteHeader(statusCode)
+		}
 		out := io.Writer(w)
-		if _, _, err := maps.Into(handler, msg, &out, format); err != nil {
-			a.encodeError(err, http.StatusNotAcceptable, w, handler)
+		if _, _, err := maps.Into(handler, body, &out, format); err != nil {
+			a.encodeError(err, http.StatusNotAcceptable, r, w, handler)
 		}
-	} else {
-		for i, format := range formats {
-			var b bytes.Buffer
-			out := io.Writer(&b)
-			if _, m, err := maps.Into(handler, msg, &out, format); err == nil {
-				var contentType string
-				if format.Rule() == maps.FormatRuleEquals {
-					contentType = api.FormatMediaType(format)
-				} else if match := m.Matched(); match != nil {
-					contentType = api.FormatMediaType(match)
-				} else {
-					w.WriteHeader(http.StatusNotAcceptable)
-					return
-				}
-				header.Set("Content-Type", contentType)
-				if _, err := w.Write(b.Bytes()); err != nil {
-					a.logger.Error(err, "unable to write response")
-					w.WriteHeader(http.StatusInternalServerError)
-				}
-				break
-			} else if i == len(formats)-1 {
-				a.encodeError(err, http.StatusNotAcceptable, w, handler)
+		return
+	}
+	for i, format := range formats {
+		var b bytes.Buffer
+		out := io.Writer(&b)
+		if _, _, err := maps.Into(handler, body, &out, format); err == nil {
+			header.Set("Content-Type", api.FormatMediaType(format))
+			if statusCode > 0 {
+				w.WriteHeader(statusCode)
 			}
+			if _, err := w.Write(b.Bytes()); err != nil {
+				a.logger.Error(err, "unable to write response")
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+			return
+		} else if i == len(formats)-1 {
+			a.encodeError(err, http.StatusNotAcceptable, r, w, handler)
 		}
 	}
 }
 
+// notAcceptable rejects the request with the supported media types.
+func (a *PolyHandler) notAcceptable(w http.ResponseWriter) {
+	supported := strings.Join(a.negotiator.Supported(), ", ")
+	http.Error(w, "406 not acceptable (supported: "+supported+")", http.StatusNotAcceptable)
+}
+
 func (a *PolyHandler) encodeError(
 	err                  error,
 	notHandledStatusCode int,
+	r                    *http.Request,
 	w                    http.ResponseWriter,
 	handler              miruken.Handler,
 ) {
 	if notHandledStatusCode > 0 {
 		var nh *miruken.NotHandledError
 		if errors.As(err, &nh) {
-			w.WriteHeader(notHandledStatusCode)
+			if notHandledStatusCode == http.StatusNotAcceptable {
+				a.notAcceptable(w)
+			} else {
+				w.WriteHeader(notHandledStatusCode)
+			}
 			return
 		}
 	}
-	w.Header().Set("Content-Type", api.ToJson.Name())
 	statusCode := http.StatusInternalServerError
 	handler = miruken.BuildUp(handler, miruken.BestEffort)
 	if sc, _, _, e := maps.Out[int](handler, err, toStatusCode); sc != 0 && e == nil {
 		statusCode = sc
 	}
-	w.WriteHeader(statusCode)
-	out := io.Writer(w)
+	if a.problems || acceptsProblem(r) {
+		a.encodeProblem(err, statusCode, w, handler)
+		return
+	}
+	format := a.errorFormat(r)
+	var b bytes.Buffer
+	out := io.Writer(&b)
 	msg := api.Message{Payload: err}
-	if _, _, err := maps.Into(handler, msg, &out, api.ToJson); err != nil {
+	_, _, err = maps.Into(handler, msg, &out, format)
+	if err != nil && format != api.ToJson {
+		format = api.ToJson
+		b.Reset()
+		_, _, err = maps.Into(handler, msg, &out, format)
+	}
+	if err != nil {
+		a.logger.Error(err, "unable to encode error response")
+	}
+	w.Header().Set("Content-Type", format.Name())
+	w.WriteHeader(statusCode)
+	if _, err := w.Write(b.Bytes()); err != nil {
 		a.logger.Error(err, "unable to write error response")
 	}
 }
 
-func formatAccept(a accept.Accept) *maps.Format {
-	var sb strings.Builder
-	if a.Subtype == "*" {
-		if a.Type == "*" {
-			return maps.To("*", a.Extensions)
+// encodeProblem writes the error as RFC 7807 problem details.
+func (a *PolyHandler) encodeProblem(
+	err        error,
+	statusCode int,
+	w          http.ResponseWriter,
+	handler    miruken.Handler,
+) {
+	problem := http2.NewProblem(handler, err, statusCode)
+	b, err := json.Marshal(problem)
+	if err != nil {
+		a.logger.Error(err, "unable to encode problem response")
+		w.WriteHeader(statusCode)
+		return
+	}
+	w.Header().Set("Content-Type", http2.ProblemMediaType)
+	w.WriteHeader(problem.Status)
+	if _, err := w.Write(b); err != nil {
+		a.logger.Error(err, "unable to write problem response")
+	}
+}
+
+// errorFormat selects the format of error responses.
+// The preferred Accept format is negotiated first, then the
+// request Content-Type, falling back to json.
+func (a *PolyHandler) errorFormat(r *http.Request) *maps.Format {
+	if hdr := r.Header.Get("Accept"); hdr != "" {
+		if formats := a.negotiator.Negotiate(hdr); len(formats) > 0 {
+			return formats[0]
 		}
-		sb.WriteString("/")
 	}
-	sb.WriteString(a.Type)
-	if a.Subtype != "*" {
-		sb.WriteString("/")
-		sb.WriteString(a.Subtype)
-	} else {
-		sb.WriteString("//")
+	if contentType := r.Header.Get("Content-Type"); contentType != "" {
+		if format, err := api.ParseMediaType(contentType, maps.DirectionTo); err == nil {
+			return format
+		}
 	}
-	return maps.To(sb.String(), a.Extensions)
+	return api.ToJson
 }
 
 func (a *PolyHandler) handlePanic(w http.ResponseWriter) {
@@ -246,4 +497,18 @@ func (a *PolyHandler) handlePanic(w http.ResponseWriter) {
 	}
 }
 
+// acceptsProblem determines if the Accept header
+// explicitly requests problem details.
+func acceptsProblem(r *http.Request) bool {
+	for _, value := range r.Header.Values("Accept") {
+		for _, accept := range strings.Split(value, ",") {
+			if mt, params, err := mime.ParseMediaType(accept); err == nil &&
+				mt == http2.ProblemMediaType && params["q"] != "0" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 var toStatusCode = maps.To("http:status-code", nil)
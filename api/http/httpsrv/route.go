@@ -0,0 +1,397 @@
+package httpsrv
+
+import (
+	context2 "context"
+	"encoding"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/handles"
+)
+
+type (
+	// Route is metadata exposing a handler binding as a resource
+	// endpoint using the net/http ServeMux pattern syntax.
+	//
+	//	_ *struct{
+	//	    handles.It
+	//	    httpsrv.Route `http:"GET /orders/{id}"`
+	//	  }, get *GetOrder,
+	//
+	// Fields of the message tagged with `path`, `query` or `header`
+	// are bound from the request.  The body of POST, PUT and PATCH
+	// requests is decoded into the message first.  An optional
+	// `status` tag overrides the status code of successful responses.
+	Route struct {
+		Method  string
+		Pattern string
+		Status  int
+	}
+
+	// RouteDescriptor describes a Route of a handler binding.
+	RouteDescriptor struct {
+		Route
+		Input  reflect.Type
+		Output reflect.Type
+		Params []RouteParam
+	}
+
+	// RouteParam describes a message field bound from the request.
+	RouteParam struct {
+		Name  string
+		In    string
+		Field reflect.StructField
+	}
+
+	// RouteTable collects the Route's of handler bindings and
+	// dispatches matching requests to them.
+	RouteTable struct {
+		routes []*RouteDescriptor
+		mux    *http.ServeMux
+		paths  *http.ServeMux
+		once   sync.Once
+		lock   sync.RWMutex
+	}
+
+	// routeRequest carries the state of a request to a Route.
+	routeRequest struct {
+		poly     *PolyHandler
+		composer miruken.Handler
+	}
+
+	// routeKey is the context key for the routeRequest.
+	routeKey struct{}
+)
+
+var (
+	ErrMissingRoute = errors.New("httpsrv: the Route metadata requires a non-empty `http` tag")
+	ErrInvalidRoute = errors.New("httpsrv: the Route `http` tag must be \"METHOD /path\"")
+)
+
+// Route
+
+func (r *Route) InitWithTag(tag reflect.StructTag) error {
+	route, ok := tag.Lookup("http")
+	if !ok || strings.TrimSpace(route) == "" {
+		return ErrMissingRoute
+	}
+	method, pattern, ok := strings.Cut(strings.TrimSpace(route), " ")
+	pattern = strings.TrimSpace(pattern)
+	if !ok || method == "" || !strings.HasPrefix(pattern, "/") {
+		return ErrInvalidRoute
+	}
+	r.Method = strings.ToUpper(method)
+	r.Pattern = pattern
+	if status, ok := tag.Lookup("status"); ok {
+		code, err := strconv.Atoi(status)
+		if err != nil || code < 100 || code > 599 {
+			return fmt.Errorf("httpsrv: invalid Route status %q", status)
+		}
+		r.Status = code
+	}
+	return nil
+}
+
+// PathParams returns the names of the wildcards in the pattern.
+func (r *Route) PathParams() []string {
+	var params []string
+	for _, segment := range strings.Split(r.Pattern, "/") {
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			name := strings.TrimSuffix(segment[1:len(segment)-1], "...")
+			if name != "" && name != "$" {
+				params = append(params, name)
+			}
+		}
+	}
+	return params
+}
+
+// Path returns the pattern without wildcard modifiers.
+func (r *Route) Path() string {
+	path := strings.ReplaceAll(r.Pattern, "...}", "}")
+	if trimmed := strings.TrimSuffix(path, "{$}"); trimmed != path {
+		path = trimmed
+	}
+	return path
+}
+
+// RouteTable
+
+// Routes returns the Route's discovered from handler bindings.
+func (t *RouteTable) Routes() []*RouteDescriptor {
+	t.lock.RLock()
+	defer t.lock.RUnlock()
+	return append([]*RouteDescriptor(nil), t.routes...)
+}
+
+func (t *RouteTable) BindingCreated(
+	policy      miruken.Policy,
+	handlerInfo *miruken.HandlerInfo,
+	binding     miruken.Binding,
+) {
+	if policy != handlesPolicy {
+		return
+	}
+	if route, ok := RouteOf(binding); ok {
+		if input, ok := binding.Key().(reflect.Type); ok {
+			descriptor := &RouteDescriptor{
+				Route:  route,
+				Input:  input,
+				Output: binding.LogicalOutputType(),
+				Params: RouteParams(input),
+			}
+			t.lock.Lock()
+			t.routes = append(t.routes, descriptor)
+			t.lock.Unlock()
+		}
+	}
+}
+
+func (t *RouteTable) HandlerInfoCreated(
+	*miruken.HandlerInfo,
+) {
+}
+
+// serve dispatches the request if it matches a Route.
+// Returns false if no Route matches the request path.
+func (t *RouteTable) serve(
+	w    http.ResponseWriter,
+	r    *http.Request,
+	h    miruken.Handler,
+	poly *PolyHandler,
+) bool {
+	t.once.Do(func() {
+		if err := t.build(); err != nil {
+			poly.logger.Error(err, "unable to register routes")
+		}
+	})
+	if t.mux == nil {
+		return false
+	}
+	// paths matches the Route's for any method so the ServeMux
+	// can respond with 405 Method Not Allowed
+	if _, pattern := t.paths.Handler(r); pattern == "" {
+		return false
+	}
+	ctx := context2.WithValue(r.Context(), routeKey{}, &routeRequest{poly, h})
+	t.mux.ServeHTTP(w, r.WithContext(ctx))
+	return true
+}
+
+func (t *RouteTable) build() (err error) {
+	routes := t.Routes()
+	if len(routes) == 0 {
+		return nil
+	}
+	mux, paths := http.NewServeMux(), http.NewServeMux()
+	registered := make(map[string]struct{})
+	for _, route := range routes {
+		err = errors.Join(err, registerRoute(mux, route))
+		if _, ok := registered[route.Pattern]; !ok {
+			registered[route.Pattern] = struct{}{}
+			registerPath(paths, route.Pattern)
+		}
+	}
+	t.mux, t.paths = mux, paths
+	return err
+}
+
+func registerRoute(
+	mux   *http.ServeMux,
+	route *RouteDescriptor,
+) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("httpsrv: invalid route %q for %v: %v",
+				route.Method+" "+route.Pattern, route.Input, r)
+		}
+	}()
+	mux.Handle(route.Method+" "+route.Pattern, http.HandlerFunc(
+		func(w http.ResponseWriter, r *http.Request) {
+			if req, ok := r.Context().Value(routeKey{}).(*routeRequest); ok {
+				req.poly.serveRoute(w, r, req.composer, route)
+			}
+		}))
+	return nil
+}
+
+// registerPath registers the pattern for any method.
+// Patterns conflicting with one already registered are skipped.
+func registerPath(
+	paths   *http.ServeMux,
+	pattern string,
+) {
+	defer func() { _ = recover() }()
+	paths.Handle(pattern, http.NotFoundHandler())
+}
+
+// RouteOf returns the Route metadata of a binding, if any.
+func RouteOf(binding miruken.Binding) (Route, bool) {
+	for _, metadata := range binding.Metadata() {
+		switch route := metadata.(type) {
+		case Route:
+			return route, true
+		case *Route:
+			return *route, true
+		}
+	}
+	return Route{}, false
+}
+
+// NewMessage creates a pointer to the message of the Route.
+func (d *RouteDescriptor) NewMessage() reflect.Value {
+	typ := d.Input
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return reflect.New(typ)
+}
+
+// HasBody determines if the request body is decoded into the message.
+func (d *RouteDescriptor) HasBody() bool {
+	switch d.Method {
+	case http.MethodPost, http.MethodPut, http.MethodPatch:
+		return true
+	}
+	return false
+}
+
+// Bind assigns the path, query and header values to the message.
+func (d *RouteDescriptor) Bind(
+	r   *http.Request,
+	msg reflect.Value,
+) error {
+	elem := reflect.Indirect(msg)
+	if elem.Kind() != reflect.Struct {
+		return nil
+	}
+	query := r.URL.Query()
+	for _, param := range d.Params {
+		var values []string
+		switch param.In {
+		case "path":
+			if value := r.PathValue(param.Name); value != "" {
+				values = []string{value}
+			}
+		case "query":
+			values = query[param.Name]
+		case "header":
+			values = r.Header.Values(param.Name)
+		}
+		if len(values) == 0 {
+			continue
+		}
+		field := elem.FieldByIndex(param.Field.Index)
+		if err := bindValues(field, values); err != nil {
+			return &RouteParamError{Param: param.Name, In: param.In, Cause: err}
+		}
+	}
+	return nil
+}
+
+// RouteParamError reports a request value that could not be bound.
+type RouteParamError struct {
+	Param string
+	In    string
+	Cause error
+}
+
+func (e *RouteParamError) Error() string {
+	return fmt.Sprintf("httpsrv: invalid %s parameter %q: %v", e.In, e.Param, e.Cause)
+}
+
+func (e *RouteParamError) Unwrap() error {
+	return e.Cause
+}
+
+// RouteParams returns the message fields bound from the request.
+func RouteParams(input reflect.Type) []RouteParam {
+	if input.Kind() == reflect.Pointer {
+		input = input.Elem()
+	}
+	if input.Kind() != reflect.Struct {
+		return nil
+	}
+	var params []RouteParam
+	for _, field := range reflect.VisibleFields(input) {
+		if !field.IsExported() {
+			continue
+		}
+		for _, in := range []string{"path", "query", "header"} {
+			if name, ok := field.Tag.Lookup(in); ok && name != "" && name != "-" {
+				params = append(params, RouteParam{Name: name, In: in, Field: field})
+				break
+			}
+		}
+	}
+	return params
+}
+
+func bindValues(field reflect.Value, values []string) error {
+	if field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.Uint8 {
+		slice := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, value := range values {
+			if err := bindValue(slice.Index(i), value); err != nil {
+				return err
+			}
+		}
+		field.Set(slice)
+		return nil
+	}
+	return bindValue(field, values[0])
+}
+
+func bindValue(field reflect.Value, value string) error {
+	if field.Kind() == reflect.Pointer {
+		ptr := reflect.New(field.Type().Elem())
+		if err := bindValue(ptr.Elem(), value); err != nil {
+			return err
+		}
+		field.Set(ptr)
+		return nil
+	}
+	if tu, ok := field.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return tu.UnmarshalText([]byte(value))
+	}
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(value)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		i, err := strconv.ParseInt(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(i)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		u, err := strconv.ParseUint(value, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(u)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(value, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(f)
+	default:
+		return fmt.Errorf("unsupported type %v", field.Type())
+	}
+	return nil
+}
+
+var (
+	handlesPolicy = (&handles.It{}).Policy()
+)
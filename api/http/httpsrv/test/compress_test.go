@@ -0,0 +1,160 @@
+package test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"compress/zlib"
+	"fmt"
+	"io"
+	http2 "net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/api/http"
+	"github.com/miruken-go/miruken/api/http/httpsrv"
+)
+
+func (suite *ApiHandlerTestSuite) TestCompression() {
+	srv := httptest.NewServer(
+		httpsrv.Compress().MinSize(100).Handler(httpsrv.Api(suite.ctx)))
+	defer srv.Close()
+
+	players := make([]PlayerData, 50)
+	for i := range players {
+		players[i] = PlayerData{Id: int32(i + 1), Name: fmt.Sprintf("Player %d", i+1)}
+	}
+
+	suite.Run("Route", func() {
+		var requestEncoding, responseEncoding string
+		record := http.PolicyFunc(func(
+			req *http2.Request,
+			_   miruken.Handler,
+			next func() (*http2.Response, error),
+		) (*http2.Response, error) {
+			requestEncoding = req.Header.Get("Content-Encoding")
+			res, err := next()
+			if err == nil {
+				responseEncoding = res.Header.Get("Content-Encoding")
+			}
+			return res, err
+		})
+		handler := miruken.BuildUp(suite.Setup(), http.Pipeline(http.Compress(), record))
+		create := api.RouteTo(CreateTeam{Name: "Tottenham", Players: players}, srv.URL)
+		_, pp, err := api.Send[*TeamData](handler, create)
+		suite.Nil(err)
+		team, err := pp.Await()
+		suite.Nil(err)
+		suite.Equal("Tottenham", team.Name)
+		suite.Equal(players, team.Players)
+		suite.Equal("gzip", requestEncoding)
+		suite.Equal("gzip", responseEncoding)
+	})
+
+	suite.Run("Negotiate", func() {
+		body := `{"payload":{"@type":"test.CreateTeam","Name":"Everton","Players":[{"Id":1,"Name":"Pickford"},{"Id":2,"Name":"Tarkowski"}]}}`
+		req, err := http2.NewRequest(http2.MethodPost, srv.URL+"/process", strings.NewReader(body))
+		suite.Nil(err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Encoding", "gzip;q=0.5, deflate")
+		res, err := http2.DefaultClient.Do(req)
+		suite.Nil(err)
+		defer func() { _ = res.Body.Close() }()
+		suite.Equal(http2.StatusOK, res.StatusCode)
+		suite.Equal("deflate", res.Header.Get("Content-Encoding"))
+		suite.Equal("Accept-Encoding", res.Header.Get("Vary"))
+		reader, err := zlib.NewReader(res.Body)
+		suite.Nil(err)
+		b, err := io.ReadAll(reader)
+		suite.Nil(err)
+		suite.Contains(string(b), `"Name":"Everton"`)
+	})
+
+	suite.Run("MinSize", func() {
+		body := `{"payload":{"@type":"test.CreateTeam","Name":"Everton"}}`
+		req, err := http2.NewRequest(http2.MethodPost, srv.URL+"/process", strings.NewReader(body))
+		suite.Nil(err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept-Encoding", "gzip")
+		res, err := http2.DefaultClient.Do(req)
+		suite.Nil(err)
+		defer func() { _ = res.Body.Close() }()
+		suite.Equal(http2.StatusOK, res.StatusCode)
+		suite.Equal("", res.Header.Get("Content-Encoding"))
+		b, err := io.ReadAll(res.Body)
+		suite.Nil(err)
+		suite.Contains(string(b), `"Name":"Everton"`)
+	})
+
+	suite.Run("Decompress", func() {
+		var b bytes.Buffer
+		gz := gzip.NewWriter(&b)
+		_, _ = gz.Write([]byte(`{"payload":{"@type":"test.CreateTeam","Name":"Liverpool"}}`))
+		suite.Nil(gz.Close())
+		req, err := http2.NewRequest(http2.MethodPost, srv.URL+"/process", &b)
+		suite.Nil(err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Encoding", "gzip")
+		res, err := http2.DefaultClient.Do(req)
+		suite.Nil(err)
+		defer func() { _ = res.Body.Close() }()
+		suite.Equal(http2.StatusOK, res.StatusCode)
+		body, err := io.ReadAll(res.Body)
+		suite.Nil(err)
+		suite.Contains(string(body), `"Name":"Liverpool"`)
+	})
+
+	suite.Run("DecompressTooLarge", func() {
+		srv := httptest.NewServer(
+			httpsrv.Compress().MaxSize(1024).Handler(httpsrv.Api(suite.ctx)))
+		defer srv.Close()
+
+		var b bytes.Buffer
+		gz := gzip.NewWriter(&b)
+		_, _ = gz.Write([]byte(`{"payload":{"@type":"test.CreateTeam","Name":"`))
+		_, _ = gz.Write(bytes.Repeat([]byte("a"), 1<<20))
+		_, _ = gz.Write([]byte(`"}}`))
+		suite.Nil(gz.Close())
+		req, err := http2.NewRequest(http2.MethodPost, srv.URL+"/process", &b)
+		suite.Nil(err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Encoding", "gzip")
+		res, err := http2.DefaultClient.Do(req)
+		suite.Nil(err)
+		defer func() { _ = res.Body.Close() }()
+		suite.Equal(http2.StatusRequestEntityTooLarge, res.StatusCode)
+	})
+
+	suite.Run("Stream", func() {
+		body := strings.NewReader(`{"payload":{"@type":"test.CreateTeam","Name":"Fulham"}}
+{"payload":{"@type":"test.CreateTeam","Name":"Brentford"}}
+`)
+		req, err := http2.NewRequest(http2.MethodPost, srv.URL+"/process", body)
+		suite.Nil(err)
+		req.Header.Set("Content-Type", "application/x-ndjson")
+		res, err := http2.DefaultClient.Do(req)
+		suite.Nil(err)
+		defer func() { _ = res.Body.Close() }()
+		suite.Equal(http2.StatusOK, res.StatusCode)
+		suite.True(res.Uncompressed)
+		b, err := io.ReadAll(res.Body)
+		suite.Nil(err)
+		lines := strings.Split(strings.TrimSpace(string(b)), "\n")
+		suite.Len(lines, 2)
+		suite.Contains(lines[0], `"Name":"Fulham"`)
+		suite.Contains(lines[1], `"Name":"Brentford"`)
+	})
+
+	suite.Run("UnsupportedEncoding", func() {
+		req, err := http2.NewRequest(http2.MethodPost, srv.URL+"/process", strings.NewReader("{}"))
+		suite.Nil(err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Content-Encoding", "br")
+		res, err := http2.DefaultClient.Do(req)
+		suite.Nil(err)
+		defer func() { _ = res.Body.Close() }()
+		suite.Equal(http2.StatusUnsupportedMediaType, res.StatusCode)
+		suite.Equal("gzip, deflate", res.Header.Get("Accept-Encoding"))
+	})
+}
@@ -0,0 +1,136 @@
+package test
+
+import (
+	http2 "net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/api/http/httpsrv"
+	"github.com/miruken-go/miruken/api/json/stdjson"
+	"github.com/miruken-go/miruken/config"
+	koanfp "github.com/miruken-go/miruken/config/koanf"
+	"github.com/miruken-go/miruken/setup"
+)
+
+func (suite *ApiHandlerTestSuite) TestCors() {
+	k := koanf.New(".")
+	suite.Nil(k.Load(confmap.Provider(map[string]any{
+		"cors": map[string]any{
+			"allowedOrigins":   []any{"https://app.example.com", "https://*.teams.example.com"},
+			"allowedMethods":   []any{"get", "post", "put"},
+			"allowedHeaders":   []any{"content-type", "authorization"},
+			"exposedHeaders":   []any{"X-Request-Id"},
+			"allowCredentials": true,
+			"maxAge":           "10m",
+		},
+	}, "."), nil))
+	ctx, err := setup.New(
+		TestFeature, config.Feature(koanfp.P(k)), httpsrv.Feature(), stdjson.Feature()).
+		Specs(&api.GoPolymorphism{}).
+		Context()
+	suite.Require().Nil(err)
+	defer ctx.End(nil)
+	srv := httptest.NewServer(httpsrv.Api(ctx, httpsrv.M[*httpsrv.Cors]()))
+	defer srv.Close()
+
+	preflight := func(origin, method, headers string) *http2.Response {
+		req, err := http2.NewRequest(http2.MethodOptions, srv.URL+"/process", nil)
+		suite.Require().Nil(err)
+		req.Header.Set("Origin", origin)
+		req.Header.Set("Access-Control-Request-Method", method)
+		if headers != "" {
+			req.Header.Set("Access-Control-Request-Headers", headers)
+		}
+		res, err := http2.DefaultClient.Do(req)
+		suite.Require().Nil(err)
+		_ = res.Body.Close()
+		return res
+	}
+
+	suite.Run("Preflight", func() {
+		res := preflight("https://app.example.com", "POST", "Content-Type, Authorization")
+		suite.Equal(http2.StatusNoContent, res.StatusCode)
+		suite.Equal("https://app.example.com", res.Header.Get("Access-Control-Allow-Origin"))
+		suite.Equal("GET, POST, PUT", res.Header.Get("Access-Control-Allow-Methods"))
+		suite.Equal("Content-Type, Authorization", res.Header.Get("Access-Control-Allow-Headers"))
+		suite.Equal("true", res.Header.Get("Access-Control-Allow-Credentials"))
+		suite.Equal("600", res.Header.Get("Access-Control-Max-Age"))
+		suite.Contains(res.Header.Values("Vary"), "Origin")
+	})
+
+	suite.Run("Pattern", func() {
+		res := preflight("https://spurs.teams.example.com", "PUT", "")
+		suite.Equal(http2.StatusNoContent, res.StatusCode)
+		suite.Equal("https://spurs.teams.example.com", res.Header.Get("Access-Control-Allow-Origin"))
+
+		res = preflight("https://evil.com/.teams.example.com", "PUT", "")
+		suite.Equal(http2.StatusForbidden, res.StatusCode)
+	})
+
+	suite.Run("Rejected", func() {
+		res := preflight("https://evil.com", "POST", "")
+		suite.Equal(http2.StatusForbidden, res.StatusCode)
+		suite.Empty(res.Header.Get("Access-Control-Allow-Origin"))
+
+		res = preflight("https://app.example.com", "DELETE", "")
+		suite.Equal(http2.StatusForbidden, res.StatusCode)
+
+		res = preflight("https://app.example.com", "POST", "X-Custom")
+		suite.Equal(http2.StatusForbidden, res.StatusCode)
+	})
+
+	suite.Run("Request", func() {
+		body := `{"payload":{"@type":"test.CreateTeam","Name":"Leeds"}}`
+		req, err := http2.NewRequest(http2.MethodPost, srv.URL+"/process", strings.NewReader(body))
+		suite.Require().Nil(err)
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Origin", "https://app.example.com")
+		res, err := http2.DefaultClient.Do(req)
+		suite.Require().Nil(err)
+		_ = res.Body.Close()
+		suite.Equal(http2.StatusOK, res.StatusCode)
+		suite.Equal("https://app.example.com", res.Header.Get("Access-Control-Allow-Origin"))
+		suite.Equal("X-Request-Id", res.Header.Get("Access-Control-Expose-Headers"))
+
+		req.Header.Set("Origin", "https://evil.com")
+		req.Body = http2.NoBody
+		res, err = http2.DefaultClient.Do(req)
+		suite.Require().Nil(err)
+		_ = res.Body.Close()
+		suite.Empty(res.Header.Get("Access-Control-Allow-Origin"))
+	})
+
+	suite.Run("AnyOrigin", func() {
+		handler := httpsrv.Api(ctx, httpsrv.NewCors(httpsrv.CorsOptions{
+			AllowedOrigins: []string{"*"},
+			AllowedHeaders: []string{"*"},
+		}))
+		req := httptest.NewRequest(http2.MethodOptions, "http://hello.com/process", nil)
+		req.Header.Set("Origin", "https://anywhere.org")
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		req.Header.Set("Access-Control-Request-Headers", "X-Custom")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		suite.Equal(http2.StatusNoContent, w.Code)
+		suite.Equal("*", w.Header().Get("Access-Control-Allow-Origin"))
+		suite.Equal("X-Custom", w.Header().Get("Access-Control-Allow-Headers"))
+	})
+
+	suite.Run("AnyOriginCredentials", func() {
+		handler := httpsrv.Api(ctx, httpsrv.NewCors(httpsrv.CorsOptions{
+			AllowedOrigins:   []string{"*"},
+			AllowCredentials: true,
+		}))
+		req := httptest.NewRequest(http2.MethodOptions, "http://hello.com/process", nil)
+		req.Header.Set("Origin", "https://evil.com")
+		req.Header.Set("Access-Control-Request-Method", "POST")
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		suite.Equal(http2.StatusNoContent, w.Code)
+		suite.Equal("*", w.Header().Get("Access-Control-Allow-Origin"))
+		suite.Empty(w.Header().Get("Access-Control-Allow-Credentials"))
+	})
+}
@@ -9,7 +9,10 @@ import (
 var TestFeature setup.Feature = setup.FeatureFunc(func(setup *setup.Builder) error {
 	setup.Specs(
 		&TeamApiConsumer{},
+		&NapHandler{},
 		&TeamApiHandler{},
+		&TeamProblemHandler{},
+		&TeamResourceHandler{},
 	)
 	return nil
 })
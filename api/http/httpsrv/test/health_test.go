@@ -0,0 +1,83 @@
+package test
+
+import (
+	context2 "context"
+	json2 "encoding/json"
+	"errors"
+	http2 "net/http"
+	"net/http/httptest"
+
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/api/http/httpsrv"
+	"github.com/miruken-go/miruken/api/json/stdjson"
+	"github.com/miruken-go/miruken/health"
+	"github.com/miruken-go/miruken/setup"
+)
+
+type StandingsCheck struct{}
+
+func (c *StandingsCheck) Name() string {
+	return "standings"
+}
+
+func (c *StandingsCheck) Check(context2.Context) error {
+	return errors.New("standings unavailable")
+}
+
+func (suite *ApiHandlerTestSuite) TestHealth() {
+	ctx, err := setup.New(
+		TestFeature, httpsrv.Feature(), stdjson.Feature(), health.Feature()).
+		Specs(&api.GoPolymorphism{}, &StandingsCheck{}).
+		Context()
+	suite.Require().Nil(err)
+	defer ctx.End(nil)
+	srv := httptest.NewServer(httpsrv.Use(ctx, httpsrv.H[*httpsrv.HealthHandler]()))
+	defer srv.Close()
+
+	get := func(method, path string) (*http2.Response, health.Report) {
+		req, err := http2.NewRequest(method, srv.URL+path, nil)
+		suite.Require().Nil(err)
+		res, err := http2.DefaultClient.Do(req)
+		suite.Require().Nil(err)
+		defer func() { _ = res.Body.Close() }()
+		var report health.Report
+		if method == http2.MethodGet && res.Header.Get("Content-Type") == "application/json" {
+			suite.Nil(json2.NewDecoder(res.Body).Decode(&report))
+		}
+		return res, report
+	}
+
+	suite.Run("Liveness", func() {
+		res, report := get(http2.MethodGet, "/healthz")
+		suite.Equal(http2.StatusOK, res.StatusCode)
+		suite.Equal("no-store", res.Header.Get("Cache-Control"))
+		suite.Equal(health.Report{Status: health.StatusUp}, report)
+	})
+
+	suite.Run("Readiness", func() {
+		res, report := get(http2.MethodGet, "/readyz")
+		suite.Equal(http2.StatusServiceUnavailable, res.StatusCode)
+		suite.Equal(health.StatusDown, report.Status)
+		suite.Equal("started", report.Stage)
+		suite.Equal([]health.Result{{
+			Name:   "standings",
+			Status: health.StatusDown,
+			Error:  "standings unavailable",
+		}}, report.Checks)
+	})
+
+	suite.Run("Head", func() {
+		res, _ := get(http2.MethodHead, "/healthz")
+		suite.Equal(http2.StatusOK, res.StatusCode)
+	})
+
+	suite.Run("MethodNotAllowed", func() {
+		res, _ := get(http2.MethodPost, "/readyz")
+		suite.Equal(http2.StatusMethodNotAllowed, res.StatusCode)
+	})
+
+	suite.Run("NotFound", func() {
+		res, _ := get(http2.MethodGet, "/statusz")
+		suite.Equal(http2.StatusNotFound, res.StatusCode)
+	})
+}
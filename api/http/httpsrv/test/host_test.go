@@ -0,0 +1,118 @@
+package test
+
+import (
+	"fmt"
+	"io"
+	http2 "net/http"
+	"strings"
+	"time"
+
+	"github.com/knadh/koanf"
+	"github.com/knadh/koanf/providers/confmap"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/api/http/httpsrv"
+	"github.com/miruken-go/miruken/api/json/stdjson"
+	"github.com/miruken-go/miruken/config"
+	koanfp "github.com/miruken-go/miruken/config/koanf"
+	"github.com/miruken-go/miruken/handles"
+	"github.com/miruken-go/miruken/provides"
+	"github.com/miruken-go/miruken/setup"
+)
+
+type (
+	Nap struct {
+		Millis int `query:"ms"`
+	}
+
+	NapHandler struct{}
+)
+
+var napping = make(chan struct{}, 1)
+
+// NapHandler
+
+func (n *NapHandler) Nap(
+	_ *struct {
+		handles.It
+		httpsrv.Route `http:"GET /nap"`
+	}, nap *Nap,
+) string {
+	napping <- struct{}{}
+	time.Sleep(time.Duration(nap.Millis) * time.Millisecond)
+	return "rested"
+}
+
+func (suite *ApiHandlerTestSuite) TestHost() {
+	suite.Run("Serve", func() {
+		k := koanf.New(".")
+		suite.Nil(k.Load(confmap.Provider(map[string]any{
+			"http": map[string]any{
+				"servers": []any{
+					map[string]any{"addr": "127.0.0.1:0"},
+				},
+			},
+		}, "."), nil))
+		ctx, err := setup.New(
+			TestFeature, config.Feature(koanfp.P(k)), stdjson.Feature(),
+			httpsrv.Feature(httpsrv.Serve(httpsrv.HostOptions{
+				Servers: []httpsrv.Config{{Addr: "127.0.0.1:0"}},
+			}))).
+			Specs(&api.GoPolymorphism{}).
+			Context()
+		suite.Nil(err)
+		defer ctx.End(nil)
+
+		host, _, ok, err := provides.Type[*httpsrv.Host](ctx)
+		suite.True(ok)
+		suite.Nil(err)
+		addrs := host.Addrs()
+		suite.Len(addrs, 2)
+		for _, addr := range addrs {
+			body := `{"payload":{"@type":"test.CreateTeam","Name":"Spurs"}}`
+			res, err := http2.Post(fmt.Sprintf("http://%s/process", addr),
+				"application/json", strings.NewReader(body))
+			suite.Nil(err)
+			b, err := io.ReadAll(res.Body)
+			_ = res.Body.Close()
+			suite.Nil(err)
+			suite.Equal(http2.StatusOK, res.StatusCode)
+			suite.Contains(string(b), `"Name":"Spurs"`)
+		}
+	})
+
+	suite.Run("GracefulShutdown", func() {
+		ctx, err := setup.New(
+			TestFeature, stdjson.Feature(),
+			httpsrv.Feature(httpsrv.Serve(httpsrv.HostOptions{
+				Servers: []httpsrv.Config{{Addr: "127.0.0.1:0", WriteTimeout: time.Second}},
+			}))).
+			Specs(&api.GoPolymorphism{}).
+			Options(setup.Options{ShutdownTimeout: time.Second}).
+			Context()
+		suite.Nil(err)
+
+		host, _, _, _ := provides.Type[*httpsrv.Host](ctx)
+		addr := host.Addrs()[0]
+
+		done := make(chan string, 1)
+		go func() {
+			res, err := http2.Get(fmt.Sprintf("http://%s/nap?ms=200", addr))
+			if err != nil {
+				done <- err.Error()
+				return
+			}
+			defer func() { _ = res.Body.Close() }()
+			b, _ := io.ReadAll(res.Body)
+			done <- fmt.Sprintf("%d %s", res.StatusCode, b)
+		}()
+
+		<-napping
+		start := time.Now()
+		ctx.End(nil)
+		suite.GreaterOrEqual(time.Since(start), 100*time.Millisecond)
+		suite.Equal(`200 "rested"`+"\n", <-done)
+
+		_, err = http2.Get(fmt.Sprintf("http://%s/nap", addr))
+		suite.NotNil(err)
+	})
+}
@@ -1,19 +1,28 @@
 package test
 
 import (
+	"bytes"
 	json2 "encoding/json"
 	"errors"
 	"io"
+	"mime/multipart"
 	http2 "net/http"
 	"net/http/httptest"
+	"net/textproto"
+	"strings"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/miruken-go/miruken"
 	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/api/binary/cbor"
+	"github.com/miruken-go/miruken/api/binary/msgpack"
 	"github.com/miruken-go/miruken/api/http"
 	"github.com/miruken-go/miruken/api/http/httpsrv"
 	"github.com/miruken-go/miruken/api/json/stdjson"
+	"github.com/miruken-go/miruken/api/xml"
+	"github.com/miruken-go/miruken/api/yaml"
 	"github.com/miruken-go/miruken/context"
 	"github.com/miruken-go/miruken/creates"
 	"github.com/miruken-go/miruken/either"
@@ -51,6 +60,16 @@ type (
 
 	GetTeamNotifications struct{}
 
+	GetMessageHeaders struct{}
+
+	MessageHeaders struct {
+		MessageId     string
+		CorrelationId string
+		CausationId   string
+		Tenant        string
+		Priority      string
+	}
+
 	TeamApiHandler struct {
 		nextId int32
 	}
@@ -84,15 +103,34 @@ func (t *TeamApiHandler) CreateTeam(
 	return promise.Resolve(team)
 }
 
+func (t *TeamApiHandler) MessageHeaders(
+	_ *handles.It, _ *GetMessageHeaders,
+	headers api.Headers,
+) *MessageHeaders {
+	return &MessageHeaders{
+		MessageId:     headers.MessageId,
+		CorrelationId: headers.CorrelationId,
+		CausationId:   headers.CausationId,
+		Tenant:        headers.Tenant,
+		Priority:      headers.Get("Priority"),
+	}
+}
+
 func (t *TeamApiHandler) New(
 	_ *struct {
 		_ creates.It `key:"test.CreateTeam"`
 		_ creates.It `key:"test.TeamCreated"`
 		_ creates.It `key:"test.GetTeamNotifications"`
 		_ creates.It `key:"test.TeamData"`
+		_ creates.It `key:"test.GetMessageHeaders"`
+		_ creates.It `key:"test.MessageHeaders"`
 	}, create *creates.It,
 ) any {
 	switch create.Key() {
+	case "test.GetMessageHeaders":
+		return new(GetMessageHeaders)
+	case "test.MessageHeaders":
+		return new(MessageHeaders)
 	case "test.CreateTeam":
 		return new(CreateTeam)
 	case "test.TeamCreated":
@@ -143,7 +181,8 @@ type ApiHandlerTestSuite struct {
 
 func (suite *ApiHandlerTestSuite) Setup(specs ...any) *context.Context {
 	ctx, _ := setup.New(
-		TestFeature, http.Feature(), stdjson.Feature()).
+		TestFeature, http.Feature(), stdjson.Feature(),
+		msgpack.Feature(), cbor.Feature(), xml.Feature(), yaml.Feature()).
 		Specs(&api.GoPolymorphism{}).
 		Specs(specs...).
 		Context()
@@ -152,7 +191,8 @@ func (suite *ApiHandlerTestSuite) Setup(specs ...any) *context.Context {
 
 func (suite *ApiHandlerTestSuite) SetupTest() {
 	ctx, _ := setup.New(
-		TestFeature, httpsrv.Feature(), stdjson.Feature()).
+		TestFeature, httpsrv.Feature(), stdjson.Feature(),
+		msgpack.Feature(), cbor.Feature(), xml.Feature(), yaml.Feature()).
 		Specs(&api.GoPolymorphism{}).
 		Context()
 	suite.ctx = ctx
@@ -209,6 +249,42 @@ func (suite *ApiHandlerTestSuite) TestApiHandler() {
 			suite.Contains(events, ev)
 		})
 
+		suite.Run("Headers", func() {
+			handler := suite.Setup()
+			explicit := api.Headers{MessageId: "m1", CorrelationId: "c1", Tenant: "acme"}
+			explicit.Set("Priority", "high")
+			get := api.RouteTo(GetMessageHeaders{}, suite.srv.URL)
+			_, ph, err := api.Send[*MessageHeaders](handler,
+				api.Message{Payload: get, Headers: explicit})
+			suite.Nil(err)
+			suite.NotNil(ph)
+			headers, err := ph.Await()
+			suite.Nil(err)
+			suite.Equal(MessageHeaders{
+				MessageId:     "m1",
+				CorrelationId: "c1",
+				Tenant:        "acme",
+				Priority:      "high",
+			}, *headers)
+		})
+
+		suite.Run("HttpHeaders", func() {
+			body := strings.NewReader(`{"payload":{"@type":"test.GetMessageHeaders"}}`)
+			req, err := http2.NewRequest(http2.MethodPost, suite.srv.URL+"/process", body)
+			suite.Nil(err)
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set(api.HeaderMessageId, "m3")
+			req.Header.Set(api.HeaderCorrelationId, "c3")
+			req.Header.Set(api.HeaderCausationId, "m2")
+			res, err := http2.DefaultClient.Do(req)
+			suite.Nil(err)
+			defer func() { _ = res.Body.Close() }()
+			suite.Equal(http2.StatusOK, res.StatusCode)
+			b, err := io.ReadAll(res.Body)
+			suite.Nil(err)
+			suite.Contains(string(b), `"MessageId":"m3","CorrelationId":"c3","CausationId":"m2"`)
+		})
+
 		suite.Run("ConcurrentSingle", func() {
 			handler := suite.Setup()
 			batch := api.RouteTo(api.ConcurrentBatch{
@@ -323,6 +399,36 @@ func (suite *ApiHandlerTestSuite) TestApiHandler() {
 			suite.Equal(3, count)
 		})
 
+		suite.Run("ConcurrentOptions", func() {
+			handler := suite.Setup()
+			batch := api.RouteTo(api.ConcurrentBatch{
+				Requests: []any{
+					&CreateTeam{Name: ""},
+					&CreateTeam{Name: "Arsenal"},
+				},
+				MaxParallelism: 1,
+				FailFast:       true,
+				Timeout:        5 * time.Second,
+			}, suite.srv.URL)
+			_, pr, err := api.Send[api.ScheduledResult](handler, batch)
+			suite.Nil(err)
+			suite.NotNil(pr)
+			r, err := pr.Await()
+			suite.Nil(err)
+			suite.Len(r.Responses, 2)
+			either.Match(r.Responses[0], func(err error) {
+				var outcome *validates.Outcome
+				suite.ErrorAs(err, &outcome)
+			}, func(res any) {
+				suite.Fail("expected validation error")
+			})
+			either.Match(r.Responses[1], func(err error) {
+				suite.Contains(err.Error(), "batch canceled")
+			}, func(res any) {
+				suite.Fail("expected canceled error")
+			})
+		})
+
 		suite.Run("Pipeline", func() {
 			handler := miruken.BuildUp(
 				suite.Setup(),
@@ -352,6 +458,295 @@ func (suite *ApiHandlerTestSuite) TestApiHandler() {
 				outcome.FieldErrors("Name"))
 		})
 
+		suite.Run("BinaryFormats", func() {
+			for _, format := range []string{"application/msgpack", "application/cbor"} {
+				suite.Run(format, func() {
+					handler := miruken.BuildUp(suite.Setup(), http.Format(format))
+					create := api.RouteTo(CreateTeam{Name: "Arsenal"}, suite.srv.URL)
+					_, pp, err := api.Send[*TeamData](handler, create)
+					suite.Nil(err)
+					team, err := pp.Await()
+					suite.Nil(err)
+					suite.Equal("Arsenal", team.Name)
+
+					explicit := api.Headers{MessageId: "m1", Tenant: "acme"}
+					get := api.RouteTo(GetMessageHeaders{}, suite.srv.URL)
+					_, ph, err := api.Send[*MessageHeaders](handler,
+						api.Message{Payload: get, Headers: explicit})
+					suite.Nil(err)
+					headers, err := ph.Await()
+					suite.Nil(err)
+					suite.Equal("m1", headers.MessageId)
+					suite.Equal("acme", headers.Tenant)
+
+					batch := api.RouteTo(api.ConcurrentBatch{
+						Requests: []any{&CreateTeam{Name: "Chelsea"}, &CreateTeam{}},
+					}, suite.srv.URL)
+					_, pr, err := api.Send[api.ScheduledResult](handler, batch)
+					suite.Nil(err)
+					r, err := pr.Await()
+					suite.Nil(err)
+					suite.Len(r.Responses, 2)
+					either.Match(r.Responses[0], func(err error) {
+						suite.Fail("unexpected error", err)
+					}, func(res any) {
+						suite.Equal("Chelsea", res.(*TeamData).Name)
+					})
+					either.Match(r.Responses[1], func(err error) {
+						suite.ErrorContains(err, `"Name" is required`)
+					}, func(res any) {
+						suite.Fail("expected error")
+					})
+
+					create = api.RouteTo(CreateTeam{}, suite.srv.URL)
+					_, pp, err = api.Send[*TeamData](handler, create)
+					suite.Nil(err)
+					_, err = pp.Await()
+					var outcome *validates.Outcome
+					suite.ErrorAs(err, &outcome)
+					suite.Equal(`Name: "Name" is required`, outcome.Error())
+				})
+			}
+		})
+
+		suite.Run("Yaml", func() {
+			handler := miruken.BuildUp(suite.Setup(), http.Format("application/yaml"))
+			create := api.RouteTo(CreateTeam{Name: "Arsenal"}, suite.srv.URL)
+			_, pp, err := api.Send[*TeamData](handler, create)
+			suite.Nil(err)
+			team, err := pp.Await()
+			suite.Nil(err)
+			suite.Equal("Arsenal", team.Name)
+
+			explicit := api.Headers{MessageId: "m1", Tenant: "acme"}
+			get := api.RouteTo(GetMessageHeaders{}, suite.srv.URL)
+			_, ph, err := api.Send[*MessageHeaders](handler,
+				api.Message{Payload: get, Headers: explicit})
+			suite.Nil(err)
+			headers, err := ph.Await()
+			suite.Nil(err)
+			suite.Equal("m1", headers.MessageId)
+			suite.Equal("acme", headers.Tenant)
+
+			batch := api.RouteTo(api.ConcurrentBatch{
+				Requests: []any{&CreateTeam{Name: "Chelsea"}, &CreateTeam{}},
+			}, suite.srv.URL)
+			_, pr, err := api.Send[api.ScheduledResult](handler, batch)
+			suite.Nil(err)
+			r, err := pr.Await()
+			suite.Nil(err)
+			suite.Len(r.Responses, 2)
+			either.Match(r.Responses[0], func(err error) {
+				suite.Fail("unexpected error", err)
+			}, func(res any) {
+				suite.Equal("Chelsea", res.(*TeamData).Name)
+			})
+			either.Match(r.Responses[1], func(err error) {
+				suite.ErrorContains(err, `"Name" is required`)
+			}, func(res any) {
+				suite.Fail("expected error")
+			})
+
+			create = api.RouteTo(CreateTeam{}, suite.srv.URL)
+			_, pp, err = api.Send[*TeamData](handler, create)
+			suite.Nil(err)
+			_, err = pp.Await()
+			var outcome *validates.Outcome
+			suite.ErrorAs(err, &outcome)
+			suite.Equal(`Name: "Name" is required`, outcome.Error())
+		})
+
+		suite.Run("Xml", func() {
+			for _, format := range []string{"application/xml", "text/xml"} {
+				suite.Run(format, func() {
+					handler := miruken.BuildUp(suite.Setup(), http.Format(format))
+					create := api.RouteTo(CreateTeam{Name: "Everton"}, suite.srv.URL)
+					_, pp, err := api.Send[*TeamData](handler, create)
+					suite.Nil(err)
+					team, err := pp.Await()
+					suite.Nil(err)
+					suite.Equal("Everton", team.Name)
+
+					explicit := api.Headers{MessageId: "m1", Tenant: "acme"}
+					explicit.Set("Priority", "high")
+					get := api.RouteTo(GetMessageHeaders{}, suite.srv.URL)
+					_, ph, err := api.Send[*MessageHeaders](handler,
+						api.Message{Payload: get, Headers: explicit})
+					suite.Nil(err)
+					headers, err := ph.Await()
+					suite.Nil(err)
+					suite.Equal("m1", headers.MessageId)
+					suite.Equal("acme", headers.Tenant)
+					suite.Equal("high", headers.Priority)
+
+					create = api.RouteTo(CreateTeam{}, suite.srv.URL)
+					_, pp, err = api.Send[*TeamData](handler, create)
+					suite.Nil(err)
+					_, err = pp.Await()
+					var outcome *validates.Outcome
+					suite.ErrorAs(err, &outcome)
+					suite.Equal(`Name: "Name" is required`, outcome.Error())
+				})
+			}
+		})
+
+		suite.Run("Negotiation", func() {
+			post := func(body, accept string) *http2.Response {
+				req, err := http2.NewRequest(http2.MethodPost, suite.srv.URL+"/process", strings.NewReader(body))
+				suite.Nil(err)
+				req.Header.Set("Content-Type", "application/json")
+				req.Header.Set("Accept", accept)
+				res, err := http2.DefaultClient.Do(req)
+				suite.Nil(err)
+				return res
+			}
+			create := `{"payload":{"@type":"test.CreateTeam","Name":"Everton"}}`
+
+			suite.Run("Quality", func() {
+				res := post(create, "application/xml;q=0.5, application/yaml")
+				defer func() { _ = res.Body.Close() }()
+				suite.Equal(http2.StatusOK, res.StatusCode)
+				suite.Equal("application/yaml", res.Header.Get("Content-Type"))
+			})
+
+			suite.Run("Wildcard", func() {
+				res := post(create, "text/plain, application/*;q=0.8")
+				defer func() { _ = res.Body.Close() }()
+				suite.Equal(http2.StatusOK, res.StatusCode)
+				suite.True(strings.HasPrefix(res.Header.Get("Content-Type"), "application/"))
+			})
+
+			suite.Run("Excluded", func() {
+				res := post(create, "application/json;q=0, */*")
+				defer func() { _ = res.Body.Close() }()
+				suite.Equal(http2.StatusOK, res.StatusCode)
+				suite.NotEqual("application/json", res.Header.Get("Content-Type"))
+			})
+
+			suite.Run("Pattern", func() {
+				res := post(create, "text/xml")
+				defer func() { _ = res.Body.Close() }()
+				suite.Equal(http2.StatusOK, res.StatusCode)
+				suite.Equal("text/xml", res.Header.Get("Content-Type"))
+			})
+
+			suite.Run("NotAcceptable", func() {
+				res := post(create, "text/plain, image/*")
+				defer func() { _ = res.Body.Close() }()
+				suite.Equal(http2.StatusNotAcceptable, res.StatusCode)
+				b, err := io.ReadAll(res.Body)
+				suite.Nil(err)
+				for _, format := range []string{
+					"application/json", "application/xml", "application/yaml",
+					"application/msgpack", "application/cbor",
+				} {
+					suite.Contains(string(b), format)
+				}
+			})
+
+			suite.Run("Error", func() {
+				res := post(`{"payload":{"@type":"test.CreateTeam"}}`, "application/yaml;q=0.2, application/xml")
+				defer func() { _ = res.Body.Close() }()
+				suite.Equal(http2.StatusUnprocessableEntity, res.StatusCode)
+				suite.Equal("application/xml", res.Header.Get("Content-Type"))
+			})
+
+			suite.Run("Route", func() {
+				var accept string
+				record := http.PolicyFunc(func(
+					req *http2.Request,
+					_   miruken.Handler,
+					next func() (*http2.Response, error),
+				) (*http2.Response, error) {
+					accept = req.Header.Get("Accept")
+					return next()
+				})
+				handler := miruken.BuildUp(suite.Setup(),
+					http.Format("application/yaml"),
+					http.Accept("application/json", "application/xml"),
+					http.Pipeline(record))
+				create := api.RouteTo(CreateTeam{Name: "Everton"}, suite.srv.URL)
+				_, pp, err := api.Send[*TeamData](handler, create)
+				suite.Nil(err)
+				team, err := pp.Await()
+				suite.Nil(err)
+				suite.Equal("Everton", team.Name)
+				suite.Equal("application/yaml, application/json;q=0.9, application/xml;q=0.8", accept)
+			})
+		})
+
+		suite.Run("Ndjson", func() {
+			body := strings.NewReader(`{"payload":{"@type":"test.CreateTeam","Name":"Tottenham"}}
+{"payload":{"@type":"test.CreateTeam"}}
+{"payload":
+{"payload":{"@type":"test.GetMessageHeaders"},"headers":{"messageId":"m9"}}
+`)
+			req, err := http2.NewRequest(http2.MethodPost, suite.srv.URL+"/process", body)
+			suite.Nil(err)
+			req.Header.Set("Content-Type", "application/x-ndjson")
+			res, err := http2.DefaultClient.Do(req)
+			suite.Nil(err)
+			defer func() { _ = res.Body.Close() }()
+			suite.Equal(http2.StatusOK, res.StatusCode)
+			suite.Equal("application/x-ndjson", res.Header.Get("Content-Type"))
+
+			handler := miruken.BuildUp(suite.Setup(), api.Polymorphic)
+			results, _, _, err := maps.Out[api.MessageStream](handler, res.Body, api.FromNdjson)
+			suite.Nil(err)
+
+			msg, err := results.Next()
+			suite.Nil(err)
+			suite.Equal("Tottenham", msg.Payload.(*TeamData).Name)
+
+			msg, err = results.Next()
+			suite.Nil(err)
+			var outcome *validates.Outcome
+			suite.ErrorAs(msg.Payload.(error), &outcome)
+			suite.Equal(`Name: "Name" is required`, outcome.Error())
+
+			msg, err = results.Next()
+			suite.Nil(err)
+			suite.Implements((*error)(nil), msg.Payload)
+
+			msg, err = results.Next()
+			suite.Nil(err)
+			suite.Equal("m9", msg.Payload.(*MessageHeaders).MessageId)
+
+			_, err = results.Next()
+			suite.Equal(io.EOF, err)
+		})
+
+		suite.Run("MultipartTooLarge", func() {
+			ctx, _ := setup.New(
+				TestFeature,
+				api.Feature(api.Multipart(api.MultipartOptions{MaxPartSize: 16})),
+				httpsrv.Feature(), stdjson.Feature()).
+				Specs(&api.GoPolymorphism{}).
+				Context()
+			defer ctx.End(nil)
+			srv := httptest.NewServer(httpsrv.Api(ctx))
+			defer srv.Close()
+
+			var b bytes.Buffer
+			mw := multipart.NewWriter(&b)
+			main, _ := mw.CreatePart(textproto.MIMEHeader{
+				"Content-Disposition": {`form-data; name="main"`},
+				"Content-Type":        {"application/json"},
+			})
+			_, _ = main.Write([]byte(`{"@type":"test.CreateTeam","Name":"Everton"}`))
+			roster, _ := mw.CreateFormFile("roster", "roster.txt")
+			_, _ = roster.Write([]byte(strings.Repeat("Pickford,Tarkowski,", 4)))
+			suite.Nil(mw.Close())
+
+			req, err := http2.NewRequest(http2.MethodPost, srv.URL+"/process", &b)
+			suite.Nil(err)
+			req.Header.Set("Content-Type", mw.FormDataContentType())
+			res, err := http2.DefaultClient.Do(req)
+			suite.Nil(err)
+			defer func() { _ = res.Body.Close() }()
+			suite.Equal(http2.StatusRequestEntityTooLarge, res.StatusCode)
+		})
+
 		suite.Run("UnknownFormat", func() {
 			handler := miruken.BuildUp(
 				suite.Setup(&BadFormatter{}),
@@ -0,0 +1,181 @@
+package test
+
+import (
+	json2 "encoding/json"
+	"errors"
+	http2 "net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/api/http"
+	"github.com/miruken-go/miruken/api/http/httpsrv"
+	"github.com/miruken-go/miruken/api/json/stdjson"
+	"github.com/miruken-go/miruken/creates"
+	"github.com/miruken-go/miruken/handles"
+	"github.com/miruken-go/miruken/maps"
+	"github.com/miruken-go/miruken/setup"
+	"github.com/miruken-go/miruken/validates"
+)
+
+type (
+	LockTeam struct {
+		Name string
+	}
+
+	TeamLockedError struct {
+		Name string
+	}
+
+	TeamProblemHandler struct{}
+)
+
+func (e *TeamLockedError) Error() string {
+	return "team " + e.Name + " is locked"
+}
+
+// TeamProblemHandler
+
+func (h *TeamProblemHandler) Lock(
+	_ *handles.It, lock *LockTeam,
+) (*TeamData, error) {
+	if lock.Name == "" {
+		return nil, errors.New("team name missing")
+	}
+	return nil, &TeamLockedError{Name: lock.Name}
+}
+
+func (h *TeamProblemHandler) ToProblem(
+	_ *struct {
+		maps.It
+		maps.Format `to:"http:problem"`
+	  }, err *TeamLockedError,
+) *http.Problem {
+	return &http.Problem{
+		Type:       "urn:test:problems:team-locked",
+		Title:      "Team locked",
+		Status:     http2.StatusConflict,
+		Detail:     err.Error(),
+		Extensions: map[string]any{"team": err.Name},
+	}
+}
+
+func (h *TeamProblemHandler) FromProblem(
+	_ *struct {
+		maps.It
+		maps.Format `from:"urn:test:problems:team-locked"`
+	  }, problem *http.Problem,
+) any {
+	name, _ := problem.Extensions["team"].(string)
+	return &TeamLockedError{Name: name}
+}
+
+func (h *TeamProblemHandler) New(
+	_ *struct {
+		creates.It `key:"test.LockTeam"`
+	}, create *creates.It,
+) any {
+	switch create.Key() {
+	case "test.LockTeam":
+		return new(LockTeam)
+	}
+	return nil
+}
+
+func (suite *ApiHandlerTestSuite) TestProblems() {
+	post := func(url, body, accept string) (*http2.Response, map[string]any) {
+		req, err := http2.NewRequest(http2.MethodPost, url+"/process", strings.NewReader(body))
+		suite.Require().Nil(err)
+		req.Header.Set("Content-Type", "application/json")
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		res, err := http2.DefaultClient.Do(req)
+		suite.Require().Nil(err)
+		defer func() { _ = res.Body.Close() }()
+		var doc map[string]any
+		if res.Header.Get("Content-Type") == http.ProblemMediaType {
+			suite.Nil(json2.NewDecoder(res.Body).Decode(&doc))
+		}
+		return res, doc
+	}
+
+	suite.Run("Accept", func() {
+		res, doc := post(suite.srv.URL, `{"payload":{"@type":"test.CreateTeam"}}`, http.ProblemMediaType)
+		suite.Equal(http2.StatusUnprocessableEntity, res.StatusCode)
+		suite.Equal(http.ProblemMediaType, res.Header.Get("Content-Type"))
+		suite.Equal(http.ValidationProblemType, doc["type"])
+		suite.Equal(float64(http2.StatusUnprocessableEntity), doc["status"])
+		suite.Equal(map[string]any{"Name": []any{`"Name" is required`}}, doc["errors"])
+	})
+
+	suite.Run("NotRequested", func() {
+		res, doc := post(suite.srv.URL, `{"payload":{"@type":"test.CreateTeam"}}`, "application/json")
+		suite.Equal(http2.StatusUnprocessableEntity, res.StatusCode)
+		suite.Equal("application/json", res.Header.Get("Content-Type"))
+		suite.Nil(doc)
+	})
+
+	ctx, err := setup.New(
+		TestFeature, httpsrv.Feature(httpsrv.Problems()), stdjson.Feature()).
+		Specs(&api.GoPolymorphism{}).
+		Context()
+	suite.Require().Nil(err)
+	defer ctx.End(nil)
+	srv := httptest.NewServer(httpsrv.Api(ctx))
+	defer srv.Close()
+
+	suite.Run("Default", func() {
+		res, doc := post(srv.URL, `{"payload":{"@type":"test.LockTeam"}}`, "")
+		suite.Equal(http2.StatusInternalServerError, res.StatusCode)
+		suite.Equal(http.ProblemMediaType, res.Header.Get("Content-Type"))
+		suite.Equal(http.DefaultProblemType, doc["type"])
+		suite.Equal("Internal Server Error", doc["title"])
+		suite.Equal("team name missing", doc["detail"])
+	})
+
+	suite.Run("Mapped", func() {
+		res, doc := post(srv.URL, `{"payload":{"@type":"test.LockTeam","Name":"Arsenal"}}`, "")
+		suite.Equal(http2.StatusConflict, res.StatusCode)
+		suite.Equal("urn:test:problems:team-locked", doc["type"])
+		suite.Equal("Team locked", doc["title"])
+		suite.Equal("team Arsenal is locked", doc["detail"])
+		suite.Equal("Arsenal", doc["team"])
+	})
+
+	suite.Run("Route", func() {
+		handler := suite.Setup()
+
+		_, pp, err := api.Send[*TeamData](handler, api.RouteTo(&CreateTeam{}, srv.URL))
+		suite.Nil(err)
+		_, err = pp.Await()
+		var outcome *validates.Outcome
+		suite.Require().ErrorAs(err, &outcome)
+		suite.Equal(`Name: "Name" is required`, outcome.Error())
+
+		_, pp, err = api.Send[*TeamData](handler, api.RouteTo(&LockTeam{Name: "Arsenal"}, srv.URL))
+		suite.Nil(err)
+		_, err = pp.Await()
+		var locked *TeamLockedError
+		suite.Require().ErrorAs(err, &locked)
+		suite.Equal("Arsenal", locked.Name)
+
+		_, pp, err = api.Send[*TeamData](handler, api.RouteTo(&LockTeam{}, srv.URL))
+		suite.Nil(err)
+		_, err = pp.Await()
+		var problem *http.ProblemError
+		suite.Require().ErrorAs(err, &problem)
+		suite.Equal(http2.StatusInternalServerError, problem.Problem.Status)
+		suite.Equal("team name missing", problem.Problem.Detail)
+	})
+
+	suite.Run("Json", func() {
+		problem := &http.Problem{Type: "urn:test", Status: 400, Extensions: map[string]any{"trace": "t1"}}
+		b, err := json2.Marshal(problem)
+		suite.Nil(err)
+		suite.JSONEq(`{"type":"urn:test","status":400,"trace":"t1"}`, string(b))
+		var decoded http.Problem
+		suite.Nil(json2.Unmarshal(b, &decoded))
+		suite.Equal(*problem, decoded)
+	})
+}
@@ -0,0 +1,170 @@
+package test
+
+import (
+	"fmt"
+	"io"
+	http2 "net/http"
+	"strings"
+
+	"github.com/miruken-go/miruken/api/http/httpsrv"
+	"github.com/miruken-go/miruken/handles"
+)
+
+type (
+	GetTeam struct {
+		Id     int32  `path:"id"`
+		Tenant string `header:"X-Tenant"`
+	}
+
+	FindTeams struct {
+		Names []string `query:"name"`
+		Limit int      `query:"limit"`
+	}
+
+	AddTeam struct {
+		Name    string
+		Players []PlayerData
+	}
+
+	RenameTeam struct {
+		Id   int32 `path:"id"`
+		Name string
+	}
+
+	RemoveTeam struct {
+		Id int32 `path:"id"`
+	}
+
+	TeamResourceHandler struct{}
+)
+
+// TeamResourceHandler
+
+func (t *TeamResourceHandler) Get(
+	_ *struct {
+		handles.It
+		httpsrv.Route `http:"GET /teams/{id}"`
+	}, get *GetTeam,
+) *TeamData {
+	return &TeamData{Id: get.Id, Name: fmt.Sprintf("%s Team %d", get.Tenant, get.Id)}
+}
+
+func (t *TeamResourceHandler) Find(
+	_ *struct {
+		handles.It
+		httpsrv.Route `http:"GET /teams"`
+	}, find FindTeams,
+) []TeamData {
+	teams := make([]TeamData, 0, len(find.Names))
+	for i, name := range find.Names {
+		if find.Limit > 0 && i >= find.Limit {
+			break
+		}
+		teams = append(teams, TeamData{Id: int32(i + 1), Name: name})
+	}
+	return teams
+}
+
+func (t *TeamResourceHandler) Add(
+	_ *struct {
+		handles.It
+		httpsrv.Route `http:"POST /teams" status:"201"`
+	}, add *AddTeam,
+) *TeamData {
+	return &TeamData{Id: 99, Name: add.Name, Players: add.Players}
+}
+
+func (t *TeamResourceHandler) Rename(
+	_ *struct {
+		handles.It
+		httpsrv.Route `http:"PUT /teams/{id}"`
+	}, rename *RenameTeam,
+) *TeamData {
+	return &TeamData{Id: rename.Id, Name: rename.Name}
+}
+
+func (t *TeamResourceHandler) Remove(
+	_ *struct {
+		handles.It
+		httpsrv.Route `http:"DELETE /teams/{id}"`
+	}, _ *RemoveTeam,
+) {
+}
+
+func (suite *ApiHandlerTestSuite) TestRoutes() {
+	do := func(method, path, body string, header ...string) (*http2.Response, string) {
+		var reader io.Reader
+		if body != "" {
+			reader = strings.NewReader(body)
+		}
+		req, err := http2.NewRequest(method, suite.srv.URL+path, reader)
+		suite.Nil(err)
+		for i := 0; i+1 < len(header); i += 2 {
+			req.Header.Set(header[i], header[i+1])
+		}
+		res, err := http2.DefaultClient.Do(req)
+		suite.Nil(err)
+		defer func() { _ = res.Body.Close() }()
+		b, err := io.ReadAll(res.Body)
+		suite.Nil(err)
+		return res, string(b)
+	}
+
+	suite.Run("Path", func() {
+		res, body := do(http2.MethodGet, "/teams/7", "", "X-Tenant", "Premier")
+		suite.Equal(http2.StatusOK, res.StatusCode)
+		suite.Equal("application/json", res.Header.Get("Content-Type"))
+		suite.JSONEq(`{"Id":7,"Name":"Premier Team 7","Players":null}`, body)
+	})
+
+	suite.Run("Query", func() {
+		res, body := do(http2.MethodGet, "/teams?name=Arsenal&name=Chelsea&name=Everton&limit=2", "")
+		suite.Equal(http2.StatusOK, res.StatusCode)
+		suite.JSONEq(`[{"Id":1,"Name":"Arsenal","Players":null},{"Id":2,"Name":"Chelsea","Players":null}]`, body)
+	})
+
+	suite.Run("Body", func() {
+		res, body := do(http2.MethodPost, "/teams",
+			`{"Name":"Brighton","Players":[{"Id":1,"Name":"Steele"}]}`,
+			"Content-Type", "application/json")
+		suite.Equal(http2.StatusCreated, res.StatusCode)
+		suite.JSONEq(`{"Id":99,"Name":"Brighton","Players":[{"Id":1,"Name":"Steele"}]}`, body)
+	})
+
+	suite.Run("BodyAndPath", func() {
+		res, body := do(http2.MethodPut, "/teams/3", `{"Id":1,"Name":"Wolves"}`,
+			"Content-Type", "application/json")
+		suite.Equal(http2.StatusOK, res.StatusCode)
+		suite.JSONEq(`{"Id":3,"Name":"Wolves","Players":null}`, body)
+	})
+
+	suite.Run("Negotiate", func() {
+		res, body := do(http2.MethodGet, "/teams/5", "", "Accept", "application/xml")
+		suite.Equal(http2.StatusOK, res.StatusCode)
+		suite.Equal("application/xml", res.Header.Get("Content-Type"))
+		suite.Contains(body, "<Id>5</Id>")
+	})
+
+	suite.Run("NoContent", func() {
+		res, body := do(http2.MethodDelete, "/teams/5", "")
+		suite.Equal(http2.StatusNoContent, res.StatusCode)
+		suite.Empty(body)
+	})
+
+	suite.Run("InvalidParam", func() {
+		res, body := do(http2.MethodGet, "/teams/abc", "")
+		suite.Equal(http2.StatusBadRequest, res.StatusCode)
+		suite.Contains(body, `invalid path parameter "id"`)
+	})
+
+	suite.Run("MethodNotAllowed", func() {
+		res, _ := do(http2.MethodPatch, "/teams/5", "")
+		suite.Equal(http2.StatusMethodNotAllowed, res.StatusCode)
+		suite.Contains(res.Header.Get("Allow"), http2.MethodGet)
+	})
+
+	suite.Run("NotFound", func() {
+		res, _ := do(http2.MethodPost, "/players/5", "{}", "Content-Type", "application/json")
+		suite.Equal(http2.StatusNotFound, res.StatusCode)
+	})
+}
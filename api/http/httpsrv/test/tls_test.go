@@ -0,0 +1,202 @@
+package test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	http2 "net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/api/http/httpsrv"
+	"github.com/miruken-go/miruken/api/json/stdjson"
+	"github.com/miruken-go/miruken/provides"
+	"github.com/miruken-go/miruken/setup"
+)
+
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA() (*testCA, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "Test CA"},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, err
+	}
+	return &testCA{cert, key, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}, nil
+}
+
+func (ca *testCA) pool() *x509.CertPool {
+	pool := x509.NewCertPool()
+	pool.AddCert(ca.cert)
+	return pool
+}
+
+func (ca *testCA) issue(
+	serial int64,
+	name   string,
+	usage  x509.ExtKeyUsage,
+) (certPEM, keyPEM []byte, err error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: name},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{usage},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		return nil, nil, err
+	}
+	keyDer, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDer}), nil
+}
+
+func (suite *ApiHandlerTestSuite) TestTLS() {
+	ca, err := newTestCA()
+	suite.Require().Nil(err)
+	dir := suite.T().TempDir()
+	certFile := filepath.Join(dir, "server.crt")
+	keyFile := filepath.Join(dir, "server.key")
+	caFile := filepath.Join(dir, "ca.crt")
+	writeCert := func(serial int64) {
+		certPEM, keyPEM, err := ca.issue(serial, "server", x509.ExtKeyUsageServerAuth)
+		suite.Require().Nil(err)
+		suite.Require().Nil(os.WriteFile(certFile, certPEM, 0600))
+		suite.Require().Nil(os.WriteFile(keyFile, keyPEM, 0600))
+	}
+	writeCert(2)
+	suite.Require().Nil(os.WriteFile(caFile, ca.pem, 0600))
+
+	clientPEM, clientKeyPEM, err := ca.issue(10, "client", x509.ExtKeyUsageClientAuth)
+	suite.Require().Nil(err)
+	clientCert, err := tls.X509KeyPair(clientPEM, clientKeyPEM)
+	suite.Require().Nil(err)
+
+	serve := func(tlsConfig *httpsrv.TLSConfig) (string, func()) {
+		ctx, err := setup.New(
+			TestFeature, stdjson.Feature(),
+			httpsrv.Feature(httpsrv.Serve(httpsrv.HostOptions{
+				Servers: []httpsrv.Config{{Addr: "127.0.0.1:0", TLS: tlsConfig}},
+			}))).
+			Specs(&api.GoPolymorphism{}).
+			Context()
+		suite.Require().Nil(err)
+		host, _, _, _ := provides.Type[*httpsrv.Host](ctx)
+		return "https://" + host.Addrs()[0].String(), func() { ctx.End(nil) }
+	}
+
+	client := func(certs ...tls.Certificate) *http2.Client {
+		return &http2.Client{Transport: &http2.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: ca.pool(), Certificates: certs},
+		}}
+	}
+
+	process := func(c *http2.Client, url string) (*http2.Response, error) {
+		body := `{"payload":{"@type":"test.CreateTeam","Name":"Villa"}}`
+		return c.Post(url+"/process", "application/json", strings.NewReader(body))
+	}
+
+	suite.Run("Serve", func() {
+		url, end := serve(&httpsrv.TLSConfig{CertFile: certFile, KeyFile: keyFile, MinVersion: "1.3"})
+		defer end()
+		res, err := process(client(), url)
+		suite.Require().Nil(err)
+		defer func() { _ = res.Body.Close() }()
+		suite.Equal(http2.StatusOK, res.StatusCode)
+		suite.Equal(uint16(tls.VersionTLS13), res.TLS.Version)
+		b, _ := io.ReadAll(res.Body)
+		suite.Contains(string(b), `"Name":"Villa"`)
+	})
+
+	suite.Run("MutualTLS", func() {
+		url, end := serve(&httpsrv.TLSConfig{
+			CertFile:     certFile,
+			KeyFile:      keyFile,
+			ClientCAFile: caFile,
+			ClientAuth:   "require-and-verify",
+		})
+		defer end()
+		res, err := process(client(clientCert), url)
+		suite.Require().Nil(err)
+		_ = res.Body.Close()
+		suite.Equal(http2.StatusOK, res.StatusCode)
+
+		_, err = process(client(), url)
+		suite.NotNil(err)
+	})
+
+	suite.Run("Reload", func() {
+		url, end := serve(&httpsrv.TLSConfig{
+			CertFile:       certFile,
+			KeyFile:        keyFile,
+			ReloadInterval: time.Nanosecond,
+		})
+		defer end()
+		serial := func() int64 {
+			c := client()
+			c.Transport.(*http2.Transport).DisableKeepAlives = true
+			res, err := process(c, url)
+			suite.Require().Nil(err)
+			_ = res.Body.Close()
+			return res.TLS.PeerCertificates[0].SerialNumber.Int64()
+		}
+		suite.Equal(int64(2), serial())
+		writeCert(3)
+		future := time.Now().Add(time.Second)
+		suite.Nil(os.Chtimes(certFile, future, future))
+		suite.Equal(int64(3), serial())
+	})
+
+	suite.Run("Invalid", func() {
+		_, err := setup.New(
+			TestFeature, stdjson.Feature(),
+			httpsrv.Feature(httpsrv.Serve(httpsrv.HostOptions{
+				Servers: []httpsrv.Config{{Addr: "127.0.0.1:0", TLS: &httpsrv.TLSConfig{
+					CertFile: certFile, KeyFile: keyFile, ClientAuth: "verify-if-given",
+				}}},
+			}))).
+			Specs(&api.GoPolymorphism{}).
+			Context()
+		suite.ErrorIs(err, httpsrv.ErrMissingClientCAs)
+	})
+}
@@ -0,0 +1,188 @@
+package httpsrv
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miruken-go/miruken/internal"
+)
+
+type (
+	// TLSConfig provides https configuration.
+	// Certificates and client CAs are reloaded when the files
+	// change so rotated certificates are used by new connections.
+	// ClientAuth is one of none, request, require, verify-if-given
+	// or require-and-verify and defaults to verify-if-given when a
+	// ClientCAFile is provided.
+	TLSConfig struct {
+		CertFile       string
+		KeyFile        string
+		MinVersion     string
+		ClientCAFile   string
+		ClientAuth     string
+		ReloadInterval time.Duration
+	}
+
+	// certReloader loads certificates and client CAs
+	// refreshing them when the files are modified.
+	certReloader struct {
+		config  TLSConfig
+		base    *tls.Config
+		cert    *tls.Certificate
+		pool    *x509.CertPool
+		mods    map[string]time.Time
+		checked time.Time
+		lock    sync.Mutex
+	}
+)
+
+// DefaultTLSReloadInterval is the default time between
+// checks for modified certificate files.
+const DefaultTLSReloadInterval = time.Minute
+
+var (
+	ErrMissingCertificate = errors.New("httpsrv: TLS requires a CertFile and KeyFile")
+	ErrMissingClientCAs   = errors.New("httpsrv: TLS client verification requires a ClientCAFile")
+)
+
+// Config builds the tls.Config for the server.
+func (c *TLSConfig) Config() (*tls.Config, error) {
+	if c.CertFile == "" || c.KeyFile == "" {
+		return nil, ErrMissingCertificate
+	}
+	minVersion, err := parseTLSVersion(c.MinVersion)
+	if err != nil {
+		return nil, err
+	}
+	clientAuth, err := parseClientAuth(c.ClientAuth, c.ClientCAFile != "")
+	if err != nil {
+		return nil, err
+	} else if clientAuth >= tls.VerifyClientCertIfGiven && c.ClientCAFile == "" {
+		return nil, ErrMissingClientCAs
+	}
+	r := &certReloader{config: *c, mods: make(map[string]time.Time)}
+	r.config.ReloadInterval = internal.DefaultValue(c.ReloadInterval, DefaultTLSReloadInterval)
+	if err := r.load(); err != nil {
+		return nil, err
+	}
+	r.base = &tls.Config{
+		MinVersion: minVersion,
+		ClientAuth: clientAuth,
+		NextProtos: []string{"h2", "http/1.1"},
+	}
+	return &tls.Config{
+		MinVersion:         minVersion,
+		ClientAuth:         clientAuth,
+		NextProtos:         r.base.NextProtos,
+		GetCertificate:     r.certificate,
+		GetConfigForClient: r.configForClient,
+	}, nil
+}
+
+// certReloader
+
+func (r *certReloader) certificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.cert, nil
+}
+
+func (r *certReloader) configForClient(*tls.ClientHelloInfo) (*tls.Config, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if time.Since(r.checked) >= r.config.ReloadInterval {
+		if r.modified() {
+			// keep serving the previous certificates if the
+			// files are incomplete while being rotated
+			_ = r.load()
+		}
+		r.checked = time.Now()
+	}
+	config := r.base.Clone()
+	config.Certificates = []tls.Certificate{*r.cert}
+	config.ClientCAs = r.pool
+	return config, nil
+}
+
+func (r *certReloader) modified() bool {
+	for _, file := range r.files() {
+		if info, err := os.Stat(file); err == nil && !info.ModTime().Equal(r.mods[file]) {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *certReloader) load() error {
+	cert, err := tls.LoadX509KeyPair(r.config.CertFile, r.config.KeyFile)
+	if err != nil {
+		return fmt.Errorf("httpsrv: unable to load certificate: %w", err)
+	}
+	var pool *x509.CertPool
+	if file := r.config.ClientCAFile; file != "" {
+		pem, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("httpsrv: unable to load client CAs: %w", err)
+		}
+		pool = x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return fmt.Errorf("httpsrv: no client CAs found in %q", file)
+		}
+	}
+	for _, file := range r.files() {
+		if info, err := os.Stat(file); err == nil {
+			r.mods[file] = info.ModTime()
+		}
+	}
+	r.cert = &cert
+	r.pool = pool
+	r.checked = time.Now()
+	return nil
+}
+
+func (r *certReloader) files() []string {
+	files := []string{r.config.CertFile, r.config.KeyFile}
+	if file := r.config.ClientCAFile; file != "" {
+		files = append(files, file)
+	}
+	return files
+}
+
+func parseTLSVersion(version string) (uint16, error) {
+	switch strings.TrimPrefix(strings.ToLower(strings.TrimSpace(version)), "tls") {
+	case "", "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	default:
+		return 0, fmt.Errorf("httpsrv: unsupported TLS MinVersion %q", version)
+	}
+}
+
+func parseClientAuth(auth string, hasCAs bool) (tls.ClientAuthType, error) {
+	switch strings.ToLower(strings.TrimSpace(auth)) {
+	case "":
+		if hasCAs {
+			return tls.VerifyClientCertIfGiven, nil
+		}
+		return tls.NoClientCert, nil
+	case "none":
+		return tls.NoClientCert, nil
+	case "request":
+		return tls.RequestClientCert, nil
+	case "require":
+		return tls.RequireAnyClientCert, nil
+	case "verify-if-given":
+		return tls.VerifyClientCertIfGiven, nil
+	case "require-and-verify":
+		return tls.RequireAndVerifyClientCert, nil
+	default:
+		return 0, fmt.Errorf("httpsrv: unsupported TLS ClientAuth %q", auth)
+	}
+}
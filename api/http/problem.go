@@ -0,0 +1,271 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/maps"
+	"github.com/miruken-go/miruken/validates"
+)
+
+type (
+	// Problem describes an error using the problem details
+	// for http apis defined by RFC 7807.  Extensions are
+	// additional members providing details of the problem.
+	Problem struct {
+		Type       string
+		Title      string
+		Status     int
+		Detail     string
+		Instance   string
+		Extensions map[string]any
+	}
+
+	// ProblemError is an error described by Problem details
+	// that could not be mapped to a more specific error.
+	ProblemError struct {
+		Problem *Problem
+	}
+
+	// ProblemMapper maps errors to and from Problem details.
+	// Errors are mapped to a Problem using the ToProblem format
+	// and from a Problem using a format named by its Type.
+	//
+	//	func (m *Mapper) ToProblem(
+	//	  _ *struct{
+	//	      maps.It
+	//	      maps.Format `to:"http:problem"`
+	//	    }, err *OrderError,
+	//	) *http.Problem
+	//
+	//	func (m *Mapper) FromProblem(
+	//	  _ *struct{
+	//	      maps.It
+	//	      maps.Format `from:"urn:example:problems:order"`
+	//	    }, problem *http.Problem,
+	//	) any
+	//
+	// Problem is not an error and mappings from a Problem return any
+	// since bindings declaring an error result are interpreted as
+	// only returning a failure.
+	ProblemMapper struct{}
+)
+
+const (
+	// ProblemMediaType is the media type of Problem details.
+	ProblemMediaType = "application/problem+json"
+
+	// DefaultProblemType is the Type of Problem details
+	// having no semantics beyond the status code.
+	DefaultProblemType = "about:blank"
+
+	// ValidationProblemType is the Type of Problem details
+	// describing a validates.Outcome.  The field errors are
+	// in the "errors" extension keyed by field path.
+	ValidationProblemType = "urn:miruken:problems:validation"
+)
+
+var (
+	// ToProblem maps an error into Problem details.
+	ToProblem = maps.To("http:problem", nil)
+
+	problemMembers = []string{"type", "title", "status", "detail", "instance"}
+)
+
+// Problem
+
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	members := make(map[string]any, len(p.Extensions)+len(problemMembers))
+	for name, value := range p.Extensions {
+		members[name] = value
+	}
+	if p.Type != "" {
+		members["type"] = p.Type
+	}
+	if p.Title != "" {
+		members["title"] = p.Title
+	}
+	if p.Status != 0 {
+		members["status"] = p.Status
+	}
+	if p.Detail != "" {
+		members["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		members["instance"] = p.Instance
+	}
+	return json.Marshal(members)
+}
+
+func (p *Problem) UnmarshalJSON(data []byte) error {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(data, &members); err != nil {
+		return err
+	}
+	var problem Problem
+	fields := []any{&problem.Type, &problem.Title, &problem.Status, &problem.Detail, &problem.Instance}
+	for i, name := range problemMembers {
+		if value, ok := members[name]; ok {
+			if err := json.Unmarshal(value, fields[i]); err != nil {
+				return err
+			}
+			delete(members, name)
+		}
+	}
+	for name, value := range members {
+		var ext any
+		if err := json.Unmarshal(value, &ext); err != nil {
+			return err
+		}
+		if problem.Extensions == nil {
+			problem.Extensions = make(map[string]any, len(members))
+		}
+		problem.Extensions[name] = ext
+	}
+	*p = problem
+	return nil
+}
+
+// ProblemError
+
+func (e *ProblemError) Error() string {
+	p := e.Problem
+	title := p.Title
+	if title == "" {
+		title = http.StatusText(p.Status)
+	}
+	if p.Detail == "" {
+		return title
+	} else if title == "" {
+		return p.Detail
+	}
+	return title + ": " + p.Detail
+}
+
+// ProblemMapper
+
+func (m *ProblemMapper) Validation(
+	_ *struct {
+		maps.It
+		maps.Format `to:"http:problem"`
+	  }, outcome *validates.Outcome,
+) *Problem {
+	errs := make(map[string][]string)
+	addOutcomeErrors(errs, "", outcome)
+	return &Problem{
+		Type:       ValidationProblemType,
+		Title:      "One or more validation errors occurred",
+		Status:     http.StatusUnprocessableEntity,
+		Extensions: map[string]any{"errors": errs},
+	}
+}
+
+func (m *ProblemMapper) Outcome(
+	_ *struct {
+		maps.It
+		maps.Format `from:"urn:miruken:problems:validation"`
+	  }, problem *Problem,
+) any {
+	outcome := &validates.Outcome{}
+	switch errs := problem.Extensions["errors"].(type) {
+	case map[string][]string:
+		for field, messages := range errs {
+			for _, msg := range messages {
+				outcome.AddError(field, errors.New(msg))
+			}
+		}
+	case map[string]any:
+		for field, messages := range errs {
+			if msgs, ok := messages.([]any); ok {
+				for _, msg := range msgs {
+					if s, ok := msg.(string); ok {
+						outcome.AddError(field, errors.New(s))
+					}
+				}
+			}
+		}
+	}
+	return outcome
+}
+
+// NewProblem returns the Problem details of an error.
+// The error is mapped using the ToProblem format, defaulting
+// to a Problem with the error message as the detail.
+// The status code is used if the mapping does not provide one.
+func NewProblem(
+	handler    miruken.Handler,
+	err        error,
+	statusCode int,
+) *Problem {
+	var problem *Problem
+	if pe, ok := err.(*ProblemError); ok {
+		cp := *pe.Problem
+		problem = &cp
+	} else if p, _, _, e := maps.Out[*Problem](handler, err, ToProblem); e == nil && p != nil {
+		problem = p
+	} else {
+		problem = &Problem{Detail: err.Error()}
+	}
+	if problem.Type == "" {
+		problem.Type = DefaultProblemType
+	}
+	if problem.Status == 0 {
+		problem.Status = statusCode
+	}
+	if problem.Title == "" {
+		problem.Title = http.StatusText(problem.Status)
+	}
+	return problem
+}
+
+// ErrorFromProblem returns the typed error described by the Problem.
+// The Problem is mapped from a format named by its Type and is
+// returned as a ProblemError if no mapping exists.
+func ErrorFromProblem(
+	handler miruken.Handler,
+	problem *Problem,
+) error {
+	if format := problemFormat(problem.Type); format != nil {
+		// returned errors fail the mapping so are reported as such
+		res, _, _, err := maps.Out[any](handler, problem, format)
+		var nh *miruken.NotHandledError
+		if err != nil && !errors.As(err, &nh) {
+			return err
+		} else if err, ok := res.(error); ok {
+			return err
+		}
+	}
+	return &ProblemError{Problem: problem}
+}
+
+// problemFormat returns the format to map a Problem from.
+// Types that cannot name a maps.Format are skipped.
+func problemFormat(typ string) *maps.Format {
+	typ = strings.TrimSpace(typ)
+	if typ == "" || typ == "*" || typ == DefaultProblemType ||
+		strings.HasPrefix(typ, "/") || strings.HasSuffix(typ, "/") {
+		return nil
+	}
+	return maps.From(typ, nil)
+}
+
+// addOutcomeErrors adds the field errors using their full path.
+func addOutcomeErrors(
+	errs    map[string][]string,
+	prefix  string,
+	outcome *validates.Outcome,
+) {
+	for _, field := range outcome.Fields() {
+		path := prefix + field
+		for _, err := range outcome.FieldErrors(field) {
+			if child, ok := err.(*validates.Outcome); ok {
+				addOutcomeErrors(errs, path+".", child)
+			} else {
+				errs[path] = append(errs[path], err.Error())
+			}
+		}
+	}
+}
@@ -2,11 +2,15 @@ package http
 
 import (
 	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/textproto"
 	"net/url"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/miruken-go/miruken"
@@ -35,8 +39,10 @@ type (
 	) (*http.Response, error)
 
 	// Options customize http operations.
+	// Accept lists additional response formats in order of preference.
 	Options struct {
 		Format      string
+		Accept      []string
 		ProcessPath string
 		PublishPath string
 		Pipeline    []Policy
@@ -89,9 +95,11 @@ func (r *Router) Route(
 
 		composer := miruken.BuildUp(ctx.Composer, api.Polymorphic)
 
+		headers, _ := api.CurrentHeaders(ctx.Composer)
+
 		var b bytes.Buffer
 		out := io.Writer(&b)
-		msg := api.Message{Payload: routed.Message}
+		msg := api.Message{Payload: routed.Message, Headers: headers}
 		if _, _, err = maps.Into(composer, msg, &out, to); err != nil {
 			reject(fmt.Errorf("http router: %w", err))
 		}
@@ -102,6 +110,8 @@ func (r *Router) Route(
 			return
 		}
 		req.Header.Add("Content-Type", format)
+		req.Header.Add("Accept", acceptHeader(format, options.Accept))
+		api.WriteHeaders(headers, textproto.MIMEHeader(req.Header))
 
 		res, err := r.invoke(req, composer, options.Pipeline)
 
@@ -176,6 +186,16 @@ func (r *Router) decodeError(
 	if err != nil {
 		return err
 	}
+	if from.Name() == ProblemMediaType {
+		var problem Problem
+		if err := json.NewDecoder(res.Body).Decode(&problem); err != nil {
+			return err
+		}
+		if problem.Status == 0 {
+			problem.Status = res.StatusCode
+		}
+		return ErrorFromProblem(composer, &problem)
+	}
 	msg, _, _, err := maps.Out[api.Message](composer, res.Body, from)
 	if err == nil {
 		if payload := msg.Payload; payload != nil {
@@ -222,12 +242,42 @@ func Format(format string) miruken.Builder {
 	return miruken.Options(Options{Format: format})
 }
 
+// Accept returns a miruken.Builder requesting additional
+// response formats in order of preference.
+func Accept(formats ...string) miruken.Builder {
+	return miruken.Options(Options{Accept: formats})
+}
+
 // Pipeline returns a miruken.Builder that registers policies to
 // apply during http request processing.
 func Pipeline(policies ...Policy) miruken.Builder {
 	return miruken.Options(Options{Pipeline: policies})
 }
 
+// acceptHeader builds the Accept header preferring the request
+// format followed by the accepted formats with decreasing quality.
+func acceptHeader(
+	format string,
+	accept []string,
+) string {
+	var sb strings.Builder
+	sb.WriteString(format)
+	q := 10
+	for _, a := range accept {
+		if a == "" || a == format {
+			continue
+		}
+		if q > 1 {
+			q--
+		}
+		sb.WriteString(", ")
+		sb.WriteString(a)
+		sb.WriteString(";q=0.")
+		sb.WriteString(strconv.Itoa(q))
+	}
+	return sb.String()
+}
+
 // newDefaultHttpClient creates an optimized http.Client
 // https://www.loginradius.com/blog/engineering/tune-the-go-http-client-for-high-performance/
 func newDefaultHttpClient() *http.Client {
@@ -10,7 +10,10 @@ import (
 )
 
 // Outcome is a surrogate for validates.Outcome over json.
-type Outcome []struct {
+type Outcome []OutcomeField
+
+// OutcomeField holds the errors of a validates.Outcome field.
+type OutcomeField struct {
 	PropertyName string
 	Errors       []string
 	Nested       Outcome
@@ -27,28 +30,25 @@ func (m *SurrogateMapper) ReplaceOutcome(
 	}, outcome *validates.Outcome,
 	ctx miruken.HandleContext,
 ) ([]byte, error) {
-	sur := outcomeToSurrogate(outcome)
+	sur := NewOutcome(outcome)
 	js, _, _, err := maps.Out[[]byte](ctx, sur, api.ToJson)
 	return js, err
 }
 
-func outcomeToSurrogate(outcome *validates.Outcome) Outcome {
+// NewOutcome returns the surrogate for the validates.Outcome.
+func NewOutcome(outcome *validates.Outcome) Outcome {
 	var sur Outcome
 	for _, field := range outcome.Fields() {
 		var messages []string
 		var children Outcome
 		for _, err := range outcome.FieldErrors(field) {
 			if child, ok := err.(*validates.Outcome); ok {
-				children = append(children, outcomeToSurrogate(child)...)
+				children = append(children, NewOutcome(child)...)
 			} else {
 				messages = append(messages, err.Error())
 			}
 		}
-		sur = append(sur, struct {
-			PropertyName string
-			Errors       []string
-			Nested       Outcome
-		}{
+		sur = append(sur, OutcomeField{
 			PropertyName: field,
 			Errors:       messages,
 			Nested:       children,
@@ -59,18 +59,26 @@ func outcomeToSurrogate(outcome *validates.Outcome) Outcome {
 
 func surrogateToOutcome(surrogate Outcome) *validates.Outcome {
 	outcome := &validates.Outcome{}
+	addSurrogateErrors(outcome, "", surrogate)
+	return outcome
+}
+
+// addSurrogateErrors adds the errors of nested fields using
+// their full path since Outcomes cannot be added directly.
+func addSurrogateErrors(
+	outcome   *validates.Outcome,
+	prefix    string,
+	surrogate Outcome,
+) {
 	for _, sur := range surrogate {
-		field := sur.PropertyName
-		if failures := sur.Errors; len(failures) > 0 {
-			for _, msg := range failures {
-				outcome.AddError(field, errors.New(msg))
-			}
+		field := prefix + sur.PropertyName
+		for _, msg := range sur.Errors {
+			outcome.AddError(field, errors.New(msg))
 		}
 		if nested := sur.Nested; len(nested) > 0 {
-			outcome.AddError(field, surrogateToOutcome(nested))
+			addSurrogateErrors(outcome, field+".", nested)
 		}
 	}
-	return outcome
 }
 
 // Error is a surrogate for a generic error over json.
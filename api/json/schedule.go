@@ -1,6 +1,9 @@
 package json
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/miruken-go/miruken"
 	"github.com/miruken-go/miruken/api"
 	"github.com/miruken-go/miruken/maps"
@@ -8,20 +11,79 @@ import (
 
 type (
 	// Concurrent is a surrogate for api.ConcurrentBatch over json.
+	// The batch options are included as a leading ConcurrentOptions
+	// element only if present.
 	Concurrent []any
 
+	// ConcurrentOptions is a surrogate for the api.ConcurrentBatch
+	// options over json.  Timeout is a duration string such as 2s.
+	ConcurrentOptions struct {
+		MaxParallelism int
+		FailFast       bool
+		Timeout        string
+	}
+
 	// Sequential is a surrogate for api.SequentialBatch over json.
 	Sequential []any
 )
 
 // Concurrent
 
+// NewConcurrent returns the surrogate for the api.ConcurrentBatch.
+func NewConcurrent(batch api.ConcurrentBatch) Concurrent {
+	sur := Concurrent(batch.Requests)
+	if batch.MaxParallelism > 0 || batch.FailFast || batch.Timeout > 0 {
+		options := ConcurrentOptions{
+			MaxParallelism: batch.MaxParallelism,
+			FailFast:       batch.FailFast,
+		}
+		if timeout := batch.Timeout; timeout > 0 {
+			options.Timeout = timeout.String()
+		}
+		sur = append(Concurrent{options}, sur...)
+	} else if sur == nil {
+		sur = make(Concurrent, 0)
+	}
+	return sur
+}
+
 func (c Concurrent) Original(miruken.Handler) (any, error) {
-	return &api.ConcurrentBatch{Requests: c}, nil
+	batch := &api.ConcurrentBatch{Requests: c}
+	if len(c) > 0 {
+		var options *ConcurrentOptions
+		switch opt := c[0].(type) {
+		case *ConcurrentOptions:
+			options = opt
+		case ConcurrentOptions:
+			options = &opt
+		}
+		if options != nil {
+			batch.Requests       = c[1:]
+			batch.MaxParallelism = options.MaxParallelism
+			batch.FailFast       = options.FailFast
+			if timeout := options.Timeout; timeout != "" {
+				d, err := time.ParseDuration(timeout)
+				if err != nil {
+					return nil, fmt.Errorf("invalid concurrent timeout %q: %w", timeout, err)
+				}
+				batch.Timeout = d
+			}
+		}
+	}
+	return batch, nil
 }
 
 // Sequential
 
+// NewSequential returns the surrogate for the api.SequentialBatch.
+func NewSequential(batch api.SequentialBatch) Sequential {
+	sur := Sequential(batch.Requests)
+	if sur == nil {
+		sur = make(Sequential, 0)
+	}
+	return sur
+}
+
 func (s Sequential) Original(miruken.Handler) (any, error) {
 	return &api.SequentialBatch{Requests: s}, nil
 }
@@ -35,11 +97,7 @@ func (m *SurrogateMapper) ReplaceConcurrent(
 	  }, batch api.ConcurrentBatch,
 	ctx miruken.HandleContext,
 ) (byt []byte, err error) {
-	sur := Concurrent(batch.Requests)
-	if sur == nil {
-		sur = make(Concurrent, 0)
-	}
-	byt, _, _, err = maps.Out[[]byte](ctx, sur, api.ToJson)
+	byt, _, _, err = maps.Out[[]byte](ctx, NewConcurrent(batch), api.ToJson)
 	return
 }
 
@@ -50,10 +108,6 @@ func (m *SurrogateMapper) ReplaceSequential(
 	  }, batch api.SequentialBatch,
 	ctx miruken.HandleContext,
 ) (byt []byte, err error) {
-	sur := Sequential(batch.Requests)
-	if sur == nil {
-		sur = make(Sequential, 0)
-	}
-	byt, _, _, err = maps.Out[[]byte](ctx, sur, api.ToJson)
+	byt, _, _, err = maps.Out[[]byte](ctx, NewSequential(batch), api.ToJson)
 	return
 }
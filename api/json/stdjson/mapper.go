@@ -115,6 +115,7 @@ func marshal(
 		src = &typeContainer{
 			v:        src,
 			typInfo:  apiOptions.TypeInfoFormat,
+			versions: apiOptions.Versions,
 			trans:    options.Transformers,
 			composer: composer,
 		}
@@ -175,6 +176,7 @@ func encode(
 		src = &typeContainer{
 			v:        src,
 			typInfo:  apiOptions.TypeInfoFormat,
+			versions: apiOptions.Versions,
 			trans:    options.Transformers,
 			composer: composer,
 		}
@@ -3,16 +3,31 @@ package stdjson
 import (
 	"encoding/json"
 	"io"
+	"time"
 
 	"github.com/miruken-go/miruken"
 	"github.com/miruken-go/miruken/api"
 	"github.com/miruken-go/miruken/maps"
 )
 
-// MessageSurrogate is a json standard surrogate for api.Message.
-type MessageSurrogate struct {
-	Payload json.RawMessage `json:"payload"`
-}
+type (
+	// MessageSurrogate is a json standard surrogate for api.Message.
+	MessageSurrogate struct {
+		Payload json.RawMessage   `json:"payload"`
+		Headers *HeadersSurrogate `json:"headers,omitempty"`
+	}
+
+	// HeadersSurrogate is a json standard surrogate for api.Headers.
+	HeadersSurrogate struct {
+		MessageId     string            `json:"messageId,omitempty"`
+		CorrelationId string            `json:"correlationId,omitempty"`
+		CausationId   string            `json:"causationId,omitempty"`
+		Tenant        string            `json:"tenant,omitempty"`
+		SentAt        *time.Time        `json:"sentAt,omitempty"`
+		Deadline      *time.Time        `json:"deadline,omitempty"`
+		Custom        map[string]string `json:"custom,omitempty"`
+	}
+)
 
 func (m *SurrogateMapper) EncodeMessage(
 	_ *struct {
@@ -30,6 +45,9 @@ func (m *SurrogateMapper) EncodeMessage(
 			}
 			sur.Payload = pb
 		}
+		if headers := msg.Headers; !headers.IsZero() {
+			sur.Headers = encodeHeaders(headers)
+		}
 		enc := json.NewEncoder(*writer)
 		if err := enc.Encode(sur); err == nil {
 			it.TargetForWrite()
@@ -53,6 +71,9 @@ func (m *SurrogateMapper) DecodeMessage(
 		if err = dec.Decode(&sur); err != nil {
 			return
 		}
+		if headers := sur.Headers; headers != nil {
+			mp.Headers = headers.decode()
+		}
 		if payload := sur.Payload; payload != nil {
 			var late api.Late
 			composer := ctx.Composer
@@ -67,3 +88,39 @@ func (m *SurrogateMapper) DecodeMessage(
 	}
 	return
 }
+
+// HeadersSurrogate
+
+func (h *HeadersSurrogate) decode() api.Headers {
+	headers := api.Headers{
+		MessageId:     h.MessageId,
+		CorrelationId: h.CorrelationId,
+		CausationId:   h.CausationId,
+		Tenant:        h.Tenant,
+		Custom:        h.Custom,
+	}
+	if h.SentAt != nil {
+		headers.SentAt = *h.SentAt
+	}
+	if h.Deadline != nil {
+		headers.Deadline = *h.Deadline
+	}
+	return headers
+}
+
+func encodeHeaders(headers api.Headers) *HeadersSurrogate {
+	sur := &HeadersSurrogate{
+		MessageId:     headers.MessageId,
+		CorrelationId: headers.CorrelationId,
+		CausationId:   headers.CausationId,
+		Tenant:        headers.Tenant,
+		Custom:        headers.Custom,
+	}
+	if sentAt := headers.SentAt; !sentAt.IsZero() {
+		sur.SentAt = &sentAt
+	}
+	if deadline := headers.Deadline; !deadline.IsZero() {
+		sur.Deadline = &deadline
+	}
+	return sur
+}
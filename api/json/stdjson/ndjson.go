@@ -0,0 +1,99 @@
+package stdjson
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"io"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/internal"
+	"github.com/miruken-go/miruken/maps"
+)
+
+// ndjsonStream reads one json encoded api.Message per line.
+// Lines are decoded on demand so the input is never buffered.
+type ndjsonStream struct {
+	reader   *bufio.Reader
+	composer miruken.Handler
+	err      error
+}
+
+func (s *ndjsonStream) Next() (api.Message, error) {
+	for s.err == nil {
+		line, err := s.reader.ReadBytes('\n')
+		if err != nil {
+			s.err = err
+		}
+		if line = bytes.TrimSpace(line); len(line) == 0 {
+			continue
+		}
+		msg, _, _, err := maps.Out[api.Message](s.composer, bytes.NewReader(line), api.FromJson)
+		if errors.Is(err, io.EOF) {
+			// a truncated line must not end the stream
+			err = io.ErrUnexpectedEOF
+		}
+		return msg, err
+	}
+	err := s.err
+	s.err = io.EOF
+	if errors.Is(err, io.EOF) {
+		return api.Message{}, io.EOF
+	}
+	return api.Message{}, err
+}
+
+func (m *Mapper) ToNdjson(
+	_ *struct {
+		maps.Format `to:"application/x-ndjson"`
+	}, stream api.MessageStream,
+	it *maps.It,
+	ctx miruken.HandleContext,
+) (io.Writer, error) {
+	if writer, ok := it.Target().(*io.Writer); ok && !internal.IsNil(*writer) {
+		it.TargetForWrite()
+		var b bytes.Buffer
+		for {
+			msg, err := stream.Next()
+			if errors.Is(err, io.EOF) {
+				break
+			} else if err != nil {
+				msg = api.Message{Payload: err}
+			}
+			b.Reset()
+			out := io.Writer(&b)
+			if _, _, err := maps.Into(ctx.Composer, msg, &out, api.ToJson); err != nil {
+				return nil, err
+			}
+			line := append(bytes.TrimRight(b.Bytes(), "\n"), '\n')
+			if _, err := (*writer).Write(line); err != nil {
+				return nil, err
+			}
+			if flusher, ok := (*writer).(interface{ Flush() }); ok {
+				flusher.Flush()
+			}
+		}
+		return *writer, nil
+	}
+	return nil, nil
+}
+
+func (m *Mapper) FromNdjson(
+	_ *struct {
+		maps.It
+		maps.Format `from:"application/x-ndjson"`
+	}, reader io.Reader,
+	it *maps.It,
+	ctx miruken.HandleContext,
+) (stream api.MessageStream, err error) {
+	if sp, ok := it.Target().(*api.MessageStream); ok {
+		it.TargetForWrite()
+		stream = &ndjsonStream{
+			reader:   bufio.NewReader(reader),
+			composer: ctx.Composer,
+		}
+		*sp = stream
+	}
+	return
+}
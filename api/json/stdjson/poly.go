@@ -23,6 +23,7 @@ type (
 	typeContainer struct {
 		v        any
 		typInfo  string
+		versions map[string]int
 		trans    []transform.Transformer
 		composer miruken.Handler
 	}
@@ -61,6 +62,7 @@ func (c *typeContainer) MarshalJSON() ([]byte, error) {
 				elem = &typeContainer{
 					v:        elem,
 					typInfo:  c.typInfo,
+					versions: c.versions,
 					trans:    c.trans,
 					composer: c.composer,
 				}
@@ -73,6 +75,11 @@ func (c *typeContainer) MarshalJSON() ([]byte, error) {
 			}
 		}
 		v = arr
+	} else if versions := c.versions; len(versions) > 0 {
+		var err error
+		if v, err = api.DowncastTo(c.composer, v, versions, c.typeInfo()); err != nil {
+			return nil, err
+		}
 	}
 	vm := v
 	if trans := c.trans; len(trans) > 0 {
@@ -196,14 +203,8 @@ func (c *typeContainer) UnmarshalJSON(data []byte) error {
 			}
 			if err := json.Unmarshal(data, vm); err != nil {
 				return err
-			} else {
-				if late, ok := c.v.(*api.Late); ok {
-					late.Value = v
-				} else {
-					internal.CopyIndirect(v, c.v)
-				}
 			}
+			return api.AssignDecoded(c.composer, typeId, v, c.v)
 		}
 	}
-	return nil
 }
@@ -8,8 +8,10 @@ import (
 
 var TestFeature setup.Feature = setup.FeatureFunc(func(setup *setup.Builder) error {
 	setup.Specs(
+		&OrderHandler{},
 		&PlayerMapper{},
 		&TypeIdMapper{},
+		&VersionMapper{},
 	)
 	return nil
 })
@@ -7,6 +7,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/miruken-go/miruken"
 	"github.com/miruken-go/miruken/api"
@@ -608,6 +609,108 @@ func (suite *StdJsonTestSuite) TestJson() {
 			})
 		})
 	})
+
+	suite.Run("Message", func() {
+		handler := miruken.BuildUp(suite.Setup(), api.Polymorphic)
+		sentAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+		headers := api.Headers{
+			MessageId:     "m2",
+			CorrelationId: "c1",
+			CausationId:   "m1",
+			Tenant:        "acme",
+			SentAt:        sentAt,
+		}
+		headers.Set("Priority", "high")
+
+		suite.Run("Headers", func() {
+			var b bytes.Buffer
+			out := io.Writer(&b)
+			msg := api.Message{Payload: &TeamData{Id: 1, Name: "Arsenal"}, Headers: headers}
+			_, _, err := maps.Into(handler, msg, &out, api.ToJson)
+			suite.Nil(err)
+			suite.Contains(b.String(), `"headers":{"messageId":"m2","correlationId":"c1","causationId":"m1","tenant":"acme","sentAt":"2024-05-01T12:00:00Z","custom":{"Priority":"high"}}`)
+
+			read, _, _, err := maps.Out[api.Message](handler, &b, api.FromJson)
+			suite.Nil(err)
+			suite.Equal(&TeamData{Id: 1, Name: "Arsenal"}, read.Payload)
+			suite.Equal(headers, read.Headers)
+		})
+
+		suite.Run("NoHeaders", func() {
+			var b bytes.Buffer
+			out := io.Writer(&b)
+			msg := api.Message{Payload: &TeamData{Id: 1}}
+			_, _, err := maps.Into(handler, msg, &out, api.ToJson)
+			suite.Nil(err)
+			suite.NotContains(b.String(), "headers")
+		})
+
+		suite.Run("Multipart", func() {
+			var wpb api.WritePartsBuilder
+			main := wpb.NewPart().
+				MediaType("application/json").
+				Body(&TeamData{Id: 2, Name: "Everton"}).
+				Build()
+			pc := wpb.MainPart(main).Build()
+			var b bytes.Buffer
+			out := io.Writer(&b)
+			to := maps.To("multipart/mixed", map[string]string{"boundary": "envelope"})
+			msg := api.Message{Payload: pc, Headers: headers}
+			_, _, err := maps.Into(handler, msg, &out, to)
+			suite.Nil(err)
+			suite.Contains(b.String(), "X-Correlation-Id: c1")
+
+			from := maps.From("multipart/mixed", map[string]string{"boundary": "envelope"})
+			read, _, _, err := maps.Out[api.Message](handler, &b, from)
+			suite.Nil(err)
+			suite.Equal(headers, read.Headers)
+			parts, ok := read.Payload.(api.PartContainer)
+			suite.True(ok)
+			suite.Equal(&TeamData{Id: 2, Name: "Everton"}, parts.MainPart().Body())
+			suite.NotContains(parts.MainPart().Metadata(), api.HeaderMessageId)
+		})
+	})
+
+	suite.Run("Ndjson", func() {
+		handler := miruken.BuildUp(suite.Setup(), api.Polymorphic)
+
+		suite.Run("Write", func() {
+			var b bytes.Buffer
+			out := io.Writer(&b)
+			stream := api.Messages(
+				api.Message{Payload: &TeamData{Id: 1, Name: "Arsenal"}},
+				api.Message{Payload: &PlayerData{Id: 2, Name: "Saka"}, Headers: api.Headers{MessageId: "m2"}})
+			_, _, err := maps.Into(handler, stream, &out, api.ToNdjson)
+			suite.Nil(err)
+			suite.Equal(`{"payload":{"@type":"test.TeamData","Id":1,"Name":"Arsenal","Players":null}}
+{"payload":{"@type":"test.PlayerData","Id":2,"Name":"Saka"},"headers":{"messageId":"m2"}}
+`, b.String())
+		})
+
+		suite.Run("Read", func() {
+			body := strings.NewReader(`{"payload":{"@type":"test.TeamData","Id":1,"Name":"Arsenal"}}
+
+{"payload":
+{"payload":{"@type":"test.TeamData","Id":2,"Name":"Chelsea"},"headers":{"messageId":"m2"}}`)
+			stream, _, _, err := maps.Out[api.MessageStream](handler, body, api.FromNdjson)
+			suite.Nil(err)
+
+			msg, err := stream.Next()
+			suite.Nil(err)
+			suite.Equal(&TeamData{Id: 1, Name: "Arsenal"}, msg.Payload)
+
+			_, err = stream.Next()
+			suite.NotNil(err)
+
+			msg, err = stream.Next()
+			suite.Nil(err)
+			suite.Equal(&TeamData{Id: 2, Name: "Chelsea"}, msg.Payload)
+			suite.Equal("m2", msg.Headers.MessageId)
+
+			_, err = stream.Next()
+			suite.Equal(io.EOF, err)
+		})
+	})
 }
 
 func TestStdJsonTestSuite(t *testing.T) {
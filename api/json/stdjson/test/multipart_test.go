@@ -0,0 +1,136 @@
+package test
+
+import (
+	"bytes"
+	context2 "context"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/maps"
+	"github.com/miruken-go/miruken/provides"
+)
+
+func (suite *StdJsonTestSuite) TestMultipart() {
+	to := maps.To("multipart/form-data", map[string]string{"boundary": "envelope"})
+	from := maps.From("multipart/form-data", map[string]string{"boundary": "envelope"})
+
+	envelope := func(handler miruken.Handler, content string) *bytes.Buffer {
+		var wpb api.WritePartsBuilder
+		main := wpb.NewPart().
+			MediaType("application/json").
+			Body(&TeamData{Id: 2, Name: "Everton"}).
+			Build()
+		file := wpb.NewPart().
+			MediaType("text/plain").
+			Filename("roster.txt").
+			Body(strings.NewReader(content)).
+			Build()
+		pc := wpb.MainPart(main).AddPart("roster", file).Build()
+		var b bytes.Buffer
+		out := io.Writer(&b)
+		_, _, err := maps.Into(handler, api.Message{Payload: pc}, &out, to)
+		suite.Nil(err)
+		return &b
+	}
+
+	spooled := func(dir string) int {
+		entries, err := os.ReadDir(dir)
+		suite.Nil(err)
+		return len(entries)
+	}
+
+	suite.Run("Spool", func() {
+		dir := suite.T().TempDir()
+		ctx, err := suite.SetupTypes(api.Multipart(api.MultipartOptions{
+			SpoolThreshold: 16,
+			SpoolDir:       dir,
+		}))
+		suite.Nil(err)
+		defer ctx.End(nil)
+		reqCtx, cancel := context2.WithCancel(context2.Background())
+		defer cancel()
+		handler := miruken.BuildUp(ctx, api.Polymorphic, provides.With(reqCtx))
+
+		content := strings.Repeat("Pickford,Tarkowski,", 4)
+		read, _, _, err := maps.Out[api.Message](handler, envelope(handler, content), from)
+		suite.Nil(err)
+		parts := read.Payload.(api.PartContainer)
+		suite.Equal(&TeamData{Id: 2, Name: "Everton"}, parts.MainPart().Body())
+		suite.Equal(1, spooled(dir))
+
+		roster := parts.Parts()["roster"][0]
+		suite.Equal("roster.txt", roster.Filename())
+		body, ok := roster.Body().(io.ReadCloser)
+		suite.True(ok)
+		data, err := io.ReadAll(body)
+		suite.Nil(err)
+		suite.Equal(content, string(data))
+		suite.Nil(body.Close())
+		suite.Equal(1, spooled(dir))
+
+		cancel()
+		suite.Eventually(func() bool {
+			return spooled(dir) == 0
+		}, time.Second, 10*time.Millisecond)
+	})
+
+	suite.Run("Memory", func() {
+		dir := suite.T().TempDir()
+		ctx, err := suite.SetupTypes(api.Multipart(api.MultipartOptions{
+			SpoolDir: dir,
+		}))
+		suite.Nil(err)
+		defer ctx.End(nil)
+		handler := miruken.BuildUp(ctx, api.Polymorphic)
+
+		read, _, _, err := maps.Out[api.Message](handler, envelope(handler, "Pickford"), from)
+		suite.Nil(err)
+		suite.Equal(0, spooled(dir))
+		roster := read.Payload.(api.PartContainer).Parts()["roster"][0]
+		body, ok := roster.Body().(io.ReadCloser)
+		suite.True(ok)
+		data, err := io.ReadAll(body)
+		suite.Nil(err)
+		suite.Equal("Pickford", string(data))
+	})
+
+	suite.Run("PartTooLarge", func() {
+		dir := suite.T().TempDir()
+		ctx, err := suite.SetupTypes(api.Multipart(api.MultipartOptions{
+			SpoolThreshold: 16,
+			SpoolDir:       dir,
+			MaxPartSize:    100,
+		}))
+		suite.Nil(err)
+		defer ctx.End(nil)
+		handler := miruken.BuildUp(ctx, api.Polymorphic)
+
+		content := strings.Repeat("Pickford,Tarkowski,", 8)
+		_, _, _, err = maps.Out[api.Message](handler, envelope(handler, content), from)
+		var tooLarge *api.ContentTooLargeError
+		suite.ErrorAs(err, &tooLarge)
+		suite.Equal("roster", tooLarge.Part)
+		suite.Equal(int64(100), tooLarge.Limit)
+		suite.Equal(0, spooled(dir))
+	})
+
+	suite.Run("TooLarge", func() {
+		ctx, err := suite.SetupTypes(api.Multipart(api.MultipartOptions{
+			MaxSize: 64,
+		}))
+		suite.Nil(err)
+		defer ctx.End(nil)
+		handler := miruken.BuildUp(ctx, api.Polymorphic)
+
+		content := strings.Repeat("Pickford,Tarkowski,", 4)
+		_, _, _, err = maps.Out[api.Message](handler, envelope(handler, content), from)
+		var tooLarge *api.ContentTooLargeError
+		suite.ErrorAs(err, &tooLarge)
+		suite.Equal("", tooLarge.Part)
+		suite.Equal(int64(64), tooLarge.Limit)
+	})
+}
@@ -0,0 +1,52 @@
+package test
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	json2 "github.com/miruken-go/miruken/api/json"
+	"github.com/miruken-go/miruken/validates"
+	"github.com/stretchr/testify/suite"
+)
+
+type SurrogateTestSuite struct {
+	suite.Suite
+}
+
+func (suite *SurrogateTestSuite) TestSurrogate() {
+	suite.Run("Outcome", func() {
+		suite.Run("Original", func() {
+			var sur json2.Outcome
+			j := `[{"PropertyName":"Name","Errors":["\"Name\" is required"]}]`
+			suite.Require().Nil(json.Unmarshal([]byte(j), &sur))
+			original, err := sur.Original(nil)
+			suite.Nil(err)
+			outcome := original.(*validates.Outcome)
+			suite.Equal([]error{errors.New(`"Name" is required`)}, outcome.FieldErrors("Name"))
+		})
+
+		suite.Run("Nested", func() {
+			var sur json2.Outcome
+			j := `[{"PropertyName":"Address","Errors":["\"Address\" is invalid"],"Nested":[` +
+				`{"PropertyName":"City","Errors":["\"City\" is required"]},` +
+				`{"PropertyName":"Country","Nested":[{"PropertyName":"Code","Errors":["\"Code\" is required"]}]}]}]`
+			suite.Require().Nil(json.Unmarshal([]byte(j), &sur))
+			var original any
+			suite.NotPanics(func() {
+				var err error
+				original, err = sur.Original(nil)
+				suite.Nil(err)
+			})
+			outcome, ok := original.(*validates.Outcome)
+			suite.Require().True(ok)
+			suite.Equal([]error{errors.New(`"City" is required`)}, outcome.FieldErrors("Address.City"))
+			suite.Equal(`Address: "Address" is invalid, (City: "City" is required; Country: (Code: "Code" is required))`,
+				outcome.Error())
+		})
+	})
+}
+
+func TestSurrogateTestSuite(t *testing.T) {
+	suite.Run(t, new(SurrogateTestSuite))
+}
@@ -0,0 +1,167 @@
+package test
+
+import (
+	"reflect"
+	"strings"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/api/json/stdjson"
+	"github.com/miruken-go/miruken/context"
+	"github.com/miruken-go/miruken/handles"
+	"github.com/miruken-go/miruken/maps"
+	"github.com/miruken-go/miruken/setup"
+)
+
+type (
+	ShipOrder struct {
+		_       struct{} `typeId:"orders.ShipOrder"`
+		OrderId string
+	}
+
+	CancelOrder struct {
+		OrderId string
+		Reason  string
+	}
+
+	OrderShipped struct {
+		OrderId  string
+		Tracking string
+	}
+
+	RefundOrder struct {
+		OrderId string
+	}
+
+	OrderHandler struct{}
+
+	DuplicateOrderHandler struct{}
+
+	DuplicateShipOrder struct {
+		_ struct{} `typeId:"orders.ShipOrder"`
+	}
+)
+
+func (CancelOrder) TypeId() string { return "orders.CancelOrder" }
+
+func (OrderShipped) TypeId() string { return "orders.OrderShipped" }
+
+// OrderHandler
+
+func (h *OrderHandler) Ship(
+	_ *handles.It, ship ShipOrder,
+) OrderShipped {
+	return OrderShipped{OrderId: ship.OrderId, Tracking: "1Z999"}
+}
+
+func (h *OrderHandler) Cancel(
+	_ *handles.It, cancel CancelOrder,
+) {
+}
+
+// DuplicateOrderHandler
+
+func (h *DuplicateOrderHandler) Ship(
+	_ *handles.It, ship DuplicateShipOrder,
+) {
+}
+
+func (suite *StdJsonTestSuite) SetupTypes(
+	config ...func(*api.Installer),
+) (*context.Context, error) {
+	return setup.New(
+		TestFeature,
+		api.Feature(config...),
+		stdjson.Feature()).
+		Specs(&api.GoPolymorphism{}).
+		Context()
+}
+
+func (suite *StdJsonTestSuite) TestTypeId() {
+	suite.Run("Declared", func() {
+		id, ok := api.DeclaredTypeId(reflect.TypeOf(ShipOrder{}))
+		suite.True(ok)
+		suite.Equal("orders.ShipOrder", id)
+		id, ok = api.DeclaredTypeId(reflect.TypeOf(&CancelOrder{}))
+		suite.True(ok)
+		suite.Equal("orders.CancelOrder", id)
+		_, ok = api.DeclaredTypeId(reflect.TypeOf(TeamData{}))
+		suite.False(ok)
+	})
+
+	suite.Run("TypeInfo", func() {
+		handler, err := suite.SetupTypes(
+			api.TypeIds(map[string]any{"orders.RefundOrder": RefundOrder{}}))
+		suite.Nil(err)
+		for _, tc := range []struct {
+			value  any
+			typeId string
+		}{
+			{&ShipOrder{}, "orders.ShipOrder"},
+			{CancelOrder{}, "orders.CancelOrder"},
+			{&RefundOrder{}, "orders.RefundOrder"},
+			{[]ShipOrder{}, "[]orders.ShipOrder"},
+			{&TeamData{}, "test.TeamData"},
+		} {
+			info, _, _, err := maps.Out[api.TypeFieldInfo](handler, tc.value, api.ToTypeInfo)
+			suite.Nil(err)
+			suite.Equal(tc.typeId, info.TypeValue)
+		}
+	})
+
+	suite.Run("Discovered", func() {
+		handler, err := suite.SetupTypes()
+		suite.Nil(err)
+		registry, _, ok, err := miruken.Resolve[*api.TypeRegistry](handler)
+		suite.True(ok)
+		suite.Nil(err)
+		for id, typ := range map[string]any{
+			"orders.ShipOrder":    ShipOrder{},
+			"orders.CancelOrder":  CancelOrder{},
+			"orders.OrderShipped": OrderShipped{},
+		} {
+			registered, ok := registry.Type(id)
+			suite.True(ok)
+			suite.Equal(reflect.TypeOf(typ), registered)
+		}
+		_, ok = registry.Type("orders.RefundOrder")
+		suite.False(ok)
+	})
+
+	suite.Run("RoundTrip", func() {
+		ctx, err := suite.SetupTypes(api.Types(CancelOrder{}))
+		suite.Nil(err)
+		handler := miruken.BuildUp(ctx, api.Polymorphic)
+		b, _, _, err := maps.Out[[]byte](handler,
+			&CancelOrder{OrderId: "123", Reason: "late"}, api.ToJson)
+		suite.Nil(err)
+		suite.Equal("{\"@type\":\"orders.CancelOrder\",\"OrderId\":\"123\",\"Reason\":\"late\"}", string(b))
+		late, _, _, err := maps.Out[api.Late](handler, strings.NewReader(string(b)), api.FromJson)
+		suite.Nil(err)
+		suite.Equal(&CancelOrder{OrderId: "123", Reason: "late"}, late.Value)
+	})
+
+	suite.Run("Duplicate", func() {
+		_, err := setup.New(
+			TestFeature,
+			stdjson.Feature()).
+			Specs(&api.GoPolymorphism{}, &DuplicateOrderHandler{}).
+			Context()
+		var dup *api.DuplicateTypeIdError
+		suite.ErrorAs(err, &dup)
+		suite.Equal("orders.ShipOrder", dup.TypeId)
+	})
+
+	suite.Run("DuplicateRegistration", func() {
+		_, err := suite.SetupTypes(
+			api.TypeIds(map[string]any{"orders.ShipOrder": RefundOrder{}}),
+			api.Types(ShipOrder{}))
+		var dup *api.DuplicateTypeIdError
+		suite.ErrorAs(err, &dup)
+	})
+
+	suite.Run("MissingTypeId", func() {
+		_, err := suite.SetupTypes(api.Types(TeamData{}))
+		suite.ErrorIs(err, api.ErrMissingTypeId)
+	})
+}
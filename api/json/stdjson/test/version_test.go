@@ -0,0 +1,213 @@
+package test
+
+import (
+	"strings"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/creates"
+	"github.com/miruken-go/miruken/maps"
+)
+
+type (
+	PlaceOrderV1 struct {
+		Item string
+	}
+
+	PlaceOrderV2 struct {
+		Item     string
+		Quantity int
+	}
+
+	PlaceOrder struct {
+		Item     string
+		Quantity int
+		Priority string
+	}
+
+	VersionMapper struct{}
+
+	// CountingHandler counts the callbacks it handles.
+	CountingHandler struct {
+		miruken.Handler
+		count int
+	}
+)
+
+func (PlaceOrderV1) TypeVersion() int { return 1 }
+func (PlaceOrderV2) TypeVersion() int { return 2 }
+func (PlaceOrder) TypeVersion() int   { return 3 }
+
+// VersionMapper
+
+func (m *VersionMapper) New(
+	_ *struct {
+		_ creates.It `key:"test.PlaceOrder"`
+		_ creates.It `key:"test.PlaceOrder@v2"`
+		_ creates.It `key:"test.PlaceOrder@v3"`
+	  }, create *creates.It,
+) any {
+	switch create.Key() {
+	case "test.PlaceOrder":
+		return new(PlaceOrderV1)
+	case "test.PlaceOrder@v2":
+		return new(PlaceOrderV2)
+	case "test.PlaceOrder@v3":
+		return new(PlaceOrder)
+	}
+	return nil
+}
+
+func (m *VersionMapper) UpcastV1(
+	_ *struct {
+		maps.It
+		maps.Format `to:"api:upcast"`
+	  }, v1 *PlaceOrderV1,
+) *PlaceOrderV2 {
+	return &PlaceOrderV2{Item: v1.Item, Quantity: 1}
+}
+
+func (m *VersionMapper) UpcastV2(
+	_ *struct {
+		maps.It
+		maps.Format `to:"api:upcast"`
+	  }, v2 *PlaceOrderV2,
+) *PlaceOrder {
+	return &PlaceOrder{Item: v2.Item, Quantity: v2.Quantity, Priority: "normal"}
+}
+
+func (m *VersionMapper) DowncastV3(
+	_ *struct {
+		maps.It
+		maps.Format `to:"api:downcast"`
+	  }, order *PlaceOrder,
+) *PlaceOrderV2 {
+	return &PlaceOrderV2{Item: order.Item, Quantity: order.Quantity}
+}
+
+func (m *VersionMapper) DowncastV2(
+	_ *struct {
+		maps.It
+		maps.Format `to:"api:downcast"`
+	  }, v2 *PlaceOrderV2,
+) *PlaceOrderV1 {
+	return &PlaceOrderV1{Item: v2.Item}
+}
+
+// CountingHandler
+
+func (h *CountingHandler) Handle(
+	callback any,
+	greedy   bool,
+	composer miruken.Handler,
+) miruken.HandleResult {
+	h.count++
+	return h.Handler.Handle(callback, greedy, composer)
+}
+
+func (suite *StdJsonTestSuite) TestVersion() {
+	suite.Run("TypeId", func() {
+		handler := suite.Setup()
+		for _, tc := range []struct {
+			value  any
+			typeId string
+		}{
+			{&PlaceOrderV1{}, "test.PlaceOrder"},
+			{&PlaceOrderV2{}, "test.PlaceOrder@v2"},
+			{&PlaceOrder{}, "test.PlaceOrder@v3"},
+			{&TeamData{}, "test.TeamData"},
+		} {
+			info, _, _, err := maps.Out[api.TypeFieldInfo](handler, tc.value, api.ToTypeInfo)
+			suite.Nil(err)
+			suite.Equal(tc.typeId, info.TypeValue)
+		}
+	})
+
+	suite.Run("ParseTypeId", func() {
+		name, version := api.ParseTypeId("test.PlaceOrder@v3")
+		suite.Equal("test.PlaceOrder", name)
+		suite.Equal(3, version)
+		name, version = api.ParseTypeId("test.PlaceOrder")
+		suite.Equal("test.PlaceOrder", name)
+		suite.Equal(1, version)
+		name, version = api.ParseTypeId("test.PlaceOrder@vx")
+		suite.Equal("test.PlaceOrder@vx", name)
+		suite.Equal(1, version)
+		suite.Equal("test.PlaceOrder", api.VersionTypeId("test.PlaceOrder", 1))
+		suite.Equal("test.PlaceOrder@v2", api.VersionTypeId("test.PlaceOrder", 2))
+	})
+
+	suite.Run("Encode", func() {
+		handler := suite.Setup()
+		b, _, _, err := maps.Out[[]byte](
+			miruken.BuildUp(handler, api.Polymorphic),
+			&PlaceOrder{Item: "Pen", Quantity: 2, Priority: "high"}, api.ToJson)
+		suite.Nil(err)
+		suite.Equal("{\"@type\":\"test.PlaceOrder@v3\",\"Item\":\"Pen\",\"Quantity\":2,\"Priority\":\"high\"}", string(b))
+	})
+
+	suite.Run("Upcast", func() {
+		handler := suite.Setup()
+		for _, j := range []string{
+			"{\"@type\":\"test.PlaceOrder\",\"Item\":\"Pen\"}",
+			"{\"@type\":\"test.PlaceOrder@v2\",\"Item\":\"Pen\",\"Quantity\":1}",
+			"{\"@type\":\"test.PlaceOrder@v3\",\"Item\":\"Pen\",\"Quantity\":1,\"Priority\":\"normal\"}",
+		} {
+			late, _, _, err := maps.Out[api.Late](
+				miruken.BuildUp(handler, api.Polymorphic),
+				strings.NewReader(j), api.FromJson)
+			suite.Nil(err)
+			suite.Equal(&PlaceOrder{Item: "Pen", Quantity: 1, Priority: "normal"}, late.Value)
+		}
+	})
+
+	suite.Run("UpcastOnlyVersioned", func() {
+		handler := &CountingHandler{Handler: suite.Setup()}
+		var v any
+		err := api.AssignDecoded(handler, "test.TeamData", &TeamData{Name: "Arsenal"}, &v)
+		suite.Nil(err)
+		suite.Equal(&TeamData{Name: "Arsenal"}, v)
+		suite.Equal(0, handler.count)
+
+		err = api.AssignDecoded(handler, "test.PlaceOrder", &PlaceOrderV1{Item: "Pen"}, &v)
+		suite.Nil(err)
+		suite.Equal(&PlaceOrder{Item: "Pen", Quantity: 1, Priority: "normal"}, v)
+		suite.Positive(handler.count)
+	})
+
+	suite.Run("UpcastMessage", func() {
+		handler := suite.Setup()
+		j := "{\"payload\":{\"@type\":\"test.PlaceOrder\",\"Item\":\"Pen\"}}"
+		msg, _, _, err := maps.Out[api.Message](
+			miruken.BuildUp(handler, api.Polymorphic),
+			strings.NewReader(j), api.FromJson)
+		suite.Nil(err)
+		suite.Equal(&PlaceOrder{Item: "Pen", Quantity: 1, Priority: "normal"}, msg.Payload)
+	})
+
+	suite.Run("Downcast", func() {
+		handler := suite.Setup()
+		order := &PlaceOrder{Item: "Pen", Quantity: 2, Priority: "high"}
+		for version, expected := range map[int]string{
+			1: "{\"@type\":\"test.PlaceOrder\",\"Item\":\"Pen\"}",
+			2: "{\"@type\":\"test.PlaceOrder@v2\",\"Item\":\"Pen\",\"Quantity\":2}",
+			3: "{\"@type\":\"test.PlaceOrder@v3\",\"Item\":\"Pen\",\"Quantity\":2,\"Priority\":\"high\"}",
+		} {
+			b, _, _, err := maps.Out[[]byte](
+				miruken.BuildUp(handler,
+					api.Polymorphic,
+					api.Versions(map[string]int{"test.PlaceOrder": version})),
+				order, api.ToJson)
+			suite.Nil(err)
+			suite.Equal(expected, string(b))
+		}
+	})
+
+	suite.Run("DowncastMissing", func() {
+		handler := suite.Setup()
+		_, err := api.Downcast(handler, &TeamData{}, 1)
+		suite.Nil(err)
+		_, err = api.Downcast(handler, struct{ PlaceOrder }{}, 1)
+		suite.NotNil(err)
+	})
+}
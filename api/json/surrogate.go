@@ -13,6 +13,7 @@ func (m *SurrogateMapper) New(
 		_ creates.It `key:"json.Outcome"`
 		_ creates.It `key:"json.Error"`
 		_ creates.It `key:"json.Concurrent"`
+		_ creates.It `key:"json.ConcurrentOptions"`
 		_ creates.It `key:"json.Sequential"`
 	  }, create *creates.It,
 ) any {
@@ -23,6 +24,8 @@ func (m *SurrogateMapper) New(
 		return new(Error)
 	case "json.Concurrent":
 		return new(Concurrent)
+	case "json.ConcurrentOptions":
+		return new(ConcurrentOptions)
 	case "json.Sequential":
 		return new(Sequential)
 	}
@@ -0,0 +1,199 @@
+package mem
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/args"
+	"github.com/miruken-go/miruken/internal"
+	"github.com/miruken-go/miruken/maps"
+	"github.com/miruken-go/miruken/promise"
+	"github.com/miruken-go/miruken/provides"
+)
+
+type (
+	// Options customize the in-memory transport.
+	// Defaults apply to every queue not explicitly
+	// configured in Queues.
+	Options struct {
+		Format   string
+		Defaults QueueOptions
+		Queues   map[string]QueueOptions
+	}
+
+	// Broker is a setup.Bootstrap that owns the queues of
+	// the in-memory transport and runs their consumers.
+	Broker struct {
+		options Options
+		logger  logr.Logger
+		handler miruken.Handler
+		queues  map[string]*Queue
+		started bool
+		closed  bool
+		lock    sync.Mutex
+	}
+
+	// poisonError reports a message that can never be consumed.
+	poisonError struct {
+		cause error
+	}
+)
+
+func (b *Broker) Constructor(
+	_ *struct {
+		provides.It
+		provides.Single
+	  },
+	_ *struct {
+		args.Optional
+		args.FromOptions
+	  }, options Options,
+	_ *struct{ args.Optional }, logger logr.Logger,
+) {
+	if logger == (logr.Logger{}) {
+		logger = logr.Discard()
+	}
+	options.Format = internal.DefaultValue(options.Format, defaultFormat)
+
+	b.options = options
+	b.logger  = logger
+	b.queues  = make(map[string]*Queue)
+}
+
+// Queue returns the named queue creating it if needed.
+func (b *Broker) Queue(name string) (*Queue, error) {
+	if name == "" {
+		return nil, ErrMissingQueue
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if q, ok := b.queues[name]; ok {
+		return q, nil
+	}
+	if b.closed {
+		return nil, ErrQueueClosed
+	}
+	options, ok := b.options.Queues[name]
+	if !ok {
+		options = b.options.Defaults
+	}
+	options.Capacity      = internal.DefaultValue(options.Capacity, defaultCapacity)
+	options.Consumers     = internal.DefaultValue(options.Consumers, defaultConsumers)
+	options.MaxDeliveries = internal.DefaultValue(options.MaxDeliveries, defaultMaxDeliveries)
+
+	q := newQueue(name, options, b)
+	b.queues[name] = q
+	if b.started {
+		q.start()
+	}
+	return q, nil
+}
+
+func (b *Broker) Startup(
+	ctx context.Context,
+	h   miruken.Handler,
+) *promise.Promise[struct{}] {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.handler = h
+	b.started = true
+	for _, q := range b.queues {
+		q.start()
+	}
+	return promise.Empty()
+}
+
+func (b *Broker) Shutdown(
+	ctx context.Context,
+) *promise.Promise[struct{}] {
+	b.lock.Lock()
+	b.closed = true
+	queues := make([]*Queue, 0, len(b.queues))
+	for _, q := range b.queues {
+		queues = append(queues, q)
+	}
+	b.lock.Unlock()
+	for _, q := range queues {
+		q.close()
+	}
+	return promise.New(ctx, func(
+		resolve func(struct{}), reject func(error), onCancel func(func()),
+	) {
+		done := make(chan struct{})
+		go func() {
+			for _, q := range queues {
+				q.consumers.Wait()
+			}
+			close(done)
+		}()
+		select {
+		case <-done:
+			resolve(struct{}{})
+		case <-ctx.Done():
+			reject(ctx.Err())
+		}
+	})
+}
+
+// dispatch decodes and delivers a message to its consumer.
+func (b *Broker) dispatch(item *delivery) (res any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("mem: panic consuming message %q: %v", item.id, r)
+		}
+	}()
+	handler := b.handler
+	from, err := api.ParseMediaType(item.contentType, maps.DirectionFrom)
+	if err != nil {
+		return nil, &poisonError{err}
+	}
+	composer := miruken.BuildUp(handler, api.Polymorphic)
+	msg, _, _, err := maps.Out[api.Message](composer, bytes.NewReader(item.body), from)
+	if err != nil {
+		return nil, &poisonError{err}
+	}
+	if item.publish {
+		var pv *promise.Promise[any]
+		if pv, err = api.Publish(handler, msg); err == nil && pv != nil {
+			_, err = pv.Await()
+		}
+		return nil, err
+	}
+	var pr *promise.Promise[any]
+	if res, pr, err = api.Send[any](handler, msg); err == nil && pr != nil {
+		res, err = pr.Await()
+	}
+	if err != nil || internal.IsNil(res) {
+		return nil, err
+	}
+	// round-trip the response as a remote consumer would
+	to, err := api.ParseMediaType(item.contentType, maps.DirectionTo)
+	if err != nil {
+		return nil, err
+	}
+	var out bytes.Buffer
+	w := io.Writer(&out)
+	if _, _, err = maps.Into(composer, api.Message{Payload: res}, &w, to); err != nil {
+		return nil, err
+	}
+	if msg, _, _, err = maps.Out[api.Message](composer, &out, from); err != nil {
+		return nil, err
+	}
+	return msg.Payload, nil
+}
+
+// poisonError
+
+func (e *poisonError) Error() string {
+	return fmt.Sprintf("mem: poison message: %v", e.cause)
+}
+
+func (e *poisonError) Unwrap() error {
+	return e.cause
+}
@@ -0,0 +1,33 @@
+package mem
+
+import (
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/setup"
+)
+
+// Installer configures the in-memory queue transport.
+type Installer struct{}
+
+func (i *Installer) DependsOn() []setup.Feature {
+	return []setup.Feature{api.Feature()}
+}
+
+func (i *Installer) Install(b *setup.Builder) error {
+	if b.Tag(&featureTag) {
+		b.Specs(&Router{}, &Broker{})
+	}
+	return nil
+}
+
+// Feature configures the in-memory queue transport.
+func Feature(config ...func(*Installer)) setup.Feature {
+	installer := &Installer{}
+	for _, configure := range config {
+		if configure != nil {
+			configure(installer)
+		}
+	}
+	return installer
+}
+
+var featureTag byte
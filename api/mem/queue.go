@@ -0,0 +1,301 @@
+package mem
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/miruken-go/miruken/promise"
+)
+
+type (
+	// QueueOptions customize a single queue.
+	QueueOptions struct {
+		Capacity        int
+		Consumers       int
+		MaxDeliveries   int
+		RedeliveryDelay time.Duration
+	}
+
+	// Queue is a bounded in-memory queue drained by a
+	// pool of competing consumers.  A message is acknowledged
+	// when its consumer succeeds and redelivered when it fails.
+	// Messages that cannot be delivered within MaxDeliveries
+	// are moved to the dead letters of the queue.
+	Queue struct {
+		name      string
+		options   QueueOptions
+		broker    *Broker
+		items     []*delivery
+		dead      []DeadLetter
+		scheduled int
+		started   bool
+		closed    bool
+		lock      sync.Mutex
+		ready     *sync.Cond
+		space     *sync.Cond
+		consumers sync.WaitGroup
+	}
+
+	// DeadLetter is a message that could not be delivered.
+	DeadLetter struct {
+		Id          string
+		Queue       string
+		ContentType string
+		Body        []byte
+		Publish     bool
+		Attempts    int
+		LastError   string
+		Died        time.Time
+	}
+
+	// DeadLetterError reports a message moved to the dead letters.
+	DeadLetterError struct {
+		Queue    string
+		Id       string
+		Attempts int
+		Cause    error
+	}
+
+	// delivery is an encoded message awaiting a consumer.
+	delivery struct {
+		id          string
+		contentType string
+		body        []byte
+		publish     bool
+		attempts    int
+		deferred    *promise.Deferred[any]
+	}
+)
+
+var (
+	ErrQueueClosed  = errors.New("mem: queue is closed")
+	ErrMissingQueue = errors.New("mem: route is missing the queue name")
+)
+
+const (
+	defaultCapacity      = 1000
+	defaultConsumers     = 1
+	defaultMaxDeliveries = 3
+)
+
+// DeadLetterError
+
+func (e *DeadLetterError) Error() string {
+	return fmt.Sprintf("mem: message %q dead lettered on queue %q after %d attempt(s): %v",
+		e.Id, e.Queue, e.Attempts, e.Cause)
+}
+
+func (e *DeadLetterError) Unwrap() error {
+	return e.Cause
+}
+
+// Queue
+
+func (q *Queue) Name() string {
+	return q.name
+}
+
+func (q *Queue) Options() QueueOptions {
+	return q.options
+}
+
+// Len returns the number of messages waiting for a consumer.
+func (q *Queue) Len() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return len(q.items)
+}
+
+// DeadLetters returns the messages that could not be delivered.
+func (q *Queue) DeadLetters() []DeadLetter {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	return append([]DeadLetter(nil), q.dead...)
+}
+
+// Redrive moves all dead letters back onto the queue and
+// returns the number of messages moved.
+func (q *Queue) Redrive() int {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.closed {
+		return 0
+	}
+	count := len(q.dead)
+	for _, dead := range q.dead {
+		q.items = append(q.items, &delivery{
+			id:          dead.Id,
+			contentType: dead.ContentType,
+			body:        dead.Body,
+			publish:     dead.Publish,
+		})
+	}
+	q.dead = nil
+	q.ready.Broadcast()
+	return count
+}
+
+// enqueue adds a message to the queue waiting for space
+// if the queue is at capacity.
+func (q *Queue) enqueue(
+	contentType string,
+	body        []byte,
+	publish     bool,
+) *promise.Promise[any] {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for !q.closed && len(q.items) >= q.options.Capacity {
+		q.space.Wait()
+	}
+	if q.closed {
+		return promise.Reject[any](ErrQueueClosed)
+	}
+	deferred := promise.Defer[any]()
+	q.items = append(q.items, &delivery{
+		id:          uuid.NewString(),
+		contentType: contentType,
+		body:        body,
+		publish:     publish,
+		deferred:    &deferred,
+	})
+	q.ready.Signal()
+	return deferred.Promise()
+}
+
+// start launches the consumers of the queue.
+func (q *Queue) start() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.started || q.closed {
+		return
+	}
+	q.started = true
+	q.consumers.Add(q.options.Consumers)
+	for i := 0; i < q.options.Consumers; i++ {
+		go q.consume()
+	}
+}
+
+// close stops accepting messages and lets the consumers
+// drain the messages already queued.
+func (q *Queue) close() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.closed {
+		return
+	}
+	q.closed = true
+	if !q.started {
+		for _, item := range q.items {
+			item.reject(ErrQueueClosed)
+		}
+		q.items = nil
+	}
+	q.ready.Broadcast()
+	q.space.Broadcast()
+}
+
+func (q *Queue) consume() {
+	defer q.consumers.Done()
+	for {
+		item := q.receive()
+		if item == nil {
+			return
+		}
+		res, err := q.broker.dispatch(item)
+		if err == nil {
+			item.resolve(res)
+		} else {
+			q.fail(item, err)
+		}
+	}
+}
+
+// receive waits for the next message or returns nil
+// when the queue is closed and drained.
+func (q *Queue) receive() *delivery {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	for len(q.items) == 0 {
+		if q.closed && q.scheduled == 0 {
+			return nil
+		}
+		q.ready.Wait()
+	}
+	item := q.items[0]
+	q.items[0] = nil
+	q.items = q.items[1:]
+	item.attempts++
+	q.space.Signal()
+	return item
+}
+
+// fail redelivers a message or moves it to the dead letters
+// once the maximum number of deliveries is exhausted.
+func (q *Queue) fail(item *delivery, cause error) {
+	q.broker.logger.Error(cause, "unable to deliver message",
+		"queue", q.name, "id", item.id, "attempts", item.attempts)
+	if _, poison := cause.(*poisonError); poison || item.attempts >= q.options.MaxDeliveries {
+		q.bury(item, cause)
+		return
+	}
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if delay := q.options.RedeliveryDelay; delay > 0 {
+		q.scheduled++
+		time.AfterFunc(delay, func() {
+			q.lock.Lock()
+			defer q.lock.Unlock()
+			q.scheduled--
+			q.items = append(q.items, item)
+			q.ready.Broadcast()
+		})
+	} else {
+		q.items = append(q.items, item)
+		q.ready.Signal()
+	}
+}
+
+func (q *Queue) bury(item *delivery, cause error) {
+	q.lock.Lock()
+	q.dead = append(q.dead, DeadLetter{
+		Id:          item.id,
+		Queue:       q.name,
+		ContentType: item.contentType,
+		Body:        item.body,
+		Publish:     item.publish,
+		Attempts:    item.attempts,
+		LastError:   cause.Error(),
+		Died:        time.Now().UTC(),
+	})
+	q.lock.Unlock()
+	item.reject(&DeadLetterError{q.name, item.id, item.attempts, cause})
+}
+
+// delivery
+
+func (d *delivery) resolve(res any) {
+	if deferred := d.deferred; deferred != nil {
+		deferred.Resolve(res)
+	}
+}
+
+func (d *delivery) reject(err error) {
+	if deferred := d.deferred; deferred != nil {
+		deferred.Reject(err)
+	}
+}
+
+func newQueue(
+	name    string,
+	options QueueOptions,
+	broker  *Broker,
+) *Queue {
+	q := &Queue{name: name, options: options, broker: broker}
+	q.ready = sync.NewCond(&q.lock)
+	q.space = sync.NewCond(&q.lock)
+	return q
+}
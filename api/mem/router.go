@@ -0,0 +1,97 @@
+package mem
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/args"
+	"github.com/miruken-go/miruken/handles"
+	"github.com/miruken-go/miruken/maps"
+	"github.com/miruken-go/miruken/promise"
+)
+
+// Router routes messages over an in-memory queue transport.
+// Routes have the form mem://queue-name.
+type Router struct{}
+
+const defaultFormat = "application/json"
+
+func (r *Router) Route(
+	_ *struct {
+		handles.It
+		api.Routes `scheme:"mem"`
+	  }, routed api.Routed,
+	broker *Broker,
+	_ *struct {
+		args.Optional
+		args.FromOptions
+	  }, options Options,
+	ctx miruken.HandleContext,
+) *promise.Promise[any] {
+	return promise.New(nil, func(resolve func(any), reject func(error), onCancel func(func())) {
+		name, err := QueueName(routed.Route)
+		if err != nil {
+			reject(fmt.Errorf("mem router: %w", err))
+			return
+		}
+		q, err := broker.Queue(name)
+		if err != nil {
+			reject(fmt.Errorf("mem router: %w", err))
+			return
+		}
+
+		var format string
+		if format = options.Format; format == "" {
+			format = broker.options.Format
+		}
+		to, err := api.ParseMediaType(format, maps.DirectionTo)
+		if err != nil {
+			reject(fmt.Errorf("mem router: %w", err))
+			return
+		}
+
+		composer := miruken.BuildUp(ctx.Composer, api.Polymorphic)
+
+		headers, _ := api.CurrentHeaders(ctx.Composer)
+
+		var b bytes.Buffer
+		out := io.Writer(&b)
+		msg := api.Message{Payload: routed.Message, Headers: headers}
+		if _, _, err = maps.Into(composer, msg, &out, to); err != nil {
+			reject(fmt.Errorf("mem router: %w", err))
+			return
+		}
+
+		if res, err := q.enqueue(format, b.Bytes(), ctx.Greedy).Await(); err != nil {
+			reject(err)
+		} else {
+			resolve(res)
+		}
+	})
+}
+
+// QueueName extracts the queue name from a mem route.
+func QueueName(route string) (string, error) {
+	u, err := url.Parse(route)
+	if err != nil {
+		return "", err
+	}
+	name := u.Host + strings.TrimSuffix(u.Path, "/")
+	if name == "" {
+		name = u.Opaque
+	}
+	if name == "" {
+		return "", ErrMissingQueue
+	}
+	return name, nil
+}
+
+// Format returns a miruken.Builder requesting a specific format.
+func Format(format string) miruken.Builder {
+	return miruken.Options(Options{Format: format})
+}
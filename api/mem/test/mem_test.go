@@ -0,0 +1,457 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/api/json/stdjson"
+	"github.com/miruken-go/miruken/api/mem"
+	"github.com/miruken-go/miruken/context"
+	"github.com/miruken-go/miruken/creates"
+	"github.com/miruken-go/miruken/either"
+	"github.com/miruken-go/miruken/handles"
+	"github.com/miruken-go/miruken/promise"
+	"github.com/miruken-go/miruken/provides"
+	"github.com/miruken-go/miruken/setup"
+	"github.com/stretchr/testify/suite"
+)
+
+//go:generate $GOPATH/bin/miruken -tests
+
+type (
+	PlaceOrder struct {
+		Id   int
+		Item string
+	}
+
+	OrderConfirmation struct {
+		Id       int
+		Attempts int
+	}
+
+	OrderShipped struct {
+		Id int
+	}
+
+	DoWork struct {
+		Id int
+	}
+
+	WorkDone struct {
+		Id int
+	}
+
+	Unknown struct {
+		Name string
+	}
+
+	OrderService struct {
+		attempts map[int]int
+		failures map[int]int
+		lock     sync.Mutex
+	}
+
+	ShippingConsumer struct {
+		shipped []int
+		lock    sync.Mutex
+	}
+
+	WorkService struct {
+		active int32
+		peak   int32
+		done   int32
+		gate   chan struct{}
+	}
+)
+
+// OrderService
+
+func (s *OrderService) Constructor(
+	_ *struct {
+		provides.It
+		provides.Single
+	  },
+) {
+	s.attempts = make(map[int]int)
+	s.failures = make(map[int]int)
+}
+
+func (s *OrderService) Place(
+	_ *handles.It, place *PlaceOrder,
+) (OrderConfirmation, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.attempts[place.Id]++
+	attempts := s.attempts[place.Id]
+	if failures := s.failures[place.Id]; failures != 0 {
+		if failures > 0 {
+			s.failures[place.Id]--
+		}
+		return OrderConfirmation{}, fmt.Errorf("order %d unavailable", place.Id)
+	}
+	return OrderConfirmation{place.Id, attempts}, nil
+}
+
+func (s *OrderService) Shipped(
+	_ *handles.It, shipped *OrderShipped,
+) {
+}
+
+func (s *OrderService) New(
+	_ *struct {
+		_ creates.It `key:"test.PlaceOrder"`
+		_ creates.It `key:"test.OrderConfirmation"`
+		_ creates.It `key:"test.OrderShipped"`
+		_ creates.It `key:"test.DoWork"`
+		_ creates.It `key:"test.WorkDone"`
+	}, create *creates.It,
+) any {
+	switch create.Key() {
+	case "test.PlaceOrder":
+		return new(PlaceOrder)
+	case "test.OrderConfirmation":
+		return new(OrderConfirmation)
+	case "test.OrderShipped":
+		return new(OrderShipped)
+	case "test.DoWork":
+		return new(DoWork)
+	case "test.WorkDone":
+		return new(WorkDone)
+	}
+	return nil
+}
+
+// Fail makes the next deliveries of an order fail.
+// A negative count fails every delivery.
+func (s *OrderService) Fail(id, failures int) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.failures[id] = failures
+}
+
+// ShippingConsumer
+
+func (c *ShippingConsumer) Constructor(
+	_ *struct {
+		provides.It
+		provides.Single
+	  },
+) {
+}
+
+func (c *ShippingConsumer) Shipped(
+	_ *handles.It, shipped *OrderShipped,
+) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.shipped = append(c.shipped, shipped.Id)
+}
+
+func (c *ShippingConsumer) Shipments() []int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]int(nil), c.shipped...)
+}
+
+// WorkService
+
+func (w *WorkService) Constructor(
+	_ *struct {
+		provides.It
+		provides.Single
+	  },
+) {
+	w.gate = make(chan struct{})
+}
+
+func (w *WorkService) Work(
+	_ *handles.It, work *DoWork,
+) WorkDone {
+	active := atomic.AddInt32(&w.active, 1)
+	for {
+		peak := atomic.LoadInt32(&w.peak)
+		if active <= peak || atomic.CompareAndSwapInt32(&w.peak, peak, active) {
+			break
+		}
+	}
+	<-w.gate
+	atomic.AddInt32(&w.active, -1)
+	atomic.AddInt32(&w.done, 1)
+	return WorkDone{work.Id}
+}
+
+func (w *WorkService) Release() {
+	close(w.gate)
+}
+
+type MemTestSuite struct {
+	suite.Suite
+}
+
+func (suite *MemTestSuite) Setup(
+	options mem.Options,
+) (*context.Context, *mem.Broker) {
+	ctx, err := setup.New(
+		TestFeature,
+		stdjson.Feature(),
+		mem.Feature()).
+		Specs(&api.GoPolymorphism{}).
+		Options(options).
+		Context()
+	suite.Require().Nil(err)
+	broker, _, ok, err := provides.Type[*mem.Broker](ctx)
+	suite.Require().True(ok)
+	suite.Require().Nil(err)
+	return ctx, broker
+}
+
+func (suite *MemTestSuite) TestMem() {
+	suite.Run("Send", func() {
+		ctx, _ := suite.Setup(mem.Options{})
+		defer ctx.End(nil)
+
+		place := api.RouteTo(PlaceOrder{1, "Book"}, "mem://orders")
+		_, pc, err := api.Send[*OrderConfirmation](ctx, place)
+		suite.Nil(err)
+		suite.NotNil(pc)
+		confirmation, err := pc.Await()
+		suite.Nil(err)
+		suite.Equal(OrderConfirmation{1, 1}, *confirmation)
+	})
+
+	suite.Run("Publish", func() {
+		ctx, _ := suite.Setup(mem.Options{})
+		defer ctx.End(nil)
+
+		pv, err := api.Publish(ctx, api.RouteTo(OrderShipped{7}, "mem://events"))
+		suite.Nil(err)
+		_, err = pv.Await()
+		suite.Nil(err)
+		consumer, _, _, _ := provides.Type[*ShippingConsumer](ctx)
+		suite.Equal([]int{7}, consumer.Shipments())
+	})
+
+	suite.Run("Batch", func() {
+		ctx, _ := suite.Setup(mem.Options{})
+		defer ctx.End(nil)
+
+		var confirmations []*promise.Promise[*OrderConfirmation]
+		results, err := miruken.BatchAsync(ctx,
+			func(batch miruken.Handler) *promise.Promise[[]*OrderConfirmation] {
+				for i := 1; i <= 3; i++ {
+					_, pc, err := api.Send[*OrderConfirmation](batch,
+						api.RouteTo(PlaceOrder{Id: i}, "mem://orders"))
+					suite.Nil(err)
+					confirmations = append(confirmations, pc)
+				}
+				return promise.All(nil, confirmations...)
+			}).Await()
+		suite.Nil(err)
+		suite.Len(results, 1)
+		replies := results[0].([]any)
+		suite.Len(replies, 1)
+		reply, ok := replies[0].(api.RouteReply)
+		suite.True(ok)
+		suite.Equal("mem://orders", reply.Uri)
+		suite.Equal([]any{
+			&OrderConfirmation{1, 1},
+			&OrderConfirmation{2, 1},
+			&OrderConfirmation{3, 1},
+		}, reply.Responses)
+		for i, pc := range confirmations {
+			confirmation, err := pc.Await()
+			suite.Nil(err)
+			suite.Equal(i+1, confirmation.Id)
+		}
+	})
+
+	suite.Run("Concurrent", func() {
+		ctx, _ := suite.Setup(mem.Options{})
+		defer ctx.End(nil)
+
+		r, pr, err := api.Send[api.ScheduledResult](ctx,
+			api.RouteTo(api.ConcurrentBatch{
+				Requests: []any{PlaceOrder{Id: 4}},
+			}, "mem://orders"))
+		suite.Nil(err)
+		suite.NotNil(pr)
+		r, err = pr.Await()
+		suite.Nil(err)
+		suite.Len(r.Responses, 1)
+		either.Match(r.Responses[0], func(err error) {
+			suite.Fail("unexpected error", err)
+		}, func(res any) {
+			suite.Equal(4, res.(*OrderConfirmation).Id)
+		})
+	})
+
+	suite.Run("Competing Consumers", func() {
+		ctx, broker := suite.Setup(mem.Options{
+			Queues: map[string]mem.QueueOptions{
+				"work": {Consumers: 4},
+			},
+		})
+		defer ctx.End(nil)
+		work, _, _, _ := provides.Type[*WorkService](ctx)
+
+		var pending []*promise.Promise[*WorkDone]
+		for i := 0; i < 8; i++ {
+			_, pw, err := api.Send[*WorkDone](ctx, api.RouteTo(DoWork{i}, "mem://work"))
+			suite.Nil(err)
+			pending = append(pending, pw)
+		}
+		suite.Eventually(func() bool {
+			return atomic.LoadInt32(&work.active) == 4
+		}, time.Second, time.Millisecond)
+		q, err := broker.Queue("work")
+		suite.Nil(err)
+		suite.Equal(4, q.Len())
+		work.Release()
+
+		_, err = promise.All(nil, pending...).Await()
+		suite.Nil(err)
+		suite.Equal(int32(4), atomic.LoadInt32(&work.peak))
+		suite.Equal(int32(8), atomic.LoadInt32(&work.done))
+	})
+
+	suite.Run("Bounded", func() {
+		ctx, broker := suite.Setup(mem.Options{
+			Queues: map[string]mem.QueueOptions{
+				"work": {Capacity: 2},
+			},
+		})
+		defer ctx.End(nil)
+		work, _, _, _ := provides.Type[*WorkService](ctx)
+
+		var pending []*promise.Promise[*WorkDone]
+		for i := 0; i < 5; i++ {
+			_, pw, err := api.Send[*WorkDone](ctx, api.RouteTo(DoWork{i}, "mem://work"))
+			suite.Nil(err)
+			pending = append(pending, pw)
+		}
+		q, err := broker.Queue("work")
+		suite.Nil(err)
+		suite.Eventually(func() bool {
+			return atomic.LoadInt32(&work.active) == 1 && q.Len() == 2
+		}, time.Second, time.Millisecond)
+		suite.Never(func() bool {
+			return q.Len() > 2
+		}, 20*time.Millisecond, time.Millisecond)
+		work.Release()
+
+		_, err = promise.All(nil, pending...).Await()
+		suite.Nil(err)
+		suite.Equal(int32(1), atomic.LoadInt32(&work.peak))
+		suite.Equal(int32(5), atomic.LoadInt32(&work.done))
+	})
+
+	suite.Run("Redelivery", func() {
+		ctx, _ := suite.Setup(mem.Options{
+			Defaults: mem.QueueOptions{RedeliveryDelay: time.Millisecond},
+		})
+		defer ctx.End(nil)
+		orders, _, _, _ := provides.Type[*OrderService](ctx)
+		orders.Fail(2, 2)
+
+		_, pc, err := api.Send[*OrderConfirmation](ctx,
+			api.RouteTo(PlaceOrder{2, "Pen"}, "mem://orders"))
+		suite.Nil(err)
+		confirmation, err := pc.Await()
+		suite.Nil(err)
+		suite.Equal(OrderConfirmation{2, 3}, *confirmation)
+	})
+
+	suite.Run("Dead Letter", func() {
+		ctx, broker := suite.Setup(mem.Options{})
+		defer ctx.End(nil)
+		orders, _, _, _ := provides.Type[*OrderService](ctx)
+		orders.Fail(3, -1)
+
+		_, pc, err := api.Send[*OrderConfirmation](ctx,
+			api.RouteTo(PlaceOrder{3, "Cup"}, "mem://orders"))
+		suite.Nil(err)
+		_, err = pc.Await()
+		var dead *mem.DeadLetterError
+		suite.ErrorAs(err, &dead)
+		suite.Equal("orders", dead.Queue)
+		suite.Equal(3, dead.Attempts)
+		suite.ErrorContains(err, "order 3 unavailable")
+
+		q, err := broker.Queue("orders")
+		suite.Nil(err)
+		letters := q.DeadLetters()
+		suite.Len(letters, 1)
+		suite.Equal(dead.Id, letters[0].Id)
+		suite.Equal("orders", letters[0].Queue)
+		suite.Equal(3, letters[0].Attempts)
+		suite.Equal("order 3 unavailable", letters[0].LastError)
+
+		orders.Fail(3, 0)
+		suite.Equal(1, q.Redrive())
+		suite.Eventually(func() bool {
+			return q.Len() == 0 && len(q.DeadLetters()) == 0
+		}, time.Second, time.Millisecond)
+		suite.Eventually(func() bool {
+			orders.lock.Lock()
+			defer orders.lock.Unlock()
+			return orders.attempts[3] == 4
+		}, time.Second, time.Millisecond)
+	})
+
+	suite.Run("Poison", func() {
+		ctx, broker := suite.Setup(mem.Options{})
+		defer ctx.End(nil)
+
+		_, pu, err := api.Send[any](ctx, api.RouteTo(Unknown{"?"}, "mem://orders"))
+		suite.Nil(err)
+		_, err = pu.Await()
+		var dead *mem.DeadLetterError
+		suite.ErrorAs(err, &dead)
+		suite.Equal(1, dead.Attempts)
+		q, _ := broker.Queue("orders")
+		suite.Len(q.DeadLetters(), 1)
+	})
+
+	suite.Run("Missing Queue", func() {
+		ctx, _ := suite.Setup(mem.Options{})
+		defer ctx.End(nil)
+
+		_, pc, err := api.Send[any](ctx, api.RouteTo(PlaceOrder{}, "mem://"))
+		suite.Nil(err)
+		_, err = pc.Await()
+		suite.ErrorIs(err, mem.ErrMissingQueue)
+	})
+
+	suite.Run("Shutdown", func() {
+		ctx, broker := suite.Setup(mem.Options{})
+		work, _, _, _ := provides.Type[*WorkService](ctx)
+
+		_, pw, err := api.Send[*WorkDone](ctx, api.RouteTo(DoWork{9}, "mem://work"))
+		suite.Nil(err)
+		suite.Eventually(func() bool {
+			return atomic.LoadInt32(&work.active) == 1
+		}, time.Second, time.Millisecond)
+
+		go func() {
+			time.Sleep(10 * time.Millisecond)
+			work.Release()
+		}()
+		ctx.End(nil)
+		done, err := pw.Await()
+		suite.Nil(err)
+		suite.Equal(9, done.Id)
+
+		_, err = broker.Queue("other")
+		suite.True(errors.Is(err, mem.ErrQueueClosed))
+	})
+}
+
+func TestMemTestSuite(t *testing.T) {
+	suite.Run(t, new(MemTestSuite))
+}
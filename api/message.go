@@ -14,6 +14,7 @@ type (
 	// Message is an envelope for polymorphic payloads.
 	Message struct {
 		Payload any
+		Headers Headers
 	}
 
 	// Surrogate replaces a value with another for api transmission.
@@ -26,6 +27,7 @@ type (
 		Polymorphism   miruken.Option[Polymorphism]
 		TypeInfoFormat string
 		TypeFieldValue string
+		Versions       map[string]int
 	}
 
 	// MalformedErrorError reports an invalid error payload.
@@ -40,6 +42,7 @@ func (e *MalformedErrorError) Error() string {
 
 // Post sends a message without an expected response.
 // A new Stash is created to manage any transit state.
+// A Message is unwrapped and its Headers applied.
 // Returns an empty promise if the call is asynchronous.
 func Post(
 	handler miruken.Handler,
@@ -51,7 +54,7 @@ func Post(
 	if internal.IsNil(message) {
 		panic("message cannot be nil")
 	}
-	stash := miruken.AddHandlers(handler, NewStash(false))
+	stash, message := envelope(handler, message)
 	defer func() {
 		if r := recover(); r != nil {
 			if e, ok := r.(error); ok {
@@ -66,6 +69,7 @@ func Post(
 
 // Send sends a request with an expected response.
 // A new Stash is created to manage any transit state.
+// A Message is unwrapped and its Headers applied.
 // Returns the TResponse if the call is synchronous or
 // a promise of TResponse if the call is asynchronous.
 func Send[TResponse any](
@@ -78,7 +82,7 @@ func Send[TResponse any](
 	if internal.IsNil(request) {
 		panic("request cannot be nil")
 	}
-	stash := miruken.AddHandlers(handler, NewStash(false))
+	stash, request := envelope(handler, request)
 	defer func() {
 		if r := recover(); r != nil {
 			if e, ok := r.(error); ok {
@@ -93,6 +97,7 @@ func Send[TResponse any](
 
 // Publish sends a message to all recipients.
 // A new Stash is created to manage any transit state.
+// A Message is unwrapped and its Headers applied.
 // Returns an empty promise if the call is asynchronous.
 func Publish(
 	handler miruken.Handler,
@@ -104,7 +109,7 @@ func Publish(
 	if internal.IsNil(message) {
 		panic("message cannot be nil")
 	}
-	stash := miruken.AddHandlers(handler, NewStash(false))
+	stash, message := envelope(handler, message)
 	defer func() {
 		if r := recover(); r != nil {
 			if e, ok := r.(error); ok {
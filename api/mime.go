@@ -23,6 +23,36 @@ var (
 
 	// FromJson decodes json into a corresponding model
 	FromJson = maps.From("application/json", nil)
+
+	// ToNdjson encodes a MessageStream into newline delimited json
+	ToNdjson = maps.To("application/x-ndjson", nil)
+
+	// FromNdjson decodes newline delimited json into a MessageStream
+	FromNdjson = maps.From("application/x-ndjson", nil)
+
+	// ToMsgpack encodes a model into MessagePack format
+	ToMsgpack = maps.To("application/msgpack", nil)
+
+	// FromMsgpack decodes MessagePack into a corresponding model
+	FromMsgpack = maps.From("application/msgpack", nil)
+
+	// ToCbor encodes a model into CBOR format
+	ToCbor = maps.To("application/cbor", nil)
+
+	// FromCbor decodes CBOR into a corresponding model
+	FromCbor = maps.From("application/cbor", nil)
+
+	// ToXml encodes a model into xml format
+	ToXml = maps.To("application/xml", nil)
+
+	// FromXml decodes xml into a corresponding model
+	FromXml = maps.From("application/xml", nil)
+
+	// ToYaml encodes a model into yaml format
+	ToYaml = maps.To("application/yaml", nil)
+
+	// FromYaml decodes yaml into a corresponding model
+	FromYaml = maps.From("application/yaml", nil)
 )
 
 // ParseMediaType parses the mediaType into a maps.Format suitable
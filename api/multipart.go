@@ -2,29 +2,93 @@ package api
 
 import (
 	"bytes"
+	"context"
 	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
+	"os"
+	"sync"
 	"time"
 
 	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/args"
 	"github.com/miruken-go/miruken/constraint"
 	"github.com/miruken-go/miruken/maps"
 )
 
-// MultipartMapper reads and writes 'multipart/*'
-// mime messages from a PartContainer.
-type MultipartMapper struct{}
+type (
+	// MultipartMapper reads and writes 'multipart/*'
+	// mime messages from a PartContainer.
+	MultipartMapper struct {
+		options MultipartOptions
+	}
+
+	// MultipartOptions customize the reading of multipart messages.
+	// Parts larger than SpoolThreshold are spooled to temporary files
+	// in SpoolDir instead of memory.  MaxPartSize and MaxSize limit the
+	// size of each part and of all parts, and are unlimited if zero.
+	MultipartOptions struct {
+		SpoolThreshold int64
+		SpoolDir       string
+		MaxPartSize    int64
+		MaxSize        int64
+	}
+
+	// ContentTooLargeError reports content exceeding a size limit.
+	// Part is empty if the limit applies to all parts.
+	ContentTooLargeError struct {
+		Part  string
+		Limit int64
+	}
+
+	// partBody is the spooled body of a multipart Part.
+	// Bodies spooled to a temporary file are opened when first
+	// read and removed when the request context ends or, without
+	// one, when closed.
+	partBody struct {
+		data   *bytes.Reader
+		path   string
+		file   *os.File
+		remove bool
+		lock   sync.Mutex
+	}
+
+	// partLimiter fails reads exceeding the part or total size limits.
+	partLimiter struct {
+		reader   io.Reader
+		part     string
+		read     int64
+		maxPart  int64
+		total    *int64
+		maxTotal int64
+	}
+)
+
+// DefaultSpoolThreshold is the size above which parts are spooled.
+const DefaultSpoolThreshold = 1 << 20
+
+// MultipartMapper
+
+func (m *MultipartMapper) Constructor(
+	_ *struct{ args.Optional }, options *MultipartOptions,
+) {
+	if options != nil {
+		m.options = *options
+	}
+	if m.options.SpoolThreshold <= 0 {
+		m.options.SpoolThreshold = DefaultSpoolThreshold
+	}
+}
 
 func (m *MultipartMapper) Read(
 	_ *struct {
 		maps.Format `from:"/multipart//"`
 	  }, reader io.Reader,
 	it *maps.It,
+	_ *struct{ args.Optional }, reqCtx context.Context,
 	ctx miruken.HandleContext,
-) (Message, error) {
-	var msg Message
+) (msg Message, err error) {
 	_, boundary, start := extractMultipartParams(it)
 	if boundary == "" {
 		return msg, ErrMissingBoundary
@@ -32,10 +96,20 @@ func (m *MultipartMapper) Read(
 
 	var main Part
 	var rpb ReadPartsBuilder
+	var total int64
+	var spooled []*partBody
 	composer := ctx.Composer
 	now := time.Now().UnixNano()
 	mr := multipart.NewReader(reader, boundary)
 
+	defer func() {
+		if err != nil {
+			for _, body := range spooled {
+				body.discard()
+			}
+		}
+	}()
+
 	for i := 0; ; i++ {
 		p, err := mr.NextPart()
 		if err == io.EOF {
@@ -45,11 +119,6 @@ func (m *MultipartMapper) Read(
 			return msg, err
 		}
 
-		body, err := io.ReadAll(p)
-		if err != nil {
-			return msg, err
-		}
-
 		addPart := true
 		header := p.Header
 		ct := header.Get("Content-Type")
@@ -58,10 +127,6 @@ func (m *MultipartMapper) Read(
 		if filename == "" {
 			filename = header.Get("Content-Filename")
 		}
-		var pb PartBuilder
-		pb.MediaType(ct).
-			MetadataStrings(header).
-			Filename(filename)
 
 		var key string
 		if key = p.FormName(); key == "" {
@@ -79,12 +144,43 @@ func (m *MultipartMapper) Read(
 			}
 		}
 
+		if !addPart {
+			if msg.Headers, err = ReadHeaders(header); err != nil {
+				return msg, err
+			}
+			RemoveHeaders(header)
+		}
+
+		var pb PartBuilder
+		pb.MediaType(ct).
+			MetadataStrings(header).
+			Filename(filename)
+
+		limiter := &partLimiter{
+			reader:   p,
+			part:     key,
+			maxPart:  m.options.MaxPartSize,
+			total:    &total,
+			maxTotal: m.options.MaxSize,
+		}
+
 		if addPart {
-			if key != "" {
-				reader := bytes.NewReader(body)
-				rpb.AddPart(key, pb.Body(reader).Build())
+			body, err := m.spool(limiter, reqCtx)
+			if err != nil {
+				return msg, err
+			}
+			if body.path != "" {
+				spooled = append(spooled, body)
 			}
-		} else if len(body) > 0 {
+			rpb.AddPart(key, pb.Body(body).Build())
+			continue
+		}
+
+		body, err := io.ReadAll(limiter)
+		if err != nil {
+			return msg, err
+		}
+		if len(body) > 0 {
 			late, _, _, err := maps.Out[Late](composer, body, maps.From(ct, nil))
 			if err != nil {
 				return msg, err
@@ -116,7 +212,7 @@ func (m *MultipartMapper) Write(
 	ctx miruken.HandleContext,
 ) (io.Writer, error) {
 	if parts, ok := msg.Payload.(PartContainer); ok {
-		return m.WriteParts(nil, parts, it, ctx)
+		return m.writeParts(parts, msg.Headers, it, ctx)
 	}
 	return nil, nil
 }
@@ -127,6 +223,17 @@ func (m *MultipartMapper) WriteParts(
 	  }, pc PartContainer,
 	it *maps.It,
 	ctx miruken.HandleContext,
+) (io.Writer, error) {
+	return m.writeParts(pc, Headers{}, it, ctx)
+}
+
+// writeParts writes the parts with the envelope
+// headers added to the main part.
+func (m *MultipartMapper) writeParts(
+	pc      PartContainer,
+	headers Headers,
+	it      *maps.It,
+	ctx     miruken.HandleContext,
 ) (io.Writer, error) {
 	if writer, ok := it.Target().(*io.Writer); ok {
 		typ, boundary, start := extractMultipartParams(it)
@@ -141,13 +248,13 @@ func (m *MultipartMapper) WriteParts(
 			return nil, err
 		}
 		if main := pc.MainPart(); main != nil {
-			if err := addPart(start, typ, main, mw, ctx); err != nil {
+			if err := addPart(start, typ, main, headers, mw, ctx); err != nil {
 				return nil, err
 			}
 		}
 		for key, set := range pc.Parts() {
 			for _, part := range set {
-				if err := addPart(key, typ, part, mw, ctx); err != nil {
+				if err := addPart(key, typ, part, Headers{}, mw, ctx); err != nil {
 					return nil, err
 				}
 			}
@@ -161,15 +268,17 @@ func (m *MultipartMapper) WriteParts(
 }
 
 func addPart(
-	key    string,
-	typ    string,
-	part   Part,
-	writer *multipart.Writer,
-	ctx    miruken.HandleContext,
+	key     string,
+	typ     string,
+	part    Part,
+	headers Headers,
+	writer  *multipart.Writer,
+	ctx     miruken.HandleContext,
 ) error {
 	contentType := part.MediaType()
 	header := NewHeader(part.Metadata())
 	header.Set("Content-Type", contentType)
+	WriteHeaders(headers, header)
 
 	if typ == "multipart/form-data" {
 		if header.Get("Content-Disposition") == "" {
@@ -206,6 +315,46 @@ func addPart(
 	return err
 }
 
+// spool reads the part body into memory up to the spool
+// threshold and into a temporary file beyond it.
+// Every part is spooled deliberately while reading the message
+// since a multipart.Reader discards the unread body of a part
+// when advancing, so parts cannot be streamed lazily once the
+// main part and headers are located.  Memory is bounded by the
+// SpoolThreshold of each part, while MaxPartSize and MaxSize
+// bound the spooled files through the reader limits.
+func (m *MultipartMapper) spool(
+	reader io.Reader,
+	ctx    context.Context,
+) (*partBody, error) {
+	var buf bytes.Buffer
+	threshold := m.options.SpoolThreshold
+	if _, err := io.CopyN(&buf, reader, threshold+1); err == io.EOF {
+		return &partBody{data: bytes.NewReader(buf.Bytes())}, nil
+	} else if err != nil {
+		return nil, err
+	}
+	file, err := os.CreateTemp(m.options.SpoolDir, "multipart-")
+	if err != nil {
+		return nil, err
+	}
+	_, err = io.Copy(file, io.MultiReader(&buf, reader))
+	if cerr := file.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		_ = os.Remove(file.Name())
+		return nil, err
+	}
+	body := &partBody{path: file.Name()}
+	if ctx != nil {
+		context.AfterFunc(ctx, body.discard)
+	} else {
+		body.remove = true
+	}
+	return body, nil
+}
+
 func extractMultipartParams(
 	src miruken.ConstraintSource,
 ) (typ string, boundary, start string) {
@@ -221,4 +370,77 @@ func extractMultipartParams(
 	return
 }
 
+// ContentTooLargeError
+
+func (e *ContentTooLargeError) Error() string {
+	if part := e.Part; part != "" {
+		return fmt.Sprintf("multipart: part %q exceeds %d bytes", part, e.Limit)
+	}
+	return fmt.Sprintf("multipart: content exceeds %d bytes", e.Limit)
+}
+
+// partBody
+
+func (b *partBody) Read(p []byte) (int, error) {
+	if data := b.data; data != nil {
+		return data.Read(p)
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.file == nil {
+		if b.path == "" {
+			return 0, os.ErrClosed
+		}
+		file, err := os.Open(b.path)
+		if err != nil {
+			return 0, err
+		}
+		b.file = file
+	}
+	return b.file.Read(p)
+}
+
+func (b *partBody) Close() error {
+	if b.remove {
+		b.discard()
+		return nil
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if file := b.file; file != nil {
+		b.file = nil
+		return file.Close()
+	}
+	return nil
+}
+
+// discard closes and removes the spooled file, if any.
+func (b *partBody) discard() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if file := b.file; file != nil {
+		b.file = nil
+		_ = file.Close()
+	}
+	if path := b.path; path != "" {
+		b.path = ""
+		_ = os.Remove(path)
+	}
+}
+
+// partLimiter
+
+func (l *partLimiter) Read(p []byte) (n int, err error) {
+	n, err = l.reader.Read(p)
+	l.read += int64(n)
+	*l.total += int64(n)
+	if l.maxPart > 0 && l.read > l.maxPart {
+		return n, &ContentTooLargeError{Part: l.part, Limit: l.maxPart}
+	}
+	if l.maxTotal > 0 && *l.total > l.maxTotal {
+		return n, &ContentTooLargeError{Limit: l.maxTotal}
+	}
+	return n, err
+}
+
 var ErrMissingBoundary = errors.New(`multipart: missing "boundary" parameter`)
@@ -0,0 +1,313 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"sync"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/args"
+	"github.com/miruken-go/miruken/either"
+	"github.com/miruken-go/miruken/handles"
+	"github.com/miruken-go/miruken/internal"
+	"github.com/miruken-go/miruken/promise"
+	"github.com/miruken-go/miruken/provides"
+)
+
+type (
+	// Partitioned is implemented by messages that must be
+	// processed in order with other messages of the same key.
+	// Alternatively, a struct field can be tagged with
+	//
+	//	OrderId string `partition:""`
+	Partitioned interface {
+		PartitionKey() any
+	}
+
+	// PartitionedBatch represents a batch of requests where requests
+	// with the same partition key are processed sequentially in the
+	// order given and different keys are processed concurrently.
+	// Requests without a key are processed concurrently.
+	// A failure skips the remaining requests of its key.
+	// Concurrency bounds the number of requests processed at once.
+	PartitionedBatch struct {
+		Requests    []any
+		Concurrency int
+	}
+
+	// PartitionOptions customize the Partitioner.
+	PartitionOptions struct {
+		Concurrency int
+	}
+
+	// Partitioner is a setup.Bootstrap that processes messages
+	// with the same partition key in the order received while
+	// different keys are processed concurrently.
+	Partitioner struct {
+		limit  chan struct{}
+		lanes  map[any]*lane
+		active sync.WaitGroup
+		closed bool
+		lock   sync.Mutex
+	}
+
+	// lane holds the messages pending for a partition key.
+	lane struct {
+		jobs []job
+	}
+
+	// job is a message pending in a lane.
+	job struct {
+		handler  miruken.Handler
+		message  any
+		deferred promise.Deferred[any]
+	}
+
+	// unkeyed is the partition key of a message without one.
+	unkeyed struct {
+		index int
+	}
+)
+
+var ErrPartitionerClosed = errors.New("api: partitioner is closed")
+
+// Scheduler
+
+func (s *Scheduler) Partition(
+	_ *handles.It, partitioned PartitionedBatch,
+	composer miruken.Handler,
+) *promise.Promise[ScheduledResult] {
+	return promise.New(nil, func(resolve func(ScheduledResult), reject func(error), onCancel func(func())) {
+		requests := partitioned.Requests
+		responses := make([]either.Monad[error, any], len(requests))
+
+		var keys []any
+		partitions := make(map[any][]int)
+		for i, request := range requests {
+			key, ok := PartitionKey(request)
+			if !ok {
+				key = unkeyed{i}
+			}
+			if _, ok := partitions[key]; !ok {
+				keys = append(keys, key)
+			}
+			partitions[key] = append(partitions[key], i)
+		}
+
+		var limit chan struct{}
+		if concurrency := partitioned.Concurrency; concurrency > 0 {
+			limit = make(chan struct{}, concurrency)
+		}
+
+		var waitGroup sync.WaitGroup
+		waitGroup.Add(len(keys))
+
+		for _, key := range keys {
+			go func(indices []int) {
+				defer waitGroup.Done()
+				var failed error
+				for _, idx := range indices {
+					if failed != nil {
+						responses[idx] = Failure(&miruken.CanceledError{
+							Message: "partition aborted", Cause: failed})
+						continue
+					}
+					if limit != nil {
+						limit <- struct{}{}
+					}
+					response, success := process(requests[idx], composer)
+					if limit != nil {
+						<-limit
+					}
+					responses[idx] = response
+					if !success {
+						failed = either.Fold(response,
+							func(err error) error { return err },
+							func(any) error { return nil })
+					}
+				}
+			}(partitions[key])
+		}
+
+		waitGroup.Wait()
+		resolve(ScheduledResult{responses})
+	})
+}
+
+// Partitioner
+
+func (p *Partitioner) Constructor(
+	_ *struct {
+		provides.It
+		provides.Single
+	  },
+	_ *struct {
+		args.Optional
+		args.FromOptions
+	  }, options PartitionOptions,
+) {
+	if concurrency := options.Concurrency; concurrency > 0 {
+		p.limit = make(chan struct{}, concurrency)
+	}
+	p.lanes = make(map[any]*lane)
+}
+
+// Send processes a request after all previous messages
+// with the same partition key.
+// Returns a promise of the response.
+func (p *Partitioner) Send(
+	handler miruken.Handler,
+	request any,
+) *promise.Promise[any] {
+	if internal.IsNil(handler) {
+		panic("handler cannot be nil")
+	}
+	if internal.IsNil(request) {
+		panic("request cannot be nil")
+	}
+	j := job{handler, request, promise.Defer[any]()}
+
+	p.lock.Lock()
+	if p.closed {
+		p.lock.Unlock()
+		return promise.Reject[any](ErrPartitionerClosed)
+	}
+	key, ok := PartitionKey(request)
+	if !ok {
+		key = &j
+	}
+	l := p.lanes[key]
+	if l == nil {
+		l = &lane{}
+		p.lanes[key] = l
+		p.active.Add(1)
+		go p.drain(key, l)
+	}
+	l.jobs = append(l.jobs, j)
+	p.lock.Unlock()
+
+	return j.deferred.Promise()
+}
+
+func (p *Partitioner) Startup(
+	ctx context.Context,
+	h   miruken.Handler,
+) *promise.Promise[struct{}] {
+	return promise.Empty()
+}
+
+// Shutdown rejects new messages and waits for
+// the pending messages to be processed.
+func (p *Partitioner) Shutdown(
+	ctx context.Context,
+) *promise.Promise[struct{}] {
+	p.lock.Lock()
+	p.closed = true
+	p.lock.Unlock()
+	return promise.New(ctx, func(
+		resolve func(struct{}), reject func(error), onCancel func(func()),
+	) {
+		done := make(chan struct{})
+		go func() {
+			p.active.Wait()
+			close(done)
+		}()
+		select {
+		case <-done:
+			resolve(struct{}{})
+		case <-ctx.Done():
+			reject(ctx.Err())
+		}
+	})
+}
+
+// drain processes the messages of a lane in order until empty.
+func (p *Partitioner) drain(key any, l *lane) {
+	defer p.active.Done()
+	for {
+		p.lock.Lock()
+		if len(l.jobs) == 0 {
+			delete(p.lanes, key)
+			p.lock.Unlock()
+			return
+		}
+		j := l.jobs[0]
+		l.jobs = l.jobs[1:]
+		p.lock.Unlock()
+
+		if limit := p.limit; limit != nil {
+			limit <- struct{}{}
+		}
+		response, _ := process(j.message, j.handler)
+		if limit := p.limit; limit != nil {
+			<-limit
+		}
+		either.Fold(response,
+			func(err error) any {
+				j.deferred.Reject(err)
+				return nil
+			},
+			func(success any) any {
+				j.deferred.Resolve(success)
+				return nil
+			})
+	}
+}
+
+// Ordered processes a batch of requests in order per partition key.
+// Returns a batch of corresponding responses (or errors).
+func Ordered(
+	handler miruken.Handler,
+	requests ...any,
+) *promise.Promise[[]either.Monad[error, any]] {
+	if internal.IsNil(handler) {
+		panic("handler cannot be nil")
+	}
+	return sendBatch(handler, PartitionedBatch{Requests: requests})
+}
+
+// PartitionKey returns the partition key of a message from
+// the Partitioned interface or a field tagged with partition.
+func PartitionKey(message any) (any, bool) {
+	if p, ok := message.(Partitioned); ok {
+		if key := p.PartitionKey(); key != nil && reflect.TypeOf(key).Comparable() {
+			return key, true
+		}
+		return nil, false
+	}
+	v := reflect.ValueOf(message)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil, false
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil, false
+	}
+	if field, ok := partitionField(v.Type()); ok {
+		return v.Field(field).Interface(), true
+	}
+	return nil, false
+}
+
+// partitionField returns the index of the field tagged with
+// partition caching the result per type.
+func partitionField(typ reflect.Type) (int, bool) {
+	if field, ok := partitionFields.Load(typ); ok {
+		return field.(int), field.(int) >= 0
+	}
+	field := -1
+	for i := range typ.NumField() {
+		f := typ.Field(i)
+		if _, ok := f.Tag.Lookup("partition"); ok && f.Type.Comparable() {
+			field = i
+			break
+		}
+	}
+	partitionFields.Store(typ, field)
+	return field, field >= 0
+}
+
+var partitionFields sync.Map
@@ -9,6 +9,7 @@ import (
 	"sync/atomic"
 
 	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/args"
 	"github.com/miruken-go/miruken/creates"
 	"github.com/miruken-go/miruken/maps"
 )
@@ -61,16 +62,31 @@ var (
 	ToTypeInfo = maps.To("type:info", nil)
 )
 
-// TypeInfo uses package and name to generate type metadata.
+// TypeInfo uses the registered type id or package and name
+// to generate type metadata.
 func (m *GoPolymorphism) TypeInfo(
 	_ *struct {
 		maps.Format `to:"type:info"`
 	  }, it *maps.It,
+	_ *struct{ args.Optional }, registry *TypeRegistry,
 ) (TypeFieldInfo, error) {
-	val := reflect.TypeOf(it.Source()).String()
-	val = strings.TrimPrefix(val, "*")
-	if strings.HasPrefix(val, "[]*") {
-		val = "[]" + val[3:]
+	var val string
+	typ := reflect.TypeOf(it.Source())
+	if id, ok := registeredTypeId(registry, typ); ok {
+		val = id
+	} else if typ.Kind() == reflect.Slice {
+		if id, ok := registeredTypeId(registry, typ.Elem()); ok {
+			val = "[]" + id
+		}
+	}
+	if val == "" {
+		val = reflect.TypeOf(it.Source()).String()
+		val = strings.TrimPrefix(val, "*")
+		if strings.HasPrefix(val, "[]*") {
+			val = "[]" + val[3:]
+		} else if !strings.HasPrefix(val, "[]") {
+			val = goTypeId(val, it.Source())
+		}
 	}
 	return TypeFieldInfo{
 		TypeField:   "@type",
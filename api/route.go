@@ -142,6 +142,9 @@ func (r routesFilter) Next(
 					ctx.Greedy,
 					composer)
 			}
+			if p, ok := coalesce(routed, &ctx); ok {
+				return nil, promise.Slice(p), nil
+			}
 		} else {
 			return next.Abort()
 		}
@@ -172,37 +175,7 @@ func (b *batchRouter) CompleteBatch(
 ) (any, *promise.Promise[any], error) {
 	complete := make([]*promise.Promise[any], 0, len(b.groups))
 	for route, group := range b.groups {
-		uri := route
-		messages := slices.Map[pending, any](group, func(p pending) any {
-			return p.message
-		})
-		routeTo := RouteTo(ConcurrentBatch{messages}, route)
-		complete = append(complete,
-			promise.Then(sendBatch(composer, routeTo),
-				func(results []either.Monad[error, any]) RouteReply {
-					responses := make([]any, len(results))
-					for i := len(responses); i < len(messages); i++ {
-						group[i].deferred.Reject(ErrMissingResponse)
-					}
-					for i, response := range results {
-						responses[i] = either.Fold(response,
-							func(err error) any {
-								group[i].deferred.Reject(err)
-								return err
-							},
-							func(success any) any {
-								group[i].deferred.Resolve(success)
-								return success
-							})
-					}
-					return RouteReply{uri, responses}
-				}).Catch(func(err error) error {
-				canceled := &miruken.CanceledError{Message: "batch canceled", Cause: err}
-				for _, p := range group {
-					p.deferred.Reject(canceled)
-				}
-				return err
-			}))
+		complete = append(complete, sendGroup(composer, route, group))
 	}
 	return nil, promise.Coerce[any](promise.All(nil, complete...)), nil
 }
@@ -234,6 +207,44 @@ func (b *batchRouter) batch(
 	return request.deferred.Promise()
 }
 
+// sendGroup sends the pending messages for a route as a single
+// ConcurrentBatch and settles each pending promise with its response.
+func sendGroup(
+	composer miruken.Handler,
+	route    string,
+	group    []pending,
+) *promise.Promise[any] {
+	messages := slices.Map[pending, any](group, func(p pending) any {
+		return p.message
+	})
+	routeTo := RouteTo(ConcurrentBatch{Requests: messages}, route)
+	return promise.Then(sendBatch(composer, routeTo),
+		func(results []either.Monad[error, any]) RouteReply {
+			responses := make([]any, len(results))
+			for i := len(responses); i < len(messages); i++ {
+				group[i].deferred.Reject(ErrMissingResponse)
+			}
+			for i, response := range results {
+				responses[i] = either.Fold(response,
+					func(err error) any {
+						group[i].deferred.Reject(err)
+						return err
+					},
+					func(success any) any {
+						group[i].deferred.Resolve(success)
+						return success
+					})
+			}
+			return RouteReply{route, responses}
+		}).Catch(func(err error) error {
+		canceled := &miruken.CanceledError{Message: "batch canceled", Cause: err}
+		for _, p := range group {
+			p.deferred.Reject(canceled)
+		}
+		return err
+	})
+}
+
 // RouteTo wraps the message in a Routed container.
 func RouteTo(message any, route string) Routed {
 	if internal.IsNil(message) {
@@ -0,0 +1,408 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/either"
+	"github.com/miruken-go/miruken/handles"
+	"github.com/miruken-go/miruken/internal"
+	"github.com/miruken-go/miruken/promise"
+)
+
+type (
+	// Aggregation enumerates how scattered responses are gathered.
+	Aggregation uint8
+
+	// ScatterGather sends a request to many targets and gathers
+	// their responses.  The targets are the Routes or all local
+	// handlers if no Routes are provided.  Gathering completes
+	// once the Strategy is satisfied or the Timeout expires and
+	// any late responses are canceled.
+	ScatterGather struct {
+		Request  any
+		Routes   []string
+		Quorum   int
+		Timeout  time.Duration
+		Strategy Aggregation
+	}
+
+	// GatherResult holds the responses of a ScatterGather.
+	// Responses correspond to Targets and include failures.
+	// Local handlers are targeted by their type name.
+	// Result is the aggregated response of the Strategy.
+	GatherResult struct {
+		Targets   []string
+		Responses []either.Monad[error, any]
+		Result    any
+	}
+
+	// QuorumError reports a ScatterGather that could not
+	// satisfy its Strategy.
+	QuorumError struct {
+		Strategy  Aggregation
+		Quorum    int
+		Successes int
+		Result    GatherResult
+	}
+
+	// gatherer aggregates scattered responses.
+	gatherer struct {
+		strategy  Aggregation
+		quorum    int
+		responses []either.Monad[error, any]
+		arrived   int
+		successes int
+		first     any
+		winner    any
+		won       bool
+		counts    []tally
+	}
+
+	// tally counts equal successful responses.
+	tally struct {
+		value any
+		count int
+	}
+
+	// scatterLocal is a Handles callback that collects the
+	// response of each local handler separately.
+	scatterLocal struct {
+		*miruken.Handles
+		request   any
+		targets   []string
+		scattered []*promise.Promise[any]
+		lock      sync.Mutex
+	}
+
+	// arrival is a response from a scattered target.
+	arrival struct {
+		index    int
+		response either.Monad[error, any]
+	}
+)
+
+const (
+	// AggregateAllSuccessful waits for all the targets and
+	// gathers the successful responses.  At least Quorum
+	// (default 1) responses must succeed.
+	AggregateAllSuccessful Aggregation = iota
+
+	// AggregateFirst completes after Quorum (default 1)
+	// successful responses with the first as the Result.
+	AggregateFirst
+
+	// AggregateMajority completes once Quorum (default more
+	// than half the targets) successful responses are equal.
+	AggregateMajority
+)
+
+var (
+	ErrGatherTimeout  = errors.New("api: scatter-gather timed out")
+	ErrMissingTargets = errors.New("api: scatter-gather has no targets")
+)
+
+// Aggregation
+
+func (a Aggregation) String() string {
+	switch a {
+	case AggregateAllSuccessful:
+		return "all-successful"
+	case AggregateFirst:
+		return "first"
+	case AggregateMajority:
+		return "majority-equal"
+	}
+	return fmt.Sprintf("Aggregation(%d)", a)
+}
+
+// QuorumError
+
+func (e *QuorumError) Error() string {
+	return fmt.Sprintf("api: scatter-gather %s quorum of %d not reached (%d of %d succeeded)",
+		e.Strategy, e.Quorum, e.Successes, len(e.Result.Responses))
+}
+
+// Scheduler
+
+func (s *Scheduler) ScatterGather(
+	_ *handles.It, sg ScatterGather,
+	composer miruken.Handler,
+) *promise.Promise[GatherResult] {
+	return promise.New(nil, func(resolve func(GatherResult), reject func(error), onCancel func(func())) {
+		if internal.IsNil(sg.Request) {
+			reject(errors.New("api: scatter-gather request cannot be nil"))
+			return
+		}
+		if len(sg.Routes) == 0 {
+			gatherLocal(sg, composer, resolve, reject, onCancel)
+			return
+		}
+		targets := sg.Routes
+		scattered := make([]*promise.Promise[any], len(targets))
+		for i, route := range targets {
+			if r, pr, err := Send[any](composer, RouteTo(sg.Request, route)); err != nil {
+				scattered[i] = promise.Reject[any](err)
+			} else if pr == nil {
+				scattered[i] = promise.Resolve(r)
+			} else {
+				scattered[i] = pr
+			}
+		}
+		gather(sg, targets, scattered, resolve, reject, onCancel)
+	})
+}
+
+// Gather sends a ScatterGather request.
+// Returns the gathered responses if the Strategy is satisfied.
+func Gather(
+	handler miruken.Handler,
+	sg      ScatterGather,
+) *promise.Promise[GatherResult] {
+	if internal.IsNil(handler) {
+		panic("handler cannot be nil")
+	}
+	if r, pr, err := Send[GatherResult](handler, sg); err != nil {
+		return promise.Reject[GatherResult](err)
+	} else if pr != nil {
+		return pr
+	} else {
+		return promise.Resolve(r)
+	}
+}
+
+// gatherLocal scatters the request to each local handler.
+func gatherLocal(
+	sg       ScatterGather,
+	composer miruken.Handler,
+	resolve  func(GatherResult),
+	reject   func(error),
+	onCancel func(func()),
+) {
+	stash, request := envelope(composer, sg.Request)
+	var builder miruken.HandlesBuilder
+	local := &scatterLocal{
+		Handles: builder.WithCallback(request).New(),
+		request: request,
+	}
+	if result := stash.Handle(local, true, nil); result.IsError() {
+		reject(result.Error())
+		return
+	}
+	// handlers resolved asynchronously are scattered when ready
+	if _, pr := local.Result(true); pr != nil {
+		_, _ = pr.Await()
+	}
+	local.lock.Lock()
+	targets, scattered := local.targets, local.scattered
+	local.lock.Unlock()
+	if len(scattered) == 0 {
+		reject(ErrMissingTargets)
+		return
+	}
+	gather(sg, targets, scattered, resolve, reject, onCancel)
+}
+
+// gather awaits the scattered responses until the Strategy
+// is satisfied or the Timeout expires.
+func gather(
+	sg        ScatterGather,
+	targets   []string,
+	scattered []*promise.Promise[any],
+	resolve   func(GatherResult),
+	reject    func(error),
+	onCancel  func(func()),
+) {
+	onCancel(func() {
+		for _, p := range scattered {
+			p.Cancel()
+		}
+	})
+
+	arrivals := make(chan arrival, len(scattered))
+	for i, p := range scattered {
+		go func(idx int, p *promise.Promise[any]) {
+			if r, err := p.Await(); err != nil {
+				arrivals <- arrival{idx, Failure(err)}
+			} else {
+				arrivals <- arrival{idx, Success(r)}
+			}
+		}(i, p)
+	}
+
+	var timeout <-chan time.Time
+	if sg.Timeout > 0 {
+		timer := time.NewTimer(sg.Timeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	g := newGatherer(sg, len(targets))
+	var cause error
+gather:
+	for !g.done() {
+		select {
+		case a := <-arrivals:
+			g.add(a.index, a.response)
+		case <-timeout:
+			cause = ErrGatherTimeout
+			break gather
+		}
+	}
+
+	for i, p := range scattered {
+		if g.responses[i] == nil {
+			p.Cancel()
+			g.responses[i] = Failure(&miruken.CanceledError{
+				Message: "scatter-gather late response", Cause: cause})
+		}
+	}
+	if result, err := g.result(targets); err != nil {
+		reject(err)
+	} else {
+		resolve(result)
+	}
+}
+
+// scatterLocal
+
+// Dispatch sends the request to each handler separately
+// so every handler contributes its own response or failure.
+func (s *scatterLocal) Dispatch(
+	handler  any,
+	greedy   bool,
+	composer miruken.Handler,
+) miruken.HandleResult {
+	if _, ok := handler.(miruken.PolicyDispatch); ok {
+		count := s.ResultCount()
+		return miruken.DispatchPolicy(handler, s, greedy, composer).
+			OtherwiseHandledIf(s.ResultCount() > count)
+	}
+	var builder miruken.HandlesBuilder
+	handles := builder.WithCallback(s.request).New()
+	var response *promise.Promise[any]
+	if result := miruken.DispatchPolicy(handler, handles, false, composer); result.IsError() {
+		response = promise.Reject[any](result.Error())
+	} else if !result.Handled() {
+		return miruken.NotHandled
+	} else if r, pr := handles.Result(false); pr != nil {
+		response = pr
+	} else {
+		response = promise.Resolve(r)
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.targets   = append(s.targets, reflect.TypeOf(handler).String())
+	s.scattered = append(s.scattered, response)
+	return miruken.Handled
+}
+
+// gatherer
+
+func newGatherer(sg ScatterGather, targets int) *gatherer {
+	quorum := sg.Quorum
+	if quorum <= 0 {
+		if sg.Strategy == AggregateMajority {
+			quorum = targets/2 + 1
+		} else {
+			quorum = 1
+		}
+	}
+	return &gatherer{
+		strategy:  sg.Strategy,
+		quorum:    quorum,
+		responses: make([]either.Monad[error, any], targets),
+	}
+}
+
+func (g *gatherer) add(index int, response either.Monad[error, any]) {
+	g.responses[index] = response
+	g.arrived++
+	either.Fold(response,
+		func(error) any { return nil },
+		func(success any) any {
+			if g.successes++; g.successes == 1 {
+				g.first = success
+			}
+			if g.strategy == AggregateMajority {
+				g.count(success)
+			}
+			return nil
+		})
+}
+
+func (g *gatherer) count(value any) {
+	for i := range g.counts {
+		if t := &g.counts[i]; reflect.DeepEqual(t.value, value) {
+			if t.count++; t.count >= g.quorum && !g.won {
+				g.winner, g.won = t.value, true
+			}
+			return
+		}
+	}
+	g.counts = append(g.counts, tally{value, 1})
+	if g.quorum <= 1 && !g.won {
+		g.winner, g.won = value, true
+	}
+}
+
+func (g *gatherer) done() bool {
+	remaining := len(g.responses) - g.arrived
+	if remaining == 0 {
+		return true
+	}
+	switch g.strategy {
+	case AggregateFirst:
+		return g.successes >= g.quorum || g.successes+remaining < g.quorum
+	case AggregateMajority:
+		if g.won {
+			return true
+		}
+		most := 0
+		for _, t := range g.counts {
+			most = max(most, t.count)
+		}
+		return most+remaining < g.quorum
+	}
+	return false
+}
+
+func (g *gatherer) result(targets []string) (GatherResult, error) {
+	result := GatherResult{Targets: targets, Responses: g.responses}
+	switch g.strategy {
+	case AggregateFirst:
+		if g.successes >= g.quorum {
+			result.Result = g.first
+			return result, nil
+		}
+	case AggregateMajority:
+		if g.won {
+			result.Result = g.winner
+			return result, nil
+		}
+	default:
+		if g.successes >= g.quorum {
+			successes := make([]any, 0, g.successes)
+			for _, response := range g.responses {
+				either.Fold(response,
+					func(error) any { return nil },
+					func(success any) any {
+						successes = append(successes, success)
+						return nil
+					})
+			}
+			result.Result = successes
+			return result, nil
+		}
+	}
+	return result, &QuorumError{
+		Strategy:  g.strategy,
+		Quorum:    g.quorum,
+		Successes: g.successes,
+		Result:    result,
+	}
+}
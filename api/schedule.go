@@ -1,7 +1,10 @@
 package api
 
 import (
+	"context"
+	"errors"
 	"sync"
+	"time"
 
 	"github.com/miruken-go/miruken"
 	"github.com/miruken-go/miruken/creates"
@@ -16,8 +19,14 @@ type (
 	// ConcurrentBatch represents a batch of requests to execute concurrently.
 	// The operation returns after all requests are completed and
 	// includes all successes and failures.
+	// MaxParallelism bounds the requests executing at once.
+	// FailFast cancels the outstanding requests on the first failure.
+	// Timeout cancels any request not completed in time.
 	ConcurrentBatch struct {
-		Requests []any
+		Requests       []any
+		MaxParallelism int
+		FailFast       bool
+		Timeout        time.Duration
 	}
 
 	// SequentialBatch represents a batch of requests to execute sequentially.
@@ -42,6 +51,11 @@ type (
 	Scheduler struct{}
 )
 
+var (
+	ErrBatchCanceled  = errors.New("api: batch canceled")
+	ErrRequestTimeout = errors.New("api: batch request timed out")
+)
+
 // Scheduler
 
 func (s *Scheduler) Constructor(
@@ -60,14 +74,41 @@ func (s *Scheduler) Concurrent(
 		requests := concurrent.Requests
 		responses := make([]either.Monad[error, any], len(requests))
 
-		var waitGroup sync.WaitGroup
-		waitGroup.Add(len(requests))
+		ctx, cancel := context.WithCancelCause(context.Background())
+		defer cancel(nil)
+		onCancel(func() { cancel(ErrBatchCanceled) })
+
+		var limit chan struct{}
+		if parallelism := concurrent.MaxParallelism; parallelism > 0 {
+			limit = make(chan struct{}, parallelism)
+		}
 
+		var waitGroup sync.WaitGroup
 		for i, request := range requests {
+			if limit != nil {
+				select {
+				case limit <- struct{}{}:
+				case <-ctx.Done():
+				}
+			}
+			if ctx.Err() != nil {
+				responses[i] = Failure(&miruken.CanceledError{
+					Message: "batch canceled", Cause: context.Cause(ctx)})
+				continue
+			}
+			waitGroup.Add(1)
 			go func(idx int, req any) {
 				defer waitGroup.Done()
-				response, _ := process(req, composer)
+				if limit != nil {
+					defer func() { <-limit }()
+				}
+				response, success := processWithin(ctx, req, composer, concurrent.Timeout)
 				responses[idx] = response
+				if !success && concurrent.FailFast {
+					cancel(either.Fold(response,
+						func(err error) error { return err },
+						func(any) error { return nil }))
+				}
 			}(i, request)
 		}
 
@@ -109,6 +150,8 @@ func (s *Scheduler) New(
 		_ creates.It `key:"api.SequentialBatch"`
 		_ creates.It `key:"api.ScheduledResult"`
 		_ creates.It `key:"api.Published"`
+		_ creates.It `key:"api.Timer"`
+		_ creates.It `key:"api.CancelTimer"`
 	}, create *creates.It,
 ) any {
 	switch create.Key() {
@@ -120,6 +163,10 @@ func (s *Scheduler) New(
 		return new(ScheduledResult)
 	case "api.Published":
 		return new(Published)
+	case "api.Timer":
+		return new(Timer)
+	case "api.CancelTimer":
+		return new(CancelTimer)
 	}
 	return nil
 }
@@ -145,7 +192,7 @@ func Concurrent(
 	if internal.IsNil(handler) {
 		panic("handler cannot be nil")
 	}
-	return sendBatch(handler, ConcurrentBatch{requests})
+	return sendBatch(handler, ConcurrentBatch{Requests: requests})
 }
 
 func process(
@@ -164,6 +211,59 @@ func process(
 	return Success(res), true
 }
 
+// processWithin processes a request until completed, the
+// timeout expires or the context is canceled.
+func processWithin(
+	ctx     context.Context,
+	request any,
+	handler miruken.Handler,
+	timeout time.Duration,
+) (either.Monad[error, any], bool) {
+	if ctx.Err() != nil {
+		return Failure(&miruken.CanceledError{
+			Message: "batch canceled", Cause: context.Cause(ctx)}), false
+	}
+	var expired <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		expired = timer.C
+	}
+	// abandoned cancels the request once no longer awaited
+	abandoned, abandon := context.WithCancel(context.Background())
+	defer abandon()
+	done := make(chan either.Monad[error, any], 1)
+	go func() {
+		// sent here since handlers may block before returning
+		res, pr, err := Send[any](handler, request)
+		if err != nil {
+			done <- Failure(err)
+			return
+		} else if pr == nil {
+			done <- Success(res)
+			return
+		}
+		stop := context.AfterFunc(abandoned, pr.Cancel)
+		defer stop()
+		if res, err = pr.Await(); err != nil {
+			done <- Failure(err)
+		} else {
+			done <- Success(res)
+		}
+	}()
+	select {
+	case response := <-done:
+		return response, either.Fold(response,
+			func(error) bool { return false },
+			func(any) bool { return true })
+	case <-expired:
+		return Failure(ErrRequestTimeout), false
+	case <-ctx.Done():
+		return Failure(&miruken.CanceledError{
+			Message: "batch canceled", Cause: context.Cause(ctx)}), false
+	}
+}
+
 func sendBatch(
 	handler miruken.Handler,
 	batch any,
@@ -0,0 +1,32 @@
+package api
+
+import "io"
+
+type (
+	// MessageStream is a sequence of messages read incrementally.
+	// Next returns io.EOF when no messages remain.  Any other error
+	// applies to the current message only and reading can continue.
+	MessageStream interface {
+		Next() (Message, error)
+	}
+
+	// MessageStreamFunc promotes a function to MessageStream.
+	MessageStreamFunc func() (Message, error)
+)
+
+func (f MessageStreamFunc) Next() (Message, error) {
+	return f()
+}
+
+// Messages returns a MessageStream over the supplied messages.
+func Messages(messages ...Message) MessageStream {
+	index := 0
+	return MessageStreamFunc(func() (Message, error) {
+		if index >= len(messages) {
+			return Message{}, io.EOF
+		}
+		msg := messages[index]
+		index++
+		return msg, nil
+	})
+}
@@ -9,11 +9,18 @@ import (
 var TestFeature setup.Feature = setup.FeatureFunc(func(setup *setup.Builder) error {
 	setup.Specs(
 		&CancelOrderFilter{},
+		&CoalesceHandler{},
+		&ConversationHandler{},
+		&EastRegionHandler{},
+		&LedgerHandler{},
 		&MissionControlHandler{},
+		&NorthRegionHandler{},
 		&OrderHandler{},
 		&PresidentHandler{},
+		&ReplicaHandler{},
 		&StockQuoteHandler{},
 		&TrashHandler{},
+		&WestRegionHandler{},
 	)
 	return nil
 })
@@ -0,0 +1,230 @@
+package test
+
+import (
+	"net/textproto"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/cascade"
+	"github.com/miruken-go/miruken/context"
+	"github.com/miruken-go/miruken/handles"
+	"github.com/miruken-go/miruken/provides"
+	"github.com/miruken-go/miruken/setup"
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	StartConversation struct{}
+	Reply             struct{}
+	Notify            struct{}
+	Acknowledge       struct{}
+
+	HeadersRecorder struct {
+		headers map[string]api.Headers
+		lock    sync.Mutex
+	}
+
+	ConversationHandler struct{}
+)
+
+// HeadersRecorder
+
+func (r *HeadersRecorder) Constructor(
+	_ *struct {
+		provides.It
+		provides.Single
+	  },
+) {
+	r.headers = make(map[string]api.Headers)
+}
+
+func (r *HeadersRecorder) Record(name string, headers api.Headers) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.headers[name] = headers
+}
+
+func (r *HeadersRecorder) Headers(name string) (api.Headers, bool) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	headers, ok := r.headers[name]
+	return headers, ok
+}
+
+// ConversationHandler
+
+func (c *ConversationHandler) Start(
+	_ *handles.It, _ StartConversation,
+	headers api.Headers,
+	composer miruken.Handler,
+) ([]api.Headers, error) {
+	reply, _, err := api.Send[api.Headers](composer, Reply{})
+	if err != nil {
+		return nil, err
+	}
+	return []api.Headers{headers, reply}, nil
+}
+
+func (c *ConversationHandler) Reply(
+	_ *handles.It, _ Reply,
+	headers api.Headers,
+) api.Headers {
+	return headers
+}
+
+func (c *ConversationHandler) Notify(
+	_ *handles.It, _ Notify,
+	headers api.Headers,
+	recorder *HeadersRecorder,
+) *cascade.Messages {
+	recorder.Record("notify", headers)
+	return cascade.Post(Acknowledge{})
+}
+
+func (c *ConversationHandler) Acknowledge(
+	_ *handles.It, _ Acknowledge,
+	headers api.Headers,
+	recorder *HeadersRecorder,
+) {
+	recorder.Record("acknowledge", headers)
+}
+
+type HeadersTestSuite struct {
+	suite.Suite
+}
+
+func (suite *HeadersTestSuite) Setup() *context.Context {
+	ctx, _ := setup.New(
+		TestFeature,
+		api.Feature()).
+		Specs(&HeadersRecorder{}).
+		Context()
+	return ctx
+}
+
+func (suite *HeadersTestSuite) TestHeaders() {
+	suite.Run("Assign", func() {
+		handler := suite.Setup()
+		headers, _, err := api.Send[api.Headers](handler, Reply{})
+		suite.Nil(err)
+		suite.NotEmpty(headers.MessageId)
+		suite.Equal(headers.MessageId, headers.CorrelationId)
+		suite.Empty(headers.CausationId)
+		suite.False(headers.SentAt.IsZero())
+
+		next, _, err := api.Send[api.Headers](handler, Reply{})
+		suite.Nil(err)
+		suite.NotEqual(headers.MessageId, next.MessageId)
+		suite.NotEqual(headers.CorrelationId, next.CorrelationId)
+	})
+
+	suite.Run("Chain", func() {
+		handler := suite.Setup()
+		headers, _, err := api.Send[[]api.Headers](handler, StartConversation{})
+		suite.Nil(err)
+		suite.Len(headers, 2)
+		start, reply := headers[0], headers[1]
+		suite.NotEqual(start.MessageId, reply.MessageId)
+		suite.Equal(start.CorrelationId, reply.CorrelationId)
+		suite.Equal(start.MessageId, reply.CausationId)
+	})
+
+	suite.Run("Explicit", func() {
+		handler := suite.Setup()
+		deadline := time.Now().Add(time.Minute).UTC()
+		explicit := api.Headers{
+			MessageId: "m1",
+			Tenant:    "acme",
+			Deadline:  deadline,
+		}
+		explicit.Set("Priority", "high")
+		headers, _, err := api.Send[[]api.Headers](handler,
+			api.Message{Payload: StartConversation{}, Headers: explicit})
+		suite.Nil(err)
+		start, reply := headers[0], headers[1]
+		suite.Equal("m1", start.MessageId)
+		suite.Equal("m1", start.CorrelationId)
+		suite.Equal("acme", start.Tenant)
+		suite.Equal("m1", reply.CorrelationId)
+		suite.Equal("m1", reply.CausationId)
+		suite.Equal("acme", reply.Tenant)
+		suite.Equal(deadline, reply.Deadline)
+		suite.Equal("high", reply.Get("Priority"))
+		suite.False(reply.Expired())
+	})
+
+	suite.Run("Cascade", func() {
+		handler := suite.Setup()
+		pv, err := api.Post(handler, Notify{})
+		suite.Nil(err)
+		if pv != nil {
+			_, err = pv.Await()
+			suite.Nil(err)
+		}
+		recorder, _, _, _ := provides.Type[*HeadersRecorder](handler)
+		notify, ok := recorder.Headers("notify")
+		suite.True(ok)
+		ack, ok := recorder.Headers("acknowledge")
+		suite.True(ok)
+		suite.Equal(notify.CorrelationId, ack.CorrelationId)
+		suite.Equal(notify.MessageId, ack.CausationId)
+	})
+
+	suite.Run("Current", func() {
+		handler := suite.Setup()
+		_, ok := api.CurrentHeaders(handler)
+		suite.False(ok)
+	})
+
+	suite.Run("Mime", func() {
+		sentAt := time.Date(2024, 3, 1, 8, 30, 0, 0, time.UTC)
+		headers := api.Headers{
+			MessageId:     "m2",
+			CorrelationId: "c1",
+			CausationId:   "m1",
+			Tenant:        "acme",
+			SentAt:        sentAt,
+			Deadline:      sentAt.Add(time.Second),
+		}
+		headers.Set("Priority", "low")
+		header := textproto.MIMEHeader{}
+		header.Set("Content-Type", "application/json")
+		api.WriteHeaders(headers, header)
+		suite.Equal("m2", header.Get(api.HeaderMessageId))
+		suite.Equal("low", header.Get("X-Header-Priority"))
+
+		read, err := api.ReadHeaders(header)
+		suite.Nil(err)
+		suite.Equal(headers, read)
+
+		api.RemoveHeaders(header)
+		suite.Equal(textproto.MIMEHeader{
+			"Content-Type": {"application/json"},
+		}, header)
+
+		header.Set(api.HeaderSentAt, "yesterday")
+		_, err = api.ReadHeaders(header)
+		suite.NotNil(err)
+	})
+
+	suite.Run("Merge", func() {
+		headers := api.Headers{MessageId: "m1"}
+		headers.Set("A", "1")
+		other := api.Headers{MessageId: "m2", Tenant: "acme"}
+		other.Set("A", "2")
+		other.Set("B", "3")
+		merged := headers.Merge(other)
+		suite.Equal("m1", merged.MessageId)
+		suite.Equal("acme", merged.Tenant)
+		suite.Equal(map[string]string{"A": "1", "B": "3"}, merged.Custom)
+		suite.True(api.Headers{}.IsZero())
+		suite.False(merged.IsZero())
+	})
+}
+
+func TestHeadersTestSuite(t *testing.T) {
+	suite.Run(t, new(HeadersTestSuite))
+}
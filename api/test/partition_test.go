@@ -0,0 +1,305 @@
+package test
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/context"
+	"github.com/miruken-go/miruken/either"
+	"github.com/miruken-go/miruken/handles"
+	"github.com/miruken-go/miruken/provides"
+	"github.com/miruken-go/miruken/setup"
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	PostEntry struct {
+		Account string `partition:""`
+		Seq     int
+		Delay   time.Duration
+		Fail    bool
+	}
+
+	Transfer struct {
+		From string
+		Seq  int
+	}
+
+	Receipt struct {
+		Account string
+		Seq     int
+	}
+
+	Ledger struct {
+		entries  map[string][]int
+		inflight int
+		peak     int
+		lock     sync.Mutex
+	}
+
+	LedgerHandler struct{}
+)
+
+// Transfer
+
+func (t Transfer) PartitionKey() any {
+	return t.From
+}
+
+// Ledger
+
+func (l *Ledger) Constructor(
+	_ *struct {
+		provides.It
+		provides.Single
+	  },
+) {
+	l.entries = make(map[string][]int)
+}
+
+func (l *Ledger) enter() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.inflight++
+	l.peak = max(l.peak, l.inflight)
+}
+
+func (l *Ledger) exit(account string, seq int) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.inflight--
+	l.entries[account] = append(l.entries[account], seq)
+}
+
+func (l *Ledger) Entries(account string) []int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return append([]int(nil), l.entries[account]...)
+}
+
+func (l *Ledger) Peak() int {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.peak
+}
+
+// LedgerHandler
+
+func (h *LedgerHandler) Post(
+	_ *handles.It, entry PostEntry,
+	ledger *Ledger,
+) (Receipt, error) {
+	ledger.enter()
+	time.Sleep(entry.Delay)
+	ledger.exit(entry.Account, entry.Seq)
+	if entry.Fail {
+		return Receipt{}, fmt.Errorf("entry %d rejected", entry.Seq)
+	}
+	return Receipt{entry.Account, entry.Seq}, nil
+}
+
+func (h *LedgerHandler) Transfer(
+	_ *handles.It, transfer Transfer,
+	ledger *Ledger,
+) Receipt {
+	ledger.enter()
+	time.Sleep(time.Duration(5-transfer.Seq) * time.Millisecond)
+	ledger.exit(transfer.From, transfer.Seq)
+	return Receipt{transfer.From, transfer.Seq}
+}
+
+type PartitionTestSuite struct {
+	suite.Suite
+}
+
+func (suite *PartitionTestSuite) Setup(
+	options api.PartitionOptions,
+) *context.Context {
+	ctx, _ := setup.New(
+		TestFeature,
+		api.Feature()).
+		Specs(&Ledger{}).
+		Options(options).
+		Context()
+	return ctx
+}
+
+func (suite *PartitionTestSuite) entries() []any {
+	return []any{
+		PostEntry{"A", 1, 20 * time.Millisecond, false},
+		PostEntry{"B", 1, 20 * time.Millisecond, false},
+		PostEntry{"A", 2, 10 * time.Millisecond, false},
+		PostEntry{"B", 2, 10 * time.Millisecond, false},
+		PostEntry{"A", 3, 0, false},
+		PostEntry{"B", 3, 0, false},
+	}
+}
+
+func (suite *PartitionTestSuite) TestPartition() {
+	suite.Run("Key", func() {
+		key, ok := api.PartitionKey(PostEntry{Account: "A"})
+		suite.True(ok)
+		suite.Equal("A", key)
+		key, ok = api.PartitionKey(&PostEntry{Account: "B"})
+		suite.True(ok)
+		suite.Equal("B", key)
+		key, ok = api.PartitionKey(Transfer{From: "C"})
+		suite.True(ok)
+		suite.Equal("C", key)
+		_, ok = api.PartitionKey(GetStockQuote{"GOOGL"})
+		suite.False(ok)
+		_, ok = api.PartitionKey(12)
+		suite.False(ok)
+	})
+
+	suite.Run("Batch", func() {
+		suite.Run("Ordered", func() {
+			handler := suite.Setup(api.PartitionOptions{})
+			ledger, _, _, _ := provides.Type[*Ledger](handler)
+			results, err := api.Ordered(handler, suite.entries()...).Await()
+			suite.Nil(err)
+			suite.Len(results, 6)
+			for i, entry := range suite.entries() {
+				e := entry.(PostEntry)
+				suite.Equal(api.Success[any](Receipt{e.Account, e.Seq}), results[i])
+			}
+			suite.Equal([]int{1, 2, 3}, ledger.Entries("A"))
+			suite.Equal([]int{1, 2, 3}, ledger.Entries("B"))
+			suite.Equal(2, ledger.Peak())
+		})
+
+		suite.Run("Unkeyed", func() {
+			handler := suite.Setup(api.PartitionOptions{})
+			results, err := api.Ordered(handler,
+				GetStockQuote{"GOOGL"}, Transfer{"A", 1}, GetStockQuote{"APPL"}).Await()
+			suite.Nil(err)
+			suite.Len(results, 3)
+			suite.Equal(api.Success[any](Receipt{"A", 1}), results[1])
+		})
+
+		suite.Run("Concurrency", func() {
+			handler := suite.Setup(api.PartitionOptions{})
+			ledger, _, _, _ := provides.Type[*Ledger](handler)
+			r, pr, err := api.Send[api.ScheduledResult](handler, api.PartitionedBatch{
+				Requests:    suite.entries(),
+				Concurrency: 1,
+			})
+			suite.Nil(err)
+			if pr != nil {
+				r, err = pr.Await()
+				suite.Nil(err)
+			}
+			suite.Len(r.Responses, 6)
+			suite.Equal(1, ledger.Peak())
+			suite.Equal([]int{1, 2, 3}, ledger.Entries("A"))
+		})
+
+		suite.Run("Failure", func() {
+			handler := suite.Setup(api.PartitionOptions{})
+			ledger, _, _, _ := provides.Type[*Ledger](handler)
+			results, err := api.Ordered(handler,
+				PostEntry{"A", 1, 0, true},
+				PostEntry{"B", 1, 0, false},
+				PostEntry{"A", 2, 0, false},
+			).Await()
+			suite.Nil(err)
+			either.Fold(results[0],
+				func(err error) any {
+					suite.Equal("entry 1 rejected", err.Error())
+					return nil
+				},
+				func(any) any {
+					suite.Fail("expected failure")
+					return nil
+				})
+			suite.Equal(api.Success[any](Receipt{"B", 1}), results[1])
+			either.Fold(results[2],
+				func(err error) any {
+					var canceled *miruken.CanceledError
+					suite.ErrorAs(err, &canceled)
+					return nil
+				},
+				func(any) any {
+					suite.Fail("expected failure")
+					return nil
+				})
+			suite.Equal([]int{1}, ledger.Entries("A"))
+		})
+	})
+
+	suite.Run("Partitioner", func() {
+		suite.Run("Ordered", func() {
+			handler := suite.Setup(api.PartitionOptions{})
+			defer handler.End(nil)
+			ledger, _, _, _ := provides.Type[*Ledger](handler)
+			partitioner, _, _, _ := provides.Type[*api.Partitioner](handler)
+			var results []any
+			for seq := 1; seq <= 4; seq++ {
+				for _, account := range []string{"A", "B"} {
+					r, err := partitioner.Send(handler, Transfer{account, seq}).Await()
+					suite.Nil(err)
+					results = append(results, r)
+				}
+			}
+			suite.Len(results, 8)
+			suite.Equal([]int{1, 2, 3, 4}, ledger.Entries("A"))
+			suite.Equal([]int{1, 2, 3, 4}, ledger.Entries("B"))
+		})
+
+		suite.Run("Concurrent", func() {
+			handler := suite.Setup(api.PartitionOptions{Concurrency: 2})
+			defer handler.End(nil)
+			ledger, _, _, _ := provides.Type[*Ledger](handler)
+			partitioner, _, _, _ := provides.Type[*api.Partitioner](handler)
+			var pending []func() (any, error)
+			for seq := 1; seq <= 4; seq++ {
+				for _, account := range []string{"A", "B", "C"} {
+					pending = append(pending, partitioner.Send(handler, Transfer{account, seq}).Await)
+				}
+			}
+			for _, await := range pending {
+				_, err := await()
+				suite.Nil(err)
+			}
+			suite.Equal([]int{1, 2, 3, 4}, ledger.Entries("A"))
+			suite.Equal([]int{1, 2, 3, 4}, ledger.Entries("B"))
+			suite.Equal([]int{1, 2, 3, 4}, ledger.Entries("C"))
+			suite.LessOrEqual(ledger.Peak(), 2)
+		})
+
+		suite.Run("Failure", func() {
+			handler := suite.Setup(api.PartitionOptions{})
+			defer handler.End(nil)
+			partitioner, _, _, _ := provides.Type[*api.Partitioner](handler)
+			p1 := partitioner.Send(handler, PostEntry{"A", 1, 0, true})
+			p2 := partitioner.Send(handler, PostEntry{"A", 2, 0, false})
+			_, err := p1.Await()
+			suite.EqualError(err, "entry 1 rejected")
+			r, err := p2.Await()
+			suite.Nil(err)
+			suite.Equal(Receipt{"A", 2}, r)
+		})
+
+		suite.Run("Shutdown", func() {
+			handler := suite.Setup(api.PartitionOptions{})
+			ledger, _, _, _ := provides.Type[*Ledger](handler)
+			partitioner, _, _, _ := provides.Type[*api.Partitioner](handler)
+			p := partitioner.Send(handler, PostEntry{"A", 1, 20 * time.Millisecond, false})
+			handler.End(nil)
+			suite.Equal([]int{1}, ledger.Entries("A"))
+			_, err := p.Await()
+			suite.Nil(err)
+			_, err = partitioner.Send(handler, PostEntry{"A", 2, 0, false}).Await()
+			suite.True(errors.Is(err, api.ErrPartitionerClosed))
+		})
+	})
+}
+
+func TestPartitionTestSuite(t *testing.T) {
+	suite.Run(t, new(PartitionTestSuite))
+}
@@ -1,8 +1,11 @@
 package test
 
 import (
+	"fmt"
+	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/miruken-go/miruken"
 	"github.com/miruken-go/miruken/api"
@@ -21,6 +24,14 @@ type (
 	}
 
 	TrashHandler struct{}
+
+	BatchRecorder struct {
+		sizes   []int
+		tenants []string
+		lock    sync.Mutex
+	}
+
+	CoalesceHandler struct{}
 )
 
 func (t *Trash) Add(items ...any) {
@@ -46,6 +57,60 @@ func (t *TrashHandler) Trash(
 	return promise.Resolve[any](nil)
 }
 
+// BatchRecorder
+
+func (r *BatchRecorder) Constructor(
+	_ *struct {
+		provides.It
+		provides.Single
+	  },
+) {
+}
+
+func (r *BatchRecorder) Record(size int, tenant string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.sizes   = append(r.sizes, size)
+	r.tenants = append(r.tenants, tenant)
+}
+
+func (r *BatchRecorder) Sizes() []int {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]int(nil), r.sizes...)
+}
+
+func (r *BatchRecorder) Tenants() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]string(nil), r.tenants...)
+}
+
+// CoalesceHandler
+
+func (c *CoalesceHandler) Route(
+	_ *struct {
+		handles.It
+		api.Routes `scheme:"coalesce"`
+	  }, routed api.Routed,
+	recorder *BatchRecorder,
+	composer miruken.Handler,
+) (any, miruken.HandleResult) {
+	headers, _ := api.CurrentHeaders(composer)
+	if batch, ok := routed.Message.(api.ConcurrentBatch); ok {
+		recorder.Record(len(batch.Requests), headers.Tenant)
+	} else {
+		recorder.Record(0, headers.Tenant)
+	}
+	if r, pr, err := api.Send[any](composer, routed.Message); err != nil {
+		return nil, miruken.NotHandled.WithError(err)
+	} else if pr == nil {
+		return r, miruken.Handled
+	} else {
+		return pr, miruken.Handled
+	}
+}
+
 type RouteTestSuite struct {
 	suite.Suite
 }
@@ -59,6 +124,44 @@ func (suite *RouteTestSuite) Setup() *context.Context {
 	return ctx
 }
 
+func (suite *RouteTestSuite) SetupCoalesce(
+	routes map[string]api.Coalescing,
+) *context.Context {
+	ctx, _ := setup.New(
+		TestFeature,
+		api.Feature()).
+		Specs(&BatchRecorder{}).
+		Options(api.CoalesceOptions{Routes: routes}).
+		Context()
+	return ctx
+}
+
+func (suite *RouteTestSuite) sendQuotes(
+	handler miruken.Handler,
+	route   string,
+	symbols ...string,
+) []error {
+	errs := make([]error, len(symbols))
+	var wg sync.WaitGroup
+	wg.Add(len(symbols))
+	for i, symbol := range symbols {
+		go func(i int, symbol string) {
+			defer wg.Done()
+			_, pq, err := api.Send[StockQuote](handler,
+				api.RouteTo(GetStockQuote{symbol}, route))
+			if err == nil {
+				var quote StockQuote
+				if quote, err = pq.Await(); err == nil && quote.Symbol != symbol {
+					err = fmt.Errorf("expected quote %q but got %q", symbol, quote.Symbol)
+				}
+			}
+			errs[i] = err
+		}(i, symbol)
+	}
+	wg.Wait()
+	return errs
+}
+
 func (suite *RouteTestSuite) TestRoute() {
 	suite.Run("Route", func() {
 		suite.Run("Requests", func() {
@@ -177,6 +280,142 @@ func (suite *RouteTestSuite) TestRoute() {
 	})
 }
 
+func (suite *RouteTestSuite) TestCoalesce() {
+	suite.Run("Window", func() {
+		handler := suite.SetupCoalesce(map[string]api.Coalescing{
+			"coalesce": {Max: 50, Window: 100 * time.Millisecond},
+		})
+		recorder, _, _, _ := provides.Type[*BatchRecorder](handler)
+		errs := suite.sendQuotes(handler, "coalesce",
+			"GOOGL", "APPL", "MSFT", "AMZN", "NFLX")
+		for _, err := range errs {
+			suite.Nil(err)
+		}
+		suite.Equal([]int{5}, recorder.Sizes())
+	})
+
+	suite.Run("Max", func() {
+		handler := suite.SetupCoalesce(map[string]api.Coalescing{
+			"coalesce": {Max: 3, Window: time.Hour},
+		})
+		recorder, _, _, _ := provides.Type[*BatchRecorder](handler)
+		errs := suite.sendQuotes(handler, "coalesce",
+			"GOOGL", "APPL", "MSFT", "AMZN", "NFLX", "META")
+		for _, err := range errs {
+			suite.Nil(err)
+		}
+		suite.Equal([]int{3, 3}, recorder.Sizes())
+	})
+
+	suite.Run("Senders", func() {
+		handler := suite.SetupCoalesce(map[string]api.Coalescing{
+			"coalesce": {Max: 50, Window: 100 * time.Millisecond},
+		})
+		recorder, _, _, _ := provides.Type[*BatchRecorder](handler)
+		sender := func(tenant string) miruken.Handler {
+			stash := miruken.AddHandlers(handler, api.NewStash(false))
+			suite.Nil(api.StashPut(stash, api.Headers{Tenant: tenant}))
+			return stash
+		}
+		acme, globex := sender("acme"), sender("globex")
+		var wg sync.WaitGroup
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for _, err := range suite.sendQuotes(acme, "coalesce", "GOOGL", "APPL") {
+				suite.Nil(err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for _, err := range suite.sendQuotes(globex, "coalesce", "MSFT", "AMZN") {
+				suite.Nil(err)
+			}
+		}()
+		wg.Wait()
+		suite.Equal([]int{2, 2}, recorder.Sizes())
+		suite.ElementsMatch([]string{"acme", "globex"}, recorder.Tenants())
+	})
+
+	suite.Run("Explicit Headers", func() {
+		handler := suite.SetupCoalesce(map[string]api.Coalescing{
+			"coalesce": {Max: 50, Window: time.Hour},
+		})
+		recorder, _, _, _ := provides.Type[*BatchRecorder](handler)
+		_, pq, err := api.Send[StockQuote](handler, api.Message{
+			Payload: api.RouteTo(GetStockQuote{"GOOGL"}, "coalesce"),
+			Headers: api.Headers{Tenant: "initech"},
+		})
+		suite.Nil(err)
+		_, err = pq.Await()
+		suite.Nil(err)
+		suite.Equal([]int{0}, recorder.Sizes())
+		suite.Equal([]string{"initech"}, recorder.Tenants())
+	})
+
+	suite.Run("Failure", func() {
+		handler := suite.SetupCoalesce(map[string]api.Coalescing{
+			"coalesce": {Max: 2, Window: time.Hour},
+		})
+		errs := suite.sendQuotes(handler, "coalesce", "GOOGL", "EX")
+		failed := 0
+		for _, err := range errs {
+			if err != nil {
+				failed++
+				suite.Equal("stock exchange is down", err.Error())
+			}
+		}
+		suite.Equal(1, failed)
+	})
+
+	suite.Run("Disabled", func() {
+		handler := suite.SetupCoalesce(nil)
+		recorder, _, _, _ := provides.Type[*BatchRecorder](handler)
+		errs := suite.sendQuotes(handler, "coalesce", "GOOGL", "APPL")
+		for _, err := range errs {
+			suite.Nil(err)
+		}
+		suite.Equal([]int{0, 0}, recorder.Sizes())
+	})
+
+	suite.Run("Explicit Batch", func() {
+		handler := suite.SetupCoalesce(map[string]api.Coalescing{
+			"coalesce": {Max: 50, Window: time.Hour},
+		})
+		recorder, _, _, _ := provides.Type[*BatchRecorder](handler)
+		pb := miruken.BatchAsync(handler,
+			func(batch miruken.Handler) *promise.Promise[StockQuote] {
+				_, pq, err := api.Send[StockQuote](batch,
+					api.RouteTo(GetStockQuote{"GOOGL"}, "coalesce"))
+				suite.Nil(err)
+				return pq
+			})
+		_, err := pb.Await()
+		suite.Nil(err)
+		suite.Equal([]int{1}, recorder.Sizes())
+	})
+
+	suite.Run("Parse", func() {
+		coalescing, err := api.ParseCoalescing("max=20, window=10ms")
+		suite.Nil(err)
+		suite.Equal(api.Coalescing{Max: 20, Window: 10 * time.Millisecond}, coalescing)
+		suite.Equal("max=20,window=10ms", coalescing.String())
+
+		coalescing, err = api.ParseCoalescing("window=1s")
+		suite.Nil(err)
+		suite.Equal(api.Coalescing{Max: 50, Window: time.Second}, coalescing)
+
+		var text api.Coalescing
+		suite.Nil(text.UnmarshalText([]byte("max=5")))
+		suite.Equal(api.Coalescing{Max: 5, Window: 5 * time.Millisecond}, text)
+
+		for _, spec := range []string{"max=0", "window=-1s", "max", "size=3"} {
+			_, err = api.ParseCoalescing(spec)
+			suite.NotNil(err, spec)
+		}
+	})
+}
+
 func TestRouteTestSuite(t *testing.T) {
 	suite.Run(t, new(RouteTestSuite))
 }
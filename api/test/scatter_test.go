@@ -0,0 +1,300 @@
+package test
+
+import (
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/context"
+	"github.com/miruken-go/miruken/either"
+	"github.com/miruken-go/miruken/handles"
+	"github.com/miruken-go/miruken/promise"
+	"github.com/miruken-go/miruken/setup"
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	GetVersion struct{}
+
+	Version struct {
+		Number string
+	}
+
+	LocateRegion struct{}
+
+	PingRegion struct {
+		Delay time.Duration
+	}
+
+	Region struct {
+		Name string
+	}
+
+	ReplicaHandler struct{}
+
+	EastRegionHandler struct{}
+
+	WestRegionHandler struct{}
+
+	NorthRegionHandler struct{}
+)
+
+// ReplicaHandler
+
+// Route simulates a replica described by the route such as
+// replica://a?version=1&delay=10ms&fail=true
+func (r *ReplicaHandler) Route(
+	_ *struct {
+		handles.It
+		api.Routes `scheme:"replica"`
+	  }, routed api.Routed,
+) *promise.Promise[any] {
+	return promise.New(nil, func(resolve func(any), reject func(error), onCancel func(func())) {
+		u, err := url.Parse(routed.Route)
+		if err != nil {
+			reject(err)
+			return
+		}
+		query := u.Query()
+		if delay, err := time.ParseDuration(query.Get("delay")); err == nil {
+			time.Sleep(delay)
+		}
+		if query.Has("fail") {
+			reject(errors.New("replica " + u.Host + " unavailable"))
+			return
+		}
+		resolve(Version{query.Get("version")})
+	})
+}
+
+// EastRegionHandler
+
+func (h *EastRegionHandler) Locate(
+	_ *handles.It, _ LocateRegion,
+) Region {
+	return Region{"east"}
+}
+
+func (h *EastRegionHandler) Ping(
+	_ *handles.It, _ PingRegion,
+) Region {
+	return Region{"east"}
+}
+
+// WestRegionHandler
+
+func (h *WestRegionHandler) Locate(
+	_ *handles.It, _ LocateRegion,
+) *promise.Promise[Region] {
+	return promise.Resolve(Region{"west"})
+}
+
+func (h *WestRegionHandler) Ping(
+	_ *handles.It, ping PingRegion,
+) *promise.Promise[Region] {
+	return promise.New(nil, func(resolve func(Region), reject func(error), onCancel func(func())) {
+		time.Sleep(ping.Delay)
+		resolve(Region{"west"})
+	})
+}
+
+// NorthRegionHandler
+
+func (h *NorthRegionHandler) Locate(
+	_ *handles.It, _ LocateRegion,
+) (Region, error) {
+	return Region{}, errors.New("north unavailable")
+}
+
+type ScatterTestSuite struct {
+	suite.Suite
+}
+
+func (suite *ScatterTestSuite) Setup() *context.Context {
+	ctx, _ := setup.New(
+		TestFeature,
+		api.Feature(),
+	).Context()
+	return ctx
+}
+
+func (suite *ScatterTestSuite) failure(
+	response either.Monad[error, any],
+) error {
+	return either.Fold(response,
+		func(err error) error { return err },
+		func(any) error { return nil })
+}
+
+func (suite *ScatterTestSuite) TestScatterGather() {
+	suite.Run("First", func() {
+		handler := suite.Setup()
+		start := time.Now()
+		result, err := api.Gather(handler, api.ScatterGather{
+			Request: GetVersion{},
+			Routes: []string{
+				"replica://a?version=1&delay=500ms",
+				"replica://b?version=2",
+				"replica://c?fail",
+			},
+			Strategy: api.AggregateFirst,
+		}).Await()
+		suite.Nil(err)
+		suite.Less(time.Since(start), 500*time.Millisecond)
+		suite.Equal(Version{"2"}, result.Result)
+		suite.Len(result.Responses, 3)
+		suite.Equal("replica://a?version=1&delay=500ms", result.Targets[0])
+		var canceled *miruken.CanceledError
+		suite.ErrorAs(suite.failure(result.Responses[0]), &canceled)
+		suite.Equal(api.Success[any](Version{"2"}), result.Responses[1])
+	})
+
+	suite.Run("All Successful", func() {
+		handler := suite.Setup()
+		result, err := api.Gather(handler, api.ScatterGather{
+			Request: GetVersion{},
+			Routes: []string{
+				"replica://a?version=1",
+				"replica://b?fail",
+				"replica://c?version=2&delay=10ms",
+			},
+		}).Await()
+		suite.Nil(err)
+		suite.Equal([]any{Version{"1"}, Version{"2"}}, result.Result)
+		suite.EqualError(suite.failure(result.Responses[1]), "replica b unavailable")
+	})
+
+	suite.Run("Majority", func() {
+		handler := suite.Setup()
+		start := time.Now()
+		result, err := api.Gather(handler, api.ScatterGather{
+			Request: GetVersion{},
+			Routes: []string{
+				"replica://a?version=1",
+				"replica://b?version=2",
+				"replica://c?version=1&delay=20ms",
+				"replica://d?version=2&delay=500ms",
+				"replica://e?fail",
+			},
+			Strategy: api.AggregateMajority,
+			Quorum:   2,
+		}).Await()
+		suite.Nil(err)
+		suite.Less(time.Since(start), 500*time.Millisecond)
+		suite.Equal(Version{"1"}, result.Result)
+	})
+
+	suite.Run("Majority Not Reached", func() {
+		handler := suite.Setup()
+		_, err := api.Gather(handler, api.ScatterGather{
+			Request: GetVersion{},
+			Routes: []string{
+				"replica://a?version=1",
+				"replica://b?version=2",
+				"replica://c?version=3",
+			},
+			Strategy: api.AggregateMajority,
+		}).Await()
+		var quorum *api.QuorumError
+		suite.ErrorAs(err, &quorum)
+		suite.Equal(2, quorum.Quorum)
+		suite.Equal(3, quorum.Successes)
+		suite.Equal(api.AggregateMajority, quorum.Strategy)
+	})
+
+	suite.Run("Quorum", func() {
+		handler := suite.Setup()
+		_, err := api.Gather(handler, api.ScatterGather{
+			Request: GetVersion{},
+			Routes: []string{
+				"replica://a?fail&delay=10ms",
+				"replica://b?version=1",
+				"replica://c?fail&delay=20ms",
+			},
+			Strategy: api.AggregateFirst,
+			Quorum:   2,
+		}).Await()
+		var quorum *api.QuorumError
+		suite.ErrorAs(err, &quorum)
+		suite.Equal(1, quorum.Successes)
+		suite.Len(quorum.Result.Responses, 3)
+	})
+
+	suite.Run("Timeout", func() {
+		handler := suite.Setup()
+		start := time.Now()
+		result, err := api.Gather(handler, api.ScatterGather{
+			Request: GetVersion{},
+			Routes: []string{
+				"replica://a?version=1",
+				"replica://b?version=2&delay=1s",
+			},
+			Timeout: 50 * time.Millisecond,
+		}).Await()
+		suite.Nil(err)
+		suite.Less(time.Since(start), time.Second)
+		suite.Equal([]any{Version{"1"}}, result.Result)
+		suite.ErrorIs(suite.failure(result.Responses[1]), api.ErrGatherTimeout)
+	})
+
+	suite.Run("Local", func() {
+		handler := suite.Setup()
+		result, err := api.Gather(handler, api.ScatterGather{
+			Request: LocateRegion{},
+		}).Await()
+		suite.Nil(err)
+		suite.ElementsMatch([]any{Region{"east"}, Region{"west"}}, result.Result)
+		suite.Len(result.Responses, 3)
+		suite.ElementsMatch([]string{
+			"*test.EastRegionHandler",
+			"*test.WestRegionHandler",
+			"*test.NorthRegionHandler",
+		}, result.Targets)
+		for i, target := range result.Targets {
+			if target == "*test.NorthRegionHandler" {
+				suite.EqualError(suite.failure(result.Responses[i]), "north unavailable")
+			}
+		}
+	})
+
+	suite.Run("Local First", func() {
+		handler := suite.Setup()
+		start := time.Now()
+		result, err := api.Gather(handler, api.ScatterGather{
+			Request:  PingRegion{500 * time.Millisecond},
+			Strategy: api.AggregateFirst,
+		}).Await()
+		suite.Nil(err)
+		suite.Less(time.Since(start), 500*time.Millisecond)
+		suite.Equal(Region{"east"}, result.Result)
+		suite.Len(result.Responses, 2)
+	})
+
+	suite.Run("Local Timeout", func() {
+		handler := suite.Setup()
+		start := time.Now()
+		result, err := api.Gather(handler, api.ScatterGather{
+			Request: PingRegion{time.Second},
+			Timeout: 50 * time.Millisecond,
+		}).Await()
+		suite.Nil(err)
+		suite.Less(time.Since(start), time.Second)
+		suite.Equal([]any{Region{"east"}}, result.Result)
+		suite.Len(result.Responses, 2)
+	})
+
+	suite.Run("Local Unhandled", func() {
+		handler := suite.Setup()
+		_, err := api.Gather(handler, api.ScatterGather{
+			Request: GetVersion{},
+		}).Await()
+		suite.NotNil(err)
+	})
+}
+
+func TestScatterTestSuite(t *testing.T) {
+	suite.Run(t, new(ScatterTestSuite))
+}
@@ -2,14 +2,18 @@ package test
 
 import (
 	"errors"
+	"fmt"
 	"math/rand"
 	"testing"
+	"time"
 
+	"github.com/miruken-go/miruken"
 	"github.com/miruken-go/miruken/api"
 	"github.com/miruken-go/miruken/context"
 	"github.com/miruken-go/miruken/either"
 	"github.com/miruken-go/miruken/handles"
 	"github.com/miruken-go/miruken/promise"
+	"github.com/miruken-go/miruken/provides"
 	"github.com/miruken-go/miruken/setup"
 	"github.com/stretchr/testify/suite"
 )
@@ -63,8 +67,9 @@ type ScheduleTestSuite struct {
 func (suite *ScheduleTestSuite) Setup() *context.Context {
 	ctx, _ := setup.New(
 		TestFeature,
-		api.Feature(),
-	).Context()
+		api.Feature()).
+		Specs(&Ledger{}).
+		Context()
 	return ctx
 }
 
@@ -231,9 +236,138 @@ func (suite *ScheduleTestSuite) TestSchedule() {
 			}
 			suite.Equal([]string{"APPL", "stock exchange is down", "stock exchange is down"}, symbols)
 		})
+
+		suite.Run("Max Parallelism", func() {
+			handler := suite.Setup()
+			ledger, _, _, _ := provides.Type[*Ledger](handler)
+			requests := make([]any, 6)
+			for i := range requests {
+				requests[i] = PostEntry{fmt.Sprint(i), i, 10 * time.Millisecond, false}
+			}
+			r, pr, err := api.Send[api.ScheduledResult](handler, api.ConcurrentBatch{
+				Requests:       requests,
+				MaxParallelism: 2,
+			})
+			suite.Nil(err)
+			r, err = pr.Await()
+			suite.Nil(err)
+			suite.Len(r.Responses, 6)
+			for i, response := range r.Responses {
+				suite.Equal(api.Success[any](Receipt{fmt.Sprint(i), i}), response)
+			}
+			suite.Equal(2, ledger.Peak())
+		})
+
+		suite.Run("Fail Fast", func() {
+			handler := suite.Setup()
+			start := time.Now()
+			r, pr, err := api.Send[api.ScheduledResult](handler, api.ConcurrentBatch{
+				Requests: []any{
+					api.RouteTo(GetVersion{}, "replica://a?version=1&delay=500ms"),
+					api.RouteTo(GetVersion{}, "replica://b?fail&delay=10ms"),
+					api.RouteTo(GetVersion{}, "replica://c?version=2"),
+				},
+				FailFast: true,
+			})
+			suite.Nil(err)
+			r, err = pr.Await()
+			suite.Nil(err)
+			suite.Less(time.Since(start), 500*time.Millisecond)
+			suite.Len(r.Responses, 3)
+			var canceled *miruken.CanceledError
+			suite.ErrorAs(suite.failure(r.Responses[0]), &canceled)
+			suite.EqualError(canceled.Cause, "replica b unavailable")
+			suite.EqualError(suite.failure(r.Responses[1]), "replica b unavailable")
+			suite.Equal(api.Success[any](Version{"2"}), r.Responses[2])
+		})
+
+		suite.Run("Fail Fast Pending", func() {
+			handler := suite.Setup()
+			r, pr, err := api.Send[api.ScheduledResult](handler, api.ConcurrentBatch{
+				Requests: []any{
+					GetStockQuote{"EX"},
+					GetStockQuote{"APPL"},
+					GetStockQuote{"GOOGL"},
+				},
+				MaxParallelism: 1,
+				FailFast:       true,
+			})
+			suite.Nil(err)
+			r, err = pr.Await()
+			suite.Nil(err)
+			suite.Len(r.Responses, 3)
+			suite.EqualError(suite.failure(r.Responses[0]), "stock exchange is down")
+			for _, response := range r.Responses[1:] {
+				var canceled *miruken.CanceledError
+				suite.ErrorAs(suite.failure(response), &canceled)
+			}
+		})
+
+		suite.Run("Timeout", func() {
+			handler := suite.Setup()
+			start := time.Now()
+			r, pr, err := api.Send[api.ScheduledResult](handler, api.ConcurrentBatch{
+				Requests: []any{
+					api.RouteTo(GetVersion{}, "replica://a?version=1&delay=500ms"),
+					api.RouteTo(GetVersion{}, "replica://b?version=2"),
+				},
+				Timeout: 50 * time.Millisecond,
+			})
+			suite.Nil(err)
+			r, err = pr.Await()
+			suite.Nil(err)
+			suite.Less(time.Since(start), 500*time.Millisecond)
+			suite.ErrorIs(suite.failure(r.Responses[0]), api.ErrRequestTimeout)
+			suite.Equal(api.Success[any](Version{"2"}), r.Responses[1])
+		})
+
+		suite.Run("Timeout Blocking", func() {
+			handler := suite.Setup()
+			start := time.Now()
+			r, pr, err := api.Send[api.ScheduledResult](handler, api.ConcurrentBatch{
+				Requests: []any{
+					PostEntry{"a", 1, 500 * time.Millisecond, false},
+					PostEntry{"b", 2, 0, false},
+				},
+				Timeout: 50 * time.Millisecond,
+			})
+			suite.Nil(err)
+			r, err = pr.Await()
+			suite.Nil(err)
+			suite.Less(time.Since(start), 500*time.Millisecond)
+			suite.ErrorIs(suite.failure(r.Responses[0]), api.ErrRequestTimeout)
+			suite.Equal(api.Success[any](Receipt{"b", 2}), r.Responses[1])
+		})
+
+		suite.Run("Fail Fast Blocking", func() {
+			handler := suite.Setup()
+			start := time.Now()
+			r, pr, err := api.Send[api.ScheduledResult](handler, api.ConcurrentBatch{
+				Requests: []any{
+					PostEntry{"a", 1, 500 * time.Millisecond, false},
+					PostEntry{"b", 2, 10 * time.Millisecond, true},
+				},
+				FailFast: true,
+			})
+			suite.Nil(err)
+			r, err = pr.Await()
+			suite.Nil(err)
+			suite.Less(time.Since(start), 500*time.Millisecond)
+			var canceled *miruken.CanceledError
+			suite.ErrorAs(suite.failure(r.Responses[0]), &canceled)
+			suite.EqualError(suite.failure(r.Responses[1]), "entry 2 rejected")
+		})
 	})
 }
 
+func (suite *ScheduleTestSuite) failure(
+	response either.Monad[error, any],
+) error {
+	return either.Fold(response,
+		func(err error) error { return err },
+		func(any) error { return nil })
+}
+
 func TestScheduleTestSuite(t *testing.T) {
 	suite.Run(t, new(ScheduleTestSuite))
 }
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"time"
+
+	"github.com/miruken-go/miruken/internal"
+)
+
+type (
+	// Timer requests delivery of a message at a future time
+	// or on a recurring cron schedule.  Sending a Timer returns
+	// the id used to cancel it.
+	Timer struct {
+		Message any
+		At      time.Time
+		Cron    string
+		Publish bool
+	}
+
+	// CancelTimer cancels a pending Timer by id.
+	CancelTimer struct {
+		Id string
+	}
+)
+
+// Schedule creates a Timer to post the message at a time.
+func Schedule(message any, at time.Time) Timer {
+	if internal.IsNil(message) {
+		panic("message cannot be nil")
+	}
+	return Timer{Message: message, At: at}
+}
+
+// Recur creates a Timer to post the message on a cron schedule.
+func Recur(message any, cron string) Timer {
+	if internal.IsNil(message) {
+		panic("message cannot be nil")
+	}
+	if cron == "" {
+		panic("cron cannot be empty")
+	}
+	return Timer{Message: message, Cron: cron}
+}
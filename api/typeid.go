@@ -0,0 +1,255 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/creates"
+	"github.com/miruken-go/miruken/handles"
+	"github.com/miruken-go/miruken/maps"
+	"github.com/miruken-go/miruken/promise"
+)
+
+type (
+	// TypeIdentifier is implemented by types declaring a stable
+	// type id to use as the discriminator of polymorphic messages.
+	// Alternatively, a struct field can be tagged with
+	//
+	//	_ struct{} `typeId:"orders.PlaceOrder"`
+	TypeIdentifier interface {
+		TypeId() string
+	}
+
+	// TypeRegistry maps stable type ids to types.
+	// Types declaring a type id are registered when handlers
+	// accepting or returning them are discovered.
+	// Duplicate type ids fail the startup of the application.
+	TypeRegistry struct {
+		ids   map[reflect.Type]string
+		types map[string]reflect.Type
+		err   error
+		lock  sync.RWMutex
+	}
+
+	// typeFactory creates the types registered in a TypeRegistry.
+	typeFactory struct {
+		registry *TypeRegistry
+	}
+
+	// DuplicateTypeIdError reports a type id assigned to more than one type.
+	DuplicateTypeIdError struct {
+		TypeId   string
+		Existing reflect.Type
+		Type     reflect.Type
+	}
+)
+
+var ErrMissingTypeId = errors.New("api: type does not declare a type id")
+
+// DuplicateTypeIdError
+
+func (e *DuplicateTypeIdError) Error() string {
+	return fmt.Sprintf("api: type id %q of %v already assigned to %v",
+		e.TypeId, e.Type, e.Existing)
+}
+
+// TypeRegistry
+
+// Register assigns a type id to a type.
+// Returns a DuplicateTypeIdError if the type id or type is
+// already registered differently.
+func (r *TypeRegistry) Register(
+	typeId string,
+	typ    reflect.Type,
+) error {
+	if typeId == "" {
+		panic("typeId cannot be empty")
+	}
+	if typ == nil {
+		panic("typ cannot be nil")
+	}
+	typ = indirectType(typ)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if existing, ok := r.types[typeId]; ok {
+		if existing == typ {
+			return nil
+		}
+		return &DuplicateTypeIdError{typeId, existing, typ}
+	}
+	if id, ok := r.ids[typ]; ok {
+		return &DuplicateTypeIdError{id, typ, typ}
+	}
+	if r.types == nil {
+		r.types = make(map[string]reflect.Type)
+		r.ids   = make(map[reflect.Type]string)
+	}
+	r.types[typeId] = typ
+	r.ids[typ]      = typeId
+	return nil
+}
+
+// RegisterType registers a type using its declared type id.
+// Returns ErrMissingTypeId if the type does not declare one.
+func (r *TypeRegistry) RegisterType(typ reflect.Type) error {
+	if id, ok := DeclaredTypeId(typ); ok {
+		return r.Register(id, typ)
+	}
+	return fmt.Errorf("%w: %v", ErrMissingTypeId, typ)
+}
+
+// TypeId returns the type id of a type if registered or declared.
+func (r *TypeRegistry) TypeId(typ reflect.Type) (string, bool) {
+	typ = indirectType(typ)
+	r.lock.RLock()
+	id, ok := r.ids[typ]
+	r.lock.RUnlock()
+	if ok {
+		return id, true
+	}
+	return DeclaredTypeId(typ)
+}
+
+// Type returns the type registered for a type id.
+func (r *TypeRegistry) Type(typeId string) (reflect.Type, bool) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	typ, ok := r.types[typeId]
+	return typ, ok
+}
+
+func (r *TypeRegistry) BindingCreated(
+	policy      miruken.Policy,
+	handlerInfo *miruken.HandlerInfo,
+	binding     miruken.Binding,
+) {
+	if policy == mapsPolicy {
+		discoverUpcast(binding)
+		return
+	}
+	if policy != handlesPolicy {
+		return
+	}
+	if in, ok := binding.Key().(reflect.Type); ok {
+		r.discover(in)
+	}
+	if out := binding.LogicalOutputType(); out != nil {
+		r.discover(out)
+	}
+}
+
+func (r *TypeRegistry) HandlerInfoCreated(
+	*miruken.HandlerInfo,
+) {
+}
+
+// Startup fails if any duplicate type ids were discovered.
+func (r *TypeRegistry) Startup(
+	ctx context.Context,
+	h   miruken.Handler,
+) *promise.Promise[struct{}] {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	if err := r.err; err != nil {
+		return promise.RejectEmpty(err)
+	}
+	return promise.Empty()
+}
+
+func (r *TypeRegistry) Shutdown(
+	ctx context.Context,
+) *promise.Promise[struct{}] {
+	return promise.Empty()
+}
+
+// discover registers a type declaring a type id
+// and records any duplicates to report at startup.
+func (r *TypeRegistry) discover(typ reflect.Type) {
+	if typ.Kind() == reflect.Slice {
+		typ = typ.Elem()
+	}
+	if id, ok := DeclaredTypeId(typ); ok {
+		if err := r.Register(id, typ); err != nil {
+			r.lock.Lock()
+			r.err = errors.Join(r.err, err)
+			r.lock.Unlock()
+		}
+	}
+}
+
+// typeFactory
+
+func (f *typeFactory) Constructor(
+	registry *TypeRegistry,
+) {
+	f.registry = registry
+}
+
+func (f *typeFactory) New(
+	_ *struct{ creates.Strict }, create *creates.It,
+) any {
+	if key, ok := create.Key().(string); ok {
+		if typ, ok := f.registry.Type(key); ok {
+			return reflect.New(typ).Interface()
+		}
+	}
+	return nil
+}
+
+// DeclaredTypeId returns the type id declared by a type through
+// the TypeIdentifier interface or a typeId struct tag.
+// The version suffix of Versioned types is included.
+func DeclaredTypeId(typ reflect.Type) (string, bool) {
+	typ = indirectType(typ)
+	if id, ok := declaredTypeIds.Load(typ); ok {
+		return id.(string), id.(string) != ""
+	}
+	var id string
+	if typ.Kind() != reflect.Interface {
+		value := reflect.New(typ).Interface()
+		if ti, ok := value.(TypeIdentifier); ok {
+			id = ti.TypeId()
+		} else if typ.Kind() == reflect.Struct {
+			for i := range typ.NumField() {
+				if tag, ok := typ.Field(i).Tag.Lookup("typeId"); ok && tag != "" {
+					id = tag
+					break
+				}
+			}
+		}
+		if id != "" {
+			id = VersionTypeId(id, TypeVersion(value))
+		}
+	}
+	declaredTypeIds.Store(typ, id)
+	return id, id != ""
+}
+
+// registeredTypeId returns the type id of a type from the
+// registry, if available, or the type declaration.
+func registeredTypeId(
+	registry *TypeRegistry,
+	typ      reflect.Type,
+) (string, bool) {
+	if registry != nil {
+		return registry.TypeId(typ)
+	}
+	return DeclaredTypeId(typ)
+}
+
+func indirectType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+var (
+	declaredTypeIds sync.Map
+	handlesPolicy   = (&handles.It{}).Policy()
+	mapsPolicy      = (&maps.It{}).Policy()
+)
@@ -0,0 +1,242 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/internal"
+	"github.com/miruken-go/miruken/maps"
+)
+
+// Versioned is implemented by messages with an evolving contract.
+// Versions greater than 1 are included in the type id as a
+// suffix such as orders.PlaceOrder@v2.  A trailing V<n> in the
+// Go type name matching the version is not part of the type id
+// so previous versions can be kept as separate types.
+//
+//	type PlaceOrderV1 struct { ... }              // orders.PlaceOrder
+//	func (PlaceOrderV1) TypeVersion() int { return 1 }
+//
+//	type PlaceOrder struct { ... }                // orders.PlaceOrder@v2
+//	func (PlaceOrder) TypeVersion() int { return 2 }
+//
+// Upcasters are maps bindings to the ToUpcast format that convert
+// version N to version N+1.  They are chained during decoding so
+// handlers only see the latest version.  Downcasters to the
+// ToDowncast format convert version N+1 to N and are applied
+// when encoding for peers restricted with Versions.
+type Versioned interface {
+	TypeVersion() int
+}
+
+var (
+	// ToUpcast maps a message to its next version.
+	ToUpcast = maps.To("api:upcast", nil)
+
+	// ToDowncast maps a message to its previous version.
+	ToDowncast = maps.To("api:downcast", nil)
+
+	ErrCastLimit = errors.New("api: exceeded maximum message version casts")
+)
+
+// maxCasts limits the length of an upcast or downcast chain.
+const maxCasts = 32
+
+// upcastTypes holds the types with upcasters discovered
+// by a TypeRegistry.
+var upcastTypes sync.Map
+
+// Versions returns a miruken.Builder restricting the message
+// versions encoded for a peer.  The keys are unversioned type
+// ids and the values are the highest versions understood.
+func Versions(versions map[string]int) miruken.Builder {
+	return miruken.Options(Options{Versions: versions})
+}
+
+// TypeVersion returns the version of a value.
+// Values that are not Versioned are version 1.
+func TypeVersion(value any) int {
+	if v, ok := value.(Versioned); ok {
+		if version := v.TypeVersion(); version > 1 {
+			return version
+		}
+	}
+	return 1
+}
+
+// VersionTypeId adds the version suffix to a type id.
+func VersionTypeId(typeId string, version int) string {
+	if version <= 1 {
+		return typeId
+	}
+	return typeId + "@v" + strconv.Itoa(version)
+}
+
+// ParseTypeId splits a type id into its name and version.
+// Type ids without a version suffix are version 1.
+func ParseTypeId(typeId string) (name string, version int) {
+	if i := strings.LastIndex(typeId, "@v"); i > 0 {
+		if v, err := strconv.Atoi(typeId[i+2:]); err == nil && v > 0 {
+			return typeId[:i], v
+		}
+	}
+	return typeId, 1
+}
+
+// Upcast chains the available upcasters to convert
+// a value to the latest version known to the handler.
+func Upcast(
+	handler miruken.Handler,
+	value   any,
+) (any, error) {
+	if internal.IsNil(handler) {
+		panic("handler cannot be nil")
+	}
+	for range maxCasts {
+		next, _, _, err := maps.Out[any](handler, value, ToUpcast)
+		if err != nil {
+			var nh *miruken.NotHandledError
+			if errors.As(err, &nh) {
+				return value, nil
+			}
+			return nil, fmt.Errorf("api: upcast %T: %w", value, err)
+		}
+		if internal.IsNil(next) {
+			return value, nil
+		}
+		value = next
+	}
+	return nil, ErrCastLimit
+}
+
+// AssignDecoded assigns a value decoded for a polymorphic type
+// id to the target.  Values assigned to a Late or any target are
+// upcast if the type id carried a version or upcasters for the
+// type were discovered.
+func AssignDecoded(
+	handler miruken.Handler,
+	typeId  string,
+	value   any,
+	target  any,
+) (err error) {
+	if late, ok := target.(*Late); ok {
+		late.Value, err = upcastDecoded(handler, typeId, value)
+		return
+	}
+	if _, ok := target.(*any); ok {
+		if value, err = upcastDecoded(handler, typeId, value); err != nil {
+			return
+		}
+	}
+	internal.CopyIndirect(value, target)
+	return nil
+}
+
+// Downcast chains the available downcasters to convert
+// a value to the requested version or lower.
+func Downcast(
+	handler miruken.Handler,
+	value   any,
+	version int,
+) (any, error) {
+	if internal.IsNil(handler) {
+		panic("handler cannot be nil")
+	}
+	for range maxCasts {
+		if TypeVersion(value) <= version {
+			return value, nil
+		}
+		prev, _, _, err := maps.Out[any](handler, value, ToDowncast)
+		if err != nil {
+			return nil, fmt.Errorf("api: downcast %T to v%d: %w", value, version, err)
+		}
+		if internal.IsNil(prev) {
+			return nil, fmt.Errorf("api: downcast %T to v%d: missing result", value, version)
+		}
+		value = prev
+	}
+	return nil, ErrCastLimit
+}
+
+// DowncastTo converts a Versioned value to the highest version
+// understood for its type id, if restricted by versions.  The
+// type id is obtained by mapping the value to the typeInfo format.
+func DowncastTo(
+	handler  miruken.Handler,
+	value    any,
+	versions map[string]int,
+	typeInfo *maps.Format,
+) (any, error) {
+	if _, ok := value.(Versioned); !ok || len(versions) == 0 {
+		return value, nil
+	}
+	info, _, _, err := maps.Out[TypeFieldInfo](handler, value, typeInfo)
+	if err != nil {
+		return nil, err
+	}
+	name, version := ParseTypeId(info.TypeValue)
+	if limit, ok := versions[name]; ok && limit < version {
+		return Downcast(handler, value, limit)
+	}
+	return value, nil
+}
+
+// upcastDecoded upcasts a decoded value only if it might
+// have a later version to avoid a mapping on every decode.
+func upcastDecoded(
+	handler miruken.Handler,
+	typeId  string,
+	value   any,
+) (any, error) {
+	if _, version := ParseTypeId(typeId); version > 1 {
+		return Upcast(handler, value)
+	}
+	if typ := reflect.TypeOf(value); typ != nil {
+		if _, ok := upcastTypes.Load(indirectType(typ)); ok {
+			return Upcast(handler, value)
+		}
+	}
+	return value, nil
+}
+
+// discoverUpcast records the source type of upcaster bindings.
+func discoverUpcast(binding miruken.Binding) {
+	key, ok := binding.Key().(miruken.DiKey)
+	if !ok {
+		return
+	}
+	typ, ok := key.In.(reflect.Type)
+	if !ok {
+		return
+	}
+	for _, provider := range binding.Filters() {
+		source, ok := provider.(miruken.ConstraintSource)
+		if !ok {
+			continue
+		}
+		for _, constraint := range source.Constraints() {
+			if format, ok := constraint.(*maps.Format); ok &&
+				format.Direction() == ToUpcast.Direction() &&
+				format.Name() == ToUpcast.Name() {
+				upcastTypes.Store(indirectType(typ), struct{}{})
+				return
+			}
+		}
+	}
+}
+
+// goTypeId strips a trailing version from a Go type name and
+// adds the version suffix of Versioned values.
+func goTypeId(typeName string, value any) string {
+	version := TypeVersion(value)
+	if _, ok := value.(Versioned); !ok {
+		return typeName
+	}
+	typeName = strings.TrimSuffix(typeName, "V"+strconv.Itoa(version))
+	return VersionTypeId(typeName, version)
+}
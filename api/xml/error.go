@@ -0,0 +1,58 @@
+package xml
+
+import (
+	"encoding/xml"
+	"errors"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api/json"
+	"github.com/miruken-go/miruken/maps"
+	"github.com/miruken-go/miruken/validates"
+)
+
+// Outcome is a surrogate for validates.Outcome over xml.
+// The fields of the json surrogate are reused.
+type Outcome struct {
+	XMLName xml.Name     `xml:"Outcome"`
+	Fields  json.Outcome `xml:"Field"`
+}
+
+func (s *Outcome) Original(composer miruken.Handler) (any, error) {
+	return s.Fields.Original(composer)
+}
+
+func (m *SurrogateMapper) ReplaceOutcome(
+	_ *struct {
+		maps.It
+		maps.Format `to:"/^(application|text)/xml$/"`
+	  }, outcome *validates.Outcome,
+	it  *maps.It,
+	ctx miruken.HandleContext,
+) ([]byte, error) {
+	sur := &Outcome{Fields: json.NewOutcome(outcome)}
+	byt, _, _, err := maps.Out[[]byte](ctx, sur, it.Format())
+	return byt, err
+}
+
+// Error is a surrogate for a generic error over xml.
+type Error struct {
+	XMLName xml.Name `xml:"Error"`
+	Message string   `xml:"Message"`
+}
+
+func (s *Error) Original(miruken.Handler) (any, error) {
+	return errors.New(s.Message), nil
+}
+
+func (m *SurrogateMapper) ReplaceError(
+	_ *struct {
+		maps.It
+		maps.Format `to:"/^(application|text)/xml$/"`
+	  }, err error,
+	it  *maps.It,
+	ctx miruken.HandleContext,
+) ([]byte, error) {
+	sur := &Error{Message: err.Error()}
+	byt, _, _, err := maps.Out[[]byte](ctx, sur, it.Format())
+	return byt, err
+}
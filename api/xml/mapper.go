@@ -0,0 +1,173 @@
+package xml
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/args"
+	"github.com/miruken-go/miruken/internal"
+	"github.com/miruken-go/miruken/maps"
+)
+
+type (
+	// Options provide options for controlling xml encoding.
+	Options struct {
+		Prefix        string
+		Indent        string
+		TypeAttribute string
+	}
+
+	// Mapper formats to and from xml using encoding/xml.
+	Mapper struct{}
+)
+
+// DefaultTypeAttribute is the attribute holding the
+// type discriminator of polymorphic elements.
+const DefaultTypeAttribute = "xsi:type"
+
+// TypeAttribute directs the xml encoding to emit type discriminators
+// using the named attribute instead of DefaultTypeAttribute.
+func TypeAttribute(name string) miruken.Builder {
+	return miruken.Options(Options{TypeAttribute: name})
+}
+
+func (m *Mapper) ToXml(
+	_ *struct {
+		maps.Format `to:"/^(application|text)/xml$/"`
+	  }, it *maps.It,
+	_ *struct {
+		args.Optional
+		args.FromOptions
+	  }, options Options,
+	_ *struct {
+		args.Optional
+		args.FromOptions
+	  }, apiOptions api.Options,
+	ctx miruken.HandleContext,
+) (x any, err error) {
+	switch t := it.Target().(type) {
+	case *[]byte:
+		return marshal(it, t, &options, &apiOptions, ctx.Composer)
+	case *io.Writer:
+		if internal.IsNil(*t) {
+			*t = new(bytes.Buffer)
+		}
+		if err = encode(it, *t, &options, &apiOptions, ctx.Composer); err == nil {
+			x = *t
+		}
+	}
+	return
+}
+
+func (m *Mapper) FromBytes(
+	_ *struct {
+		maps.Format `from:"/^(application|text)/xml$/"`
+	  }, byt []byte,
+	_ *struct {
+		args.Optional
+		args.FromOptions
+	  }, options Options,
+	_ *struct {
+		args.Optional
+		args.FromOptions
+	  }, apiOptions api.Options,
+	it  *maps.It,
+	ctx miruken.HandleContext,
+) (any, error) {
+	return decode(it, bytes.NewReader(byt), &options, &apiOptions, ctx.Composer)
+}
+
+func (m *Mapper) FromReader(
+	_ *struct {
+		maps.Format `from:"/^(application|text)/xml$/"`
+	  }, reader io.Reader,
+	_ *struct {
+		args.Optional
+		args.FromOptions
+	  }, options Options,
+	_ *struct {
+		args.Optional
+		args.FromOptions
+	  }, apiOptions api.Options,
+	it  *maps.It,
+	ctx miruken.HandleContext,
+) (any, error) {
+	return decode(it, reader, &options, &apiOptions, ctx.Composer)
+}
+
+func marshal(
+	it         *maps.It,
+	byt        *[]byte,
+	options    *Options,
+	apiOptions *api.Options,
+	composer   miruken.Handler,
+) ([]byte, error) {
+	var b bytes.Buffer
+	if err := encode(it, &b, options, apiOptions, composer); err != nil {
+		return nil, err
+	}
+	*byt = b.Bytes()
+	return *byt, nil
+}
+
+func encode(
+	it         *maps.It,
+	writer     io.Writer,
+	options    *Options,
+	apiOptions *api.Options,
+	composer   miruken.Handler,
+) error {
+	it.TargetForWrite()
+	enc := xml.NewEncoder(writer)
+	if prefix, indent := options.Prefix, options.Indent; prefix != "" || indent != "" {
+		enc.Indent(prefix, indent)
+	}
+	src := it.Source()
+	if apiOptions.Polymorphism == miruken.Set(api.PolymorphismRoot) {
+		tc := &typeContainer{
+			v:        src,
+			attr:     options.typeAttribute(),
+			typInfo:  apiOptions.TypeInfoFormat,
+			versions: apiOptions.Versions,
+			composer: composer,
+		}
+		if err := enc.EncodeElement(tc, rootElement(src, tc.attr)); err != nil {
+			return err
+		}
+	} else if err := enc.Encode(src); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func decode(
+	it         *maps.It,
+	reader     io.Reader,
+	options    *Options,
+	apiOptions *api.Options,
+	composer   miruken.Handler,
+) (target any, err error) {
+	target = it.TargetForWrite()
+	dec := xml.NewDecoder(reader)
+	if apiOptions.Polymorphism == miruken.Set(api.PolymorphismRoot) {
+		tc := typeContainer{
+			v:        target,
+			attr:     options.typeAttribute(),
+			composer: composer,
+		}
+		err = dec.Decode(&tc)
+	} else {
+		err = dec.Decode(target)
+	}
+	return
+}
+
+func (o *Options) typeAttribute() string {
+	if attr := o.TypeAttribute; attr != "" {
+		return attr
+	}
+	return DefaultTypeAttribute
+}
@@ -0,0 +1,148 @@
+package xml
+
+import (
+	"encoding/xml"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/maps"
+)
+
+type (
+	// MessageSurrogate is a xml surrogate for api.Message.
+	MessageSurrogate struct {
+		XMLName xml.Name `xml:"Message"`
+		Payload *Payload `xml:"Payload"`
+		Headers *Headers `xml:"Headers"`
+	}
+
+	// Payload holds the xml of the polymorphic message payload.
+	Payload struct {
+		Content []byte `xml:",innerxml"`
+	}
+
+	// Headers is a surrogate for api.Headers over xml.
+	Headers struct {
+		MessageId     string     `xml:"MessageId,omitempty"`
+		CorrelationId string     `xml:"CorrelationId,omitempty"`
+		CausationId   string     `xml:"CausationId,omitempty"`
+		Tenant        string     `xml:"Tenant,omitempty"`
+		SentAt        *time.Time `xml:"SentAt"`
+		Deadline      *time.Time `xml:"Deadline"`
+		Custom        []Header   `xml:"Header"`
+	}
+
+	// Header is a custom header of the message.
+	Header struct {
+		Name  string `xml:"name,attr"`
+		Value string `xml:",chardata"`
+	}
+)
+
+func (m *SurrogateMapper) EncodeMessage(
+	_ *struct {
+		maps.Format `to:"/^(application|text)/xml$/"`
+	  }, msg api.Message,
+	it  *maps.It,
+	ctx miruken.HandleContext,
+) (io.Writer, error) {
+	if writer, ok := it.Target().(*io.Writer); ok {
+		var sur MessageSurrogate
+		if payload := msg.Payload; payload != nil {
+			pb, _, _, err := maps.Out[[]byte](ctx.Composer, payload, it.Format())
+			if err != nil {
+				return nil, err
+			}
+			sur.Payload = &Payload{pb}
+		}
+		if headers := msg.Headers; !headers.IsZero() {
+			sur.Headers = encodeHeaders(headers)
+		}
+		if err := xml.NewEncoder(*writer).Encode(sur); err != nil {
+			return nil, err
+		}
+		it.TargetForWrite()
+		return *writer, nil
+	}
+	return nil, nil
+}
+
+func (m *SurrogateMapper) DecodeMessage(
+	_ *struct {
+		maps.It
+		maps.Format `from:"/^(application|text)/xml$/"`
+	  }, reader io.Reader,
+	it  *maps.It,
+	ctx miruken.HandleContext,
+) (msg api.Message, err error) {
+	if mp, ok := it.Target().(*api.Message); ok {
+		var sur MessageSurrogate
+		if err = xml.NewDecoder(reader).Decode(&sur); err != nil {
+			return
+		}
+		if headers := sur.Headers; headers != nil {
+			mp.Headers = headers.decode()
+		}
+		if payload := sur.Payload; payload != nil {
+			var late api.Late
+			composer := ctx.Composer
+			late, _, _, err = maps.Out[api.Late](composer, payload.Content, it.Format())
+			if err != nil {
+				return
+			}
+			if sur, ok := late.Value.(api.Surrogate); ok {
+				if late.Value, err = sur.Original(composer); err != nil {
+					return
+				}
+			}
+			it.TargetForWrite()
+			mp.Payload = late.Value
+			msg = *mp
+		}
+	}
+	return
+}
+
+func encodeHeaders(headers api.Headers) *Headers {
+	sur := &Headers{
+		MessageId:     headers.MessageId,
+		CorrelationId: headers.CorrelationId,
+		CausationId:   headers.CausationId,
+		Tenant:        headers.Tenant,
+	}
+	if sentAt := headers.SentAt; !sentAt.IsZero() {
+		sur.SentAt = &sentAt
+	}
+	if deadline := headers.Deadline; !deadline.IsZero() {
+		sur.Deadline = &deadline
+	}
+	for name, value := range headers.Custom {
+		sur.Custom = append(sur.Custom, Header{name, value})
+	}
+	sort.Slice(sur.Custom, func(i, j int) bool {
+		return sur.Custom[i].Name < sur.Custom[j].Name
+	})
+	return sur
+}
+
+func (h *Headers) decode() api.Headers {
+	headers := api.Headers{
+		MessageId:     h.MessageId,
+		CorrelationId: h.CorrelationId,
+		CausationId:   h.CausationId,
+		Tenant:        h.Tenant,
+	}
+	if h.SentAt != nil {
+		headers.SentAt = *h.SentAt
+	}
+	if h.Deadline != nil {
+		headers.Deadline = *h.Deadline
+	}
+	for _, header := range h.Custom {
+		headers.Set(header.Name, header.Value)
+	}
+	return headers
+}
@@ -0,0 +1,224 @@
+package xml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/creates"
+	"github.com/miruken-go/miruken/internal"
+	"github.com/miruken-go/miruken/maps"
+)
+
+type (
+	// typeContainer customizes xml standard serialization to
+	// emit type attribute information needed to support polymorphism.
+	typeContainer struct {
+		v        any
+		attr     string
+		typInfo  string
+		versions map[string]int
+		composer miruken.Handler
+	}
+)
+
+// xsiNamespace is the namespace of the xsi:type attribute.
+const xsiNamespace = "http://www.w3.org/2001/XMLSchema-instance"
+
+func (c *typeContainer) typeInfo() *maps.Format {
+	if typeInfo := c.typInfo; len(typeInfo) > 0 {
+		return maps.To(typeInfo, nil)
+	}
+	return api.ToTypeInfo
+}
+
+func (c *typeContainer) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	v := c.v
+	typ := reflect.TypeOf(v)
+	if typ == nil {
+		return nil
+	}
+	slice := isSlice(typ)
+	if !slice {
+		if versions := c.versions; len(versions) > 0 {
+			var err error
+			if v, err = api.DowncastTo(c.composer, v, versions, c.typeInfo()); err != nil {
+				return err
+			}
+		}
+		if indirectType(reflect.TypeOf(v)).Kind() != reflect.Struct {
+			return e.EncodeElement(v, start)
+		}
+	}
+	typeInfo, _, _, err := maps.Out[api.TypeFieldInfo](c.composer, v, c.typeInfo())
+	if err != nil {
+		return err
+	}
+	start.Attr = append(start.Attr, xml.Attr{
+		Name:  xml.Name{Local: c.attr},
+		Value: typeInfo.TypeValue,
+	})
+	if !slice {
+		return e.EncodeElement(v, start)
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	et := typ.Elem()
+	s := reflect.ValueOf(v)
+	for i := range s.Len() {
+		elem := s.Index(i).Interface()
+		item := xml.StartElement{Name: elementName(elem)}
+		if internal.IsAny(et) || reflect.TypeOf(elem) != et {
+			elem = &typeContainer{
+				v:        elem,
+				attr:     c.attr,
+				typInfo:  c.typInfo,
+				versions: c.versions,
+				composer: c.composer,
+			}
+		}
+		if err := e.EncodeElement(elem, item); err != nil {
+			return fmt.Errorf("can't marshal array index %d: %w", i, err)
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+func (c *typeContainer) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	typeId, ok := c.typeId(start)
+	if !ok {
+		if _, ok := c.v.(*api.Late); ok {
+			return fmt.Errorf("missing %q attribute for element %q", c.attr, start.Name.Local)
+		}
+		if isSlice(indirectType(reflect.TypeOf(c.v))) {
+			return c.decodeItems(d, c.v)
+		}
+		return d.DecodeElement(c.v, &start)
+	} else if typeId == "" {
+		return fmt.Errorf("empty type id for attribute %q", c.attr)
+	}
+	v, _, err := creates.Key[any](c.composer, typeId)
+	if err != nil {
+		return &api.UnknownTypeIdError{TypeId: typeId, Cause: err}
+	}
+	if isSlice(indirectType(reflect.TypeOf(v))) {
+		err = c.decodeItems(d, v)
+	} else {
+		err = d.DecodeElement(v, &start)
+	}
+	if err != nil {
+		return err
+	}
+	return api.AssignDecoded(c.composer, typeId, v, c.v)
+}
+
+// typeId returns the type discriminator of the element, if present.
+// The namespace prefix of the type attribute is matched loosely
+// since the decoder resolves it to the declared namespace.
+func (c *typeContainer) typeId(start xml.StartElement) (string, bool) {
+	prefix, local, ok := strings.Cut(c.attr, ":")
+	if !ok {
+		prefix, local = "", c.attr
+	}
+	for _, attr := range start.Attr {
+		if attr.Name.Local == local && (attr.Name.Space == "") == (prefix == "") {
+			return attr.Value, true
+		}
+	}
+	return "", false
+}
+
+// decodeItems decodes the child elements of the current
+// element into the slice referenced by target.  The slice is
+// replaced since targets may be shared prototypes.
+func (c *typeContainer) decodeItems(d *xml.Decoder, target any) error {
+	s := reflect.ValueOf(target)
+	if s.Kind() != reflect.Pointer {
+		return fmt.Errorf("can't unmarshal array into %T", target)
+	}
+	s = s.Elem()
+	s.Set(reflect.MakeSlice(s.Type(), 0, 0))
+	et := s.Type().Elem()
+	for i := 0; ; {
+		token, err := d.Token()
+		if err != nil {
+			return err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			elem := reflect.New(et)
+			tc := typeContainer{
+				v:        elem.Interface(),
+				attr:     c.attr,
+				typInfo:  c.typInfo,
+				versions: c.versions,
+				composer: c.composer,
+			}
+			if err := d.DecodeElement(&tc, &t); err != nil {
+				return fmt.Errorf("can't unmarshal array index %d: %w", i, err)
+			}
+			s.Set(reflect.Append(s, elem.Elem()))
+			i++
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// rootElement returns the outermost element of a polymorphic
+// value which declares the xsi namespace if needed.
+func rootElement(v any, attr string) xml.StartElement {
+	start := xml.StartElement{Name: elementName(v)}
+	if strings.HasPrefix(attr, "xsi:") {
+		start.Attr = []xml.Attr{{
+			Name:  xml.Name{Local: "xmlns:xsi"},
+			Value: xsiNamespace,
+		}}
+	}
+	return start
+}
+
+// elementName returns the name of the element for a value.
+// The XMLName field is honored, otherwise the type name is used.
+func elementName(v any) xml.Name {
+	typ := reflect.TypeOf(v)
+	if typ == nil {
+		return xml.Name{Local: "Value"}
+	}
+	if typ = indirectType(typ); typ.Kind() == reflect.Struct {
+		if field, ok := typ.FieldByName("XMLName"); ok && field.Type == xmlNameType {
+			if tag, _, _ := strings.Cut(field.Tag.Get("xml"), ","); tag != "" {
+				if space, local, ok := strings.Cut(tag, " "); ok {
+					return xml.Name{Space: space, Local: local}
+				}
+				return xml.Name{Local: tag}
+			}
+		}
+	}
+	if isSlice(typ) {
+		return xml.Name{Local: "Values"}
+	}
+	if name := typ.Name(); name != "" {
+		return xml.Name{Local: name}
+	}
+	return xml.Name{Local: "Value"}
+}
+
+// isSlice determines if the type is encoded as a list of elements.
+// Byte slices are excluded since they are encoded as character data.
+func isSlice(typ reflect.Type) bool {
+	return typ != nil && typ.Kind() == reflect.Slice && typ.Elem().Kind() != reflect.Uint8
+}
+
+func indirectType(typ reflect.Type) reflect.Type {
+	for typ != nil && typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ
+}
+
+var xmlNameType = reflect.TypeFor[xml.Name]()
@@ -0,0 +1,24 @@
+package xml
+
+import "github.com/miruken-go/miruken/creates"
+
+// SurrogateMapper maps concepts to values that are more suitable
+// for transmission over a xml api.  The replacement type usually
+// implements api.Surrogate to allow infrastructure to obtain the
+// original value using the Original() method.
+type SurrogateMapper struct{}
+
+func (m *SurrogateMapper) New(
+	_ *struct {
+		_ creates.It `key:"xml.Outcome"`
+		_ creates.It `key:"xml.Error"`
+	  }, create *creates.It,
+) any {
+	switch create.Key() {
+	case "xml.Outcome":
+		return new(Outcome)
+	case "xml.Error":
+		return new(Error)
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package test
+
+import (
+	"testing"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/api/apitest"
+	"github.com/miruken-go/miruken/api/xml"
+	"github.com/miruken-go/miruken/maps"
+	"github.com/miruken-go/miruken/setup"
+	"github.com/stretchr/testify/suite"
+)
+
+//go:generate $GOPATH/bin/miruken -tests
+
+type XmlTestSuite struct {
+	apitest.CodecSuite
+}
+
+func (suite *XmlTestSuite) TestXml() {
+	team := apitest.TeamData{
+		Id:   1,
+		Name: "Liverpool",
+		Players: []apitest.PlayerData{
+			{Id: 1, Name: "Mohamed Salah"},
+			{Id: 2, Name: "Virgil van Dijk"},
+		},
+	}
+
+	suite.Run("Encode", func() {
+		handler := suite.Setup()
+		byt, _, _, err := maps.Out[[]byte](handler, team, api.ToXml)
+		suite.Nil(err)
+		suite.Equal("<TeamData><Id>1</Id><Name>Liverpool</Name><Players><Id>1</Id><Name>Mohamed Salah</Name></Players><Players><Id>2</Id><Name>Virgil van Dijk</Name></Players></TeamData>", string(byt))
+	})
+
+	suite.Run("Indent", func() {
+		handler := miruken.BuildUp(suite.Setup(),
+			miruken.Options(xml.Options{Indent: "  "}))
+		byt, _, _, err := maps.Out[[]byte](handler, apitest.PlayerData{Id: 1, Name: "Salah"}, api.ToXml)
+		suite.Nil(err)
+		suite.Equal("<PlayerData>\n  <Id>1</Id>\n  <Name>Salah</Name>\n</PlayerData>", string(byt))
+	})
+
+	suite.Run("TextXml", func() {
+		handler := suite.Setup()
+		byt, _, _, err := maps.Out[[]byte](handler, team, maps.To("text/xml", nil))
+		suite.Nil(err)
+		var decoded apitest.TeamData
+		_, _, err = maps.Into(handler, byt, &decoded, maps.From("text/xml", nil))
+		suite.Nil(err)
+		suite.Equal(team, decoded)
+	})
+
+	suite.Run("PolymorphicTypeAttribute", func() {
+		handler := suite.Setup()
+		byt, _, _, err := maps.Out[[]byte](
+			miruken.BuildUp(handler, api.Polymorphic),
+			&apitest.PlayerData{Id: 1, Name: "Salah"}, api.ToXml)
+		suite.Nil(err)
+		suite.Equal(`<PlayerData xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="apitest.PlayerData"><Id>1</Id><Name>Salah</Name></PlayerData>`, string(byt))
+	})
+
+	suite.Run("CustomTypeAttribute", func() {
+		handler := miruken.BuildUp(suite.Setup(),
+			api.Polymorphic, xml.TypeAttribute("kind"))
+		byt, _, _, err := maps.Out[[]byte](handler, &apitest.PlayerData{Id: 1, Name: "Salah"}, api.ToXml)
+		suite.Nil(err)
+		suite.Equal(`<PlayerData kind="apitest.PlayerData"><Id>1</Id><Name>Salah</Name></PlayerData>`, string(byt))
+		late, _, _, err := maps.Out[api.Late](handler, byt, api.FromXml)
+		suite.Nil(err)
+		suite.Equal(&apitest.PlayerData{Id: 1, Name: "Salah"}, late.Value)
+	})
+
+	suite.Run("MissingTypeAttribute", func() {
+		handler := miruken.BuildUp(suite.Setup(), api.Polymorphic)
+		_, _, _, err := maps.Out[api.Late](handler,
+			[]byte("<PlayerData><Id>1</Id></PlayerData>"), api.FromXml)
+		suite.ErrorContains(err, `missing "xsi:type" attribute for element "PlayerData"`)
+	})
+
+	suite.Run("UnknownTypeId", func() {
+		handler := miruken.BuildUp(suite.Setup(), api.Polymorphic)
+		_, _, _, err := maps.Out[api.Late](handler,
+			[]byte(`<Foo xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="test.Foo"></Foo>`), api.FromXml)
+		suite.NotNil(err)
+	})
+}
+
+func TestXmlTestSuite(t *testing.T) {
+	suite.Run(t, &XmlTestSuite{apitest.CodecSuite{
+		Features: []setup.Feature{TestFeature, xml.Feature()},
+		To:       api.ToXml,
+		From:     api.FromXml,
+	}})
+}
@@ -0,0 +1,86 @@
+package test
+
+import (
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/api/apitest"
+	"github.com/miruken-go/miruken/creates"
+	"github.com/miruken-go/miruken/maps"
+)
+
+type (
+	PlaceOrderV1 struct {
+		Item string
+	}
+
+	PlaceOrder struct {
+		Item     string
+		Quantity int
+	}
+
+	VersionMapper struct{}
+)
+
+func (PlaceOrderV1) TypeVersion() int { return 1 }
+func (PlaceOrder) TypeVersion() int   { return 2 }
+
+// VersionMapper
+
+func (m *VersionMapper) New(
+	_ *struct {
+		_ creates.It `key:"test.PlaceOrder"`
+		_ creates.It `key:"test.PlaceOrder@v2"`
+	  }, create *creates.It,
+) any {
+	switch create.Key() {
+	case "test.PlaceOrder":
+		return new(PlaceOrderV1)
+	case "test.PlaceOrder@v2":
+		return new(PlaceOrder)
+	}
+	return nil
+}
+
+func (m *VersionMapper) UpcastV1(
+	_ *struct {
+		maps.It
+		maps.Format `to:"api:upcast"`
+	  }, v1 *PlaceOrderV1,
+) *PlaceOrder {
+	return &PlaceOrder{Item: v1.Item, Quantity: 1}
+}
+
+func (m *VersionMapper) DowncastV2(
+	_ *struct {
+		maps.It
+		maps.Format `to:"api:downcast"`
+	  }, order *PlaceOrder,
+) *PlaceOrderV1 {
+	return &PlaceOrderV1{Item: order.Item}
+}
+
+func (suite *XmlTestSuite) TestVersion() {
+	suite.Run("Downcast", func() {
+		handler := suite.Setup()
+		byt, _, _, err := maps.Out[[]byte](
+			miruken.BuildUp(handler,
+				api.Polymorphic,
+				api.Versions(map[string]int{"test.PlaceOrder": 1})),
+			&PlaceOrder{Item: "Pen", Quantity: 2}, api.ToXml)
+		suite.Nil(err)
+		suite.Equal(`<PlaceOrder xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:type="test.PlaceOrder"><Item>Pen</Item></PlaceOrder>`, string(byt))
+	})
+
+	suite.Run("DowncastSlice", func() {
+		handler := miruken.BuildUp(suite.Setup(),
+			api.Polymorphic,
+			api.Versions(map[string]int{"test.PlaceOrder": 1}))
+		batch := []any{&PlaceOrder{Item: "Pen", Quantity: 2}, &apitest.PlayerData{Id: 1, Name: "Salah"}}
+		byt, _, _, err := maps.Out[[]byte](handler, batch, api.ToXml)
+		suite.Nil(err)
+		suite.Contains(string(byt), `<PlaceOrder xsi:type="test.PlaceOrder"><Item>Pen</Item></PlaceOrder>`)
+		late, _, _, err := maps.Out[api.Late](handler, byt, api.FromXml)
+		suite.Nil(err)
+		suite.Equal(&[]any{&PlaceOrder{Item: "Pen", Quantity: 1}, &apitest.PlayerData{Id: 1, Name: "Salah"}}, late.Value)
+	})
+}
@@ -0,0 +1,141 @@
+package yaml
+
+import (
+	"bytes"
+	"io"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/args"
+	"github.com/miruken-go/miruken/internal"
+	"github.com/miruken-go/miruken/maps"
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// Options provide options for controlling yaml encoding.
+	Options struct {
+		Indent int
+	}
+
+	// Mapper formats to and from yaml using gopkg.in/yaml.v3.
+	Mapper struct{}
+)
+
+func (m *Mapper) ToYaml(
+	_ *struct {
+		maps.Format `to:"application/yaml"`
+	  }, it *maps.It,
+	_ *struct {
+		args.Optional
+		args.FromOptions
+	  }, options Options,
+	_ *struct {
+		args.Optional
+		args.FromOptions
+	  }, apiOptions api.Options,
+	ctx miruken.HandleContext,
+) (y any, err error) {
+	switch t := it.Target().(type) {
+	case *[]byte:
+		return marshal(it, t, &options, &apiOptions, ctx.Composer)
+	case *io.Writer:
+		if internal.IsNil(*t) {
+			*t = new(bytes.Buffer)
+		}
+		if err = encode(it, *t, &options, &apiOptions, ctx.Composer); err == nil {
+			y = *t
+		}
+	}
+	return
+}
+
+func (m *Mapper) FromBytes(
+	_ *struct {
+		maps.Format `from:"application/yaml"`
+	  }, byt []byte,
+	_ *struct {
+		args.Optional
+		args.FromOptions
+	  }, apiOptions api.Options,
+	it  *maps.It,
+	ctx miruken.HandleContext,
+) (any, error) {
+	return decode(it, bytes.NewReader(byt), &apiOptions, ctx.Composer)
+}
+
+func (m *Mapper) FromReader(
+	_ *struct {
+		maps.Format `from:"application/yaml"`
+	  }, reader io.Reader,
+	_ *struct {
+		args.Optional
+		args.FromOptions
+	  }, apiOptions api.Options,
+	it  *maps.It,
+	ctx miruken.HandleContext,
+) (any, error) {
+	return decode(it, reader, &apiOptions, ctx.Composer)
+}
+
+func marshal(
+	it         *maps.It,
+	byt        *[]byte,
+	options    *Options,
+	apiOptions *api.Options,
+	composer   miruken.Handler,
+) ([]byte, error) {
+	var b bytes.Buffer
+	if err := encode(it, &b, options, apiOptions, composer); err != nil {
+		return nil, err
+	}
+	*byt = b.Bytes()
+	return *byt, nil
+}
+
+func encode(
+	it         *maps.It,
+	writer     io.Writer,
+	options    *Options,
+	apiOptions *api.Options,
+	composer   miruken.Handler,
+) error {
+	it.TargetForWrite()
+	enc := yaml.NewEncoder(writer)
+	if indent := options.Indent; indent > 0 {
+		enc.SetIndent(indent)
+	}
+	src := it.Source()
+	if apiOptions.Polymorphism == miruken.Set(api.PolymorphismRoot) {
+		src = &typeContainer{
+			v:        src,
+			typInfo:  apiOptions.TypeInfoFormat,
+			versions: apiOptions.Versions,
+			composer: composer,
+		}
+	}
+	if err := enc.Encode(src); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+func decode(
+	it         *maps.It,
+	reader     io.Reader,
+	apiOptions *api.Options,
+	composer   miruken.Handler,
+) (target any, err error) {
+	target = it.TargetForWrite()
+	dec := yaml.NewDecoder(reader)
+	if apiOptions.Polymorphism == miruken.Set(api.PolymorphismRoot) {
+		tc := typeContainer{
+			v:        target,
+			composer: composer,
+		}
+		err = dec.Decode(&tc)
+	} else {
+		err = dec.Decode(target)
+	}
+	return
+}
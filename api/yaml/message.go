@@ -0,0 +1,158 @@
+package yaml
+
+import (
+	"io"
+	"time"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/maps"
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// MessageSurrogate is a yaml surrogate for api.Message.
+	MessageSurrogate struct {
+		Payload yaml.Node         `yaml:"payload,omitempty"`
+		Headers *HeadersSurrogate `yaml:"headers,omitempty"`
+	}
+
+	// HeadersSurrogate is a yaml surrogate for api.Headers.
+	HeadersSurrogate struct {
+		MessageId     string            `yaml:"messageId,omitempty"`
+		CorrelationId string            `yaml:"correlationId,omitempty"`
+		CausationId   string            `yaml:"causationId,omitempty"`
+		Tenant        string            `yaml:"tenant,omitempty"`
+		SentAt        *time.Time        `yaml:"sentAt,omitempty"`
+		Deadline      *time.Time        `yaml:"deadline,omitempty"`
+		Custom        map[string]string `yaml:"custom,omitempty"`
+	}
+)
+
+func (m *SurrogateMapper) EncodeMessage(
+	_ *struct {
+		maps.Format `to:"application/yaml"`
+	  }, msg api.Message,
+	it  *maps.It,
+	ctx miruken.HandleContext,
+) (io.Writer, error) {
+	if writer, ok := it.Target().(*io.Writer); ok {
+		var sur MessageSurrogate
+		if payload := msg.Payload; payload != nil {
+			node, err := encodeNode(ctx.Composer, payload)
+			if err != nil {
+				return nil, err
+			}
+			sur.Payload = node
+		}
+		if headers := msg.Headers; !headers.IsZero() {
+			sur.Headers = encodeHeaders(headers)
+		}
+		enc := yaml.NewEncoder(*writer)
+		if err := enc.Encode(sur); err != nil {
+			return nil, err
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+		it.TargetForWrite()
+		return *writer, nil
+	}
+	return nil, nil
+}
+
+func (m *SurrogateMapper) DecodeMessage(
+	_ *struct {
+		maps.It
+		maps.Format `from:"application/yaml"`
+	  }, reader io.Reader,
+	it  *maps.It,
+	ctx miruken.HandleContext,
+) (msg api.Message, err error) {
+	if mp, ok := it.Target().(*api.Message); ok {
+		var sur MessageSurrogate
+		if err = yaml.NewDecoder(reader).Decode(&sur); err != nil {
+			return
+		}
+		if headers := sur.Headers; headers != nil {
+			mp.Headers = headers.decode()
+		}
+		if payload := sur.Payload; !payload.IsZero() {
+			var byt []byte
+			if byt, err = yaml.Marshal(&payload); err != nil {
+				return
+			}
+			var late api.Late
+			composer := ctx.Composer
+			late, _, _, err = maps.Out[api.Late](composer, byt, api.FromYaml)
+			if err != nil {
+				return
+			}
+			if sur, ok := late.Value.(api.Surrogate); ok {
+				if late.Value, err = sur.Original(composer); err != nil {
+					return
+				}
+			}
+			it.TargetForWrite()
+			mp.Payload = late.Value
+			msg = *mp
+		}
+	}
+	return
+}
+
+// encodeNode maps a value into yaml and parses the resulting node
+// to embed it in a surrogate.
+func encodeNode(
+	composer miruken.Handler,
+	v        any,
+) (yaml.Node, error) {
+	var node yaml.Node
+	byt, _, _, err := maps.Out[[]byte](composer, v, api.ToYaml)
+	if err != nil {
+		return node, err
+	}
+	if err = yaml.Unmarshal(byt, &node); err != nil {
+		return node, err
+	}
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		return *node.Content[0], nil
+	}
+	return node, nil
+}
+
+// HeadersSurrogate
+
+func (h *HeadersSurrogate) decode() api.Headers {
+	headers := api.Headers{
+		MessageId:     h.MessageId,
+		CorrelationId: h.CorrelationId,
+		CausationId:   h.CausationId,
+		Tenant:        h.Tenant,
+		Custom:        h.Custom,
+	}
+	if h.SentAt != nil {
+		headers.SentAt = *h.SentAt
+	}
+	if h.Deadline != nil {
+		headers.Deadline = *h.Deadline
+	}
+	return headers
+}
+
+func encodeHeaders(headers api.Headers) *HeadersSurrogate {
+	sur := &HeadersSurrogate{
+		MessageId:     headers.MessageId,
+		CorrelationId: headers.CorrelationId,
+		CausationId:   headers.CausationId,
+		Tenant:        headers.Tenant,
+		Custom:        headers.Custom,
+	}
+	if sentAt := headers.SentAt; !sentAt.IsZero() {
+		sur.SentAt = &sentAt
+	}
+	if deadline := headers.Deadline; !deadline.IsZero() {
+		sur.Deadline = &deadline
+	}
+	return sur
+}
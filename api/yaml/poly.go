@@ -0,0 +1,186 @@
+package yaml
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/creates"
+	"github.com/miruken-go/miruken/internal"
+	"github.com/miruken-go/miruken/maps"
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// typeContainer customizes yaml serialization to
+	// emit type field information needed to support polymorphism.
+	typeContainer struct {
+		v        any
+		typInfo  string
+		versions map[string]int
+		composer miruken.Handler
+	}
+)
+
+var (
+	// KnownTypeFields holds the list of yaml keys
+	// that can contain type discriminators.
+	KnownTypeFields = []string{"$type", "@type"}
+
+	// KnownValuesFields holds the list of yaml keys
+	// that can contain values for discriminated arrays.
+	KnownValuesFields = []string{"$values", "@values"}
+)
+
+func (c *typeContainer) typeInfo() *maps.Format {
+	if typeInfo := c.typInfo; len(typeInfo) > 0 {
+		return maps.To(typeInfo, nil)
+	}
+	return api.ToTypeInfo
+}
+
+func (c *typeContainer) MarshalYAML() (any, error) {
+	v := c.v
+	typ := reflect.TypeOf(v)
+	if typ != nil && typ.Kind() == reflect.Slice {
+		et := typ.Elem()
+		s := reflect.ValueOf(v)
+		arr := make([]any, 0, s.Len())
+		for i := range s.Len() {
+			elem := s.Index(i).Interface()
+			if internal.IsAny(et) || reflect.TypeOf(elem) != et {
+				elem = &typeContainer{
+					v:        elem,
+					typInfo:  c.typInfo,
+					versions: c.versions,
+					composer: c.composer,
+				}
+			}
+			arr = append(arr, elem)
+		}
+		v = arr
+	} else if versions := c.versions; len(versions) > 0 {
+		var err error
+		if v, err = api.DowncastTo(c.composer, v, versions, c.typeInfo()); err != nil {
+			return nil, err
+		}
+	}
+	var node yaml.Node
+	if err := node.Encode(v); err != nil {
+		return nil, err
+	}
+	switch node.Kind {
+	case yaml.MappingNode:
+		typeInfo, _, _, err := maps.Out[api.TypeFieldInfo](c.composer, v, c.typeInfo())
+		if err != nil {
+			return nil, err
+		}
+		node.Content = append(
+			[]*yaml.Node{scalar(typeInfo.TypeField), scalar(typeInfo.TypeValue)},
+			node.Content...)
+	case yaml.SequenceNode:
+		typeInfo, _, _, err := maps.Out[api.TypeFieldInfo](c.composer, c.v, c.typeInfo())
+		if err != nil {
+			return nil, err
+		}
+		values := node
+		node = yaml.Node{
+			Kind: yaml.MappingNode,
+			Tag:  "!!map",
+			Content: []*yaml.Node{
+				scalar(typeInfo.TypeField), scalar(typeInfo.TypeValue),
+				scalar(typeInfo.ValuesField), &values,
+			},
+		}
+	}
+	return &node, nil
+}
+
+func (c *typeContainer) UnmarshalYAML(node *yaml.Node) error {
+	if node.Kind == yaml.AliasNode {
+		node = node.Alias
+	}
+	switch node.Kind {
+	case yaml.SequenceNode:
+		var arr reflect.Value
+		typ := reflect.Indirect(reflect.ValueOf(c.v)).Type()
+		if typ.Kind() == reflect.Slice {
+			arr = reflect.MakeSlice(typ, len(node.Content), len(node.Content))
+		} else {
+			arr = reflect.ValueOf(make([]any, len(node.Content)))
+		}
+		for i, elem := range node.Content {
+			tc := typeContainer{
+				v:        arr.Index(i).Addr().Interface(), // &arr[0]
+				typInfo:  c.typInfo,
+				composer: c.composer,
+			}
+			if err := elem.Decode(&tc); err != nil {
+				return fmt.Errorf("can't unmarshal array index %d: %w", i, err)
+			}
+		}
+		if late, ok := c.v.(*api.Late); ok {
+			late.Value = arr.Interface()
+		} else {
+			internal.CopyIndirect(arr.Interface(), c.v)
+		}
+		return nil
+	case yaml.MappingNode:
+	default:
+		if late, ok := c.v.(*api.Late); ok {
+			return node.Decode(&late.Value)
+		}
+		return node.Decode(c.v)
+	}
+	field, typeIdNode := mappingValue(node, KnownTypeFields)
+	if typeIdNode == nil {
+		if late, ok := c.v.(*api.Late); ok {
+			return node.Decode(&late.Value)
+		}
+		return node.Decode(c.v)
+	}
+	var typeId string
+	if err := typeIdNode.Decode(&typeId); err != nil {
+		return err
+	} else if typeId == "" {
+		return fmt.Errorf("empty type id for field %q", field)
+	}
+	v, _, err := creates.Key[any](c.composer, typeId)
+	if err != nil {
+		return &api.UnknownTypeIdError{TypeId: typeId, Cause: err}
+	}
+	if _, values := mappingValue(node, KnownValuesFields); values != nil {
+		err = values.Decode(&typeContainer{
+			v:        v,
+			typInfo:  c.typInfo,
+			composer: c.composer,
+		})
+	} else {
+		err = node.Decode(v)
+	}
+	if err != nil {
+		return err
+	}
+	return api.AssignDecoded(c.composer, typeId, v, c.v)
+}
+
+// mappingValue returns the first value of a mapping node
+// with one of the supplied keys.
+func mappingValue(
+	node *yaml.Node,
+	keys []string,
+) (string, *yaml.Node) {
+	for _, key := range keys {
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == key {
+				return key, node.Content[i+1]
+			}
+		}
+	}
+	return "", nil
+}
+
+func scalar(value string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+}
@@ -0,0 +1,99 @@
+package yaml
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/either"
+	"github.com/miruken-go/miruken/maps"
+	"gopkg.in/yaml.v3"
+)
+
+type (
+	// Either is a surrogate for either.Monad using yaml.
+	Either[L, R any] struct {
+		Left  bool      `yaml:"left"`
+		Value yaml.Node `yaml:"value"`
+	}
+
+	// ScheduledResult is a surrogate for api.ScheduledResult over yaml.
+	ScheduledResult []Either[error, any]
+)
+
+// Either
+
+func (s Either[L, R]) Original(
+	composer miruken.Handler,
+) (any, error) {
+	byt, err := yaml.Marshal(&s.Value)
+	if err != nil {
+		return nil, err
+	}
+	v, _, _, err := maps.Out[any](composer, byt, api.FromYaml)
+	if err != nil {
+		return nil, err
+	}
+	if sur, ok := v.(api.Surrogate); ok {
+		if v, err = sur.Original(composer); err != nil {
+			return nil, err
+		}
+	}
+	if s.Left {
+		if l, ok := v.(L); ok {
+			return either.Left(l), nil
+		}
+		return nil, fmt.Errorf("expected left of %s", reflect.TypeFor[L]())
+	}
+	if r, ok := v.(R); ok {
+		return either.Right(r), nil
+	}
+	return nil, fmt.Errorf("expected right of %s", reflect.TypeFor[R]())
+}
+
+// ScheduledResult
+
+func (s ScheduledResult) Original(composer miruken.Handler) (any, error) {
+	responses := make([]either.Monad[error, any], len(s))
+	for i, resp := range s {
+		if orig, err := resp.Original(composer); err != nil {
+			return nil, err
+		} else {
+			responses[i] = orig
+		}
+	}
+	return &api.ScheduledResult{Responses: responses}, nil
+}
+
+// SurrogateMapper
+
+func (m *SurrogateMapper) ReplaceScheduledResult(
+	_ *struct {
+		maps.It
+		maps.Format `to:"application/yaml"`
+	  }, result api.ScheduledResult,
+	ctx miruken.HandleContext,
+) ([]byte, error) {
+	sur := make(ScheduledResult, len(result.Responses))
+	for i, resp := range result.Responses {
+		err := either.Fold(resp, func(e error) error {
+			node, err := encodeNode(ctx, e)
+			if err == nil {
+				sur[i] = Either[error, any]{true, node}
+			}
+			return err
+		}, func(val any) error {
+			node, err := encodeNode(ctx, val)
+			if err == nil {
+				sur[i] = Either[error, any]{false, node}
+			}
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	byt, _, _, err := maps.Out[[]byte](ctx, sur, api.ToYaml)
+	return byt, err
+}
@@ -0,0 +1,72 @@
+package yaml
+
+import (
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/api/json"
+	"github.com/miruken-go/miruken/creates"
+	"github.com/miruken-go/miruken/maps"
+	"github.com/miruken-go/miruken/validates"
+)
+
+// SurrogateMapper maps concepts to values that are more suitable
+// for transmission over a polymorphic yaml api.  The json surrogates
+// are reused since yaml is a superset of json.
+type SurrogateMapper struct{}
+
+func (m *SurrogateMapper) New(
+	_ *struct {
+		creates.It `key:"yaml.ScheduledResult"`
+	  }, create *creates.It,
+) any {
+	switch create.Key() {
+	case "yaml.ScheduledResult":
+		return new(ScheduledResult)
+	}
+	return nil
+}
+
+func (m *SurrogateMapper) ReplaceOutcome(
+	_ *struct {
+		maps.It
+		maps.Format `to:"application/yaml"`
+	  }, outcome *validates.Outcome,
+	ctx miruken.HandleContext,
+) ([]byte, error) {
+	byt, _, _, err := maps.Out[[]byte](ctx, json.NewOutcome(outcome), api.ToYaml)
+	return byt, err
+}
+
+func (m *SurrogateMapper) ReplaceError(
+	_ *struct {
+		maps.It
+		maps.Format `to:"application/yaml"`
+	  }, err error,
+	ctx miruken.HandleContext,
+) ([]byte, error) {
+	sur := json.Error{Message: err.Error()}
+	byt, _, _, err := maps.Out[[]byte](ctx, sur, api.ToYaml)
+	return byt, err
+}
+
+func (m *SurrogateMapper) ReplaceConcurrent(
+	_ *struct {
+		maps.It
+		maps.Format `to:"application/yaml"`
+	  }, batch api.ConcurrentBatch,
+	ctx miruken.HandleContext,
+) (byt []byte, err error) {
+	byt, _, _, err = maps.Out[[]byte](ctx, json.NewConcurrent(batch), api.ToYaml)
+	return
+}
+
+func (m *SurrogateMapper) ReplaceSequential(
+	_ *struct {
+		maps.It
+		maps.Format `to:"application/yaml"`
+	  }, batch api.SequentialBatch,
+	ctx miruken.HandleContext,
+) (byt []byte, err error) {
+	byt, _, _, err = maps.Out[[]byte](ctx, json.NewSequential(batch), api.ToYaml)
+	return
+}
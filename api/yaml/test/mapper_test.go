@@ -0,0 +1,109 @@
+package test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/api/apitest"
+	"github.com/miruken-go/miruken/api/yaml"
+	"github.com/miruken-go/miruken/maps"
+	"github.com/miruken-go/miruken/setup"
+	"github.com/stretchr/testify/suite"
+)
+
+type YamlTestSuite struct {
+	apitest.CodecSuite
+}
+
+func (suite *YamlTestSuite) TestYaml() {
+	suite.Run("PolymorphicTypeField", func() {
+		handler := suite.Setup()
+		byt, _, _, err := maps.Out[[]byte](
+			miruken.BuildUp(handler, api.Polymorphic),
+			&apitest.PlayerData{Id: 1, Name: "Salah"}, api.ToYaml)
+		suite.Nil(err)
+		suite.Equal("'@type': apitest.PlayerData\nid: 1\nname: Salah\n", string(byt))
+	})
+
+	suite.Run("Document", func() {
+		handler := suite.Setup()
+		doc := `
+- "@type": apitest.TeamData
+  id: 9
+  name: Breakaway
+  players:
+    - id: 1
+      name: Sean Rose
+- "@type": apitest.PlayerData
+  id: 4
+  name: Mark Kingston
+`
+		fixtures, _, _, err := maps.Out[[]any](
+			miruken.BuildUp(handler, api.Polymorphic),
+			strings.NewReader(doc), api.FromYaml)
+		suite.Nil(err)
+		suite.Equal([]any{
+			&apitest.TeamData{Id: 9, Name: "Breakaway", Players: []apitest.PlayerData{
+				{Id: 1, Name: "Sean Rose"},
+			}},
+			&apitest.PlayerData{Id: 4, Name: "Mark Kingston"},
+		}, fixtures)
+	})
+
+	suite.Run("Alias", func() {
+		handler := suite.Setup()
+		doc := `
+- &captain
+  "@type": apitest.PlayerData
+  id: 4
+  name: Mark Kingston
+- *captain
+`
+		fixtures, _, _, err := maps.Out[[]any](
+			miruken.BuildUp(handler, api.Polymorphic),
+			strings.NewReader(doc), api.FromYaml)
+		suite.Nil(err)
+		suite.Equal([]any{
+			&apitest.PlayerData{Id: 4, Name: "Mark Kingston"},
+			&apitest.PlayerData{Id: 4, Name: "Mark Kingston"},
+		}, fixtures)
+	})
+
+	suite.Run("Values", func() {
+		handler := miruken.BuildUp(suite.Setup(), api.Polymorphic)
+		byt, _, _, err := maps.Out[[]byte](handler, []string{"X", "Y"}, api.ToYaml)
+		suite.Nil(err)
+		suite.Equal("'@type': '[]string'\n'@values':\n    - X\n    - \"Y\"\n", string(byt))
+		doc := `
+"@type": "[]string"
+"@values": [Craig, Brenda, Lauren]
+`
+		names, _, _, err := maps.Out[[]string](handler, strings.NewReader(doc), api.FromYaml)
+		suite.Nil(err)
+		suite.Equal([]string{"Craig", "Brenda", "Lauren"}, names)
+	})
+
+	suite.Run("Indent", func() {
+		handler := miruken.BuildUp(suite.Setup(),
+			miruken.Options(yaml.Options{Indent: 2}))
+		team := apitest.TeamData{
+			Id:      1,
+			Name:    "Liverpool",
+			Players: []apitest.PlayerData{{Id: 1, Name: "Mohamed Salah"}},
+		}
+		byt, _, _, err := maps.Out[[]byte](handler, team, api.ToYaml)
+		suite.Nil(err)
+		suite.Contains(string(byt), "players:\n  - id: 1\n    name: Mohamed Salah\n")
+	})
+}
+
+func TestYamlTestSuite(t *testing.T) {
+	suite.Run(t, &YamlTestSuite{apitest.CodecSuite{
+		Features: []setup.Feature{yaml.Feature()},
+		To:       api.ToYaml,
+		From:     api.FromYaml,
+		Batches:  true,
+	}})
+}
@@ -192,8 +192,6 @@ func validateContravariantFunc(
 		err = errors.Join(err, fmt.Errorf("contravariant: %w", err2))
 	}
 
-	resIdx := -1
-
 	for i := range numOut {
 		out := funType.Out(i)
 		if out.AssignableTo(internal.ErrorType) {
@@ -211,8 +209,7 @@ func validateContravariantFunc(
 		} else if err2 != nil {
 			err = errors.Join(err, fmt.Errorf(
 				"contravariant: invalid effect at index %v: %w", i, err2))
-		} else if resIdx == 0 {  // response assumed be first
-			resIdx = i
+		} else if i == 0 {  // response assumed be first
 			if lt, ok := promise.Inspect(out); ok {
 				spec.flags |= bindingAsync
 				out = lt
@@ -0,0 +1,197 @@
+package eventsource
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+type (
+	// Aggregate maintains the identity and pending changes of
+	// an event sourced type.  It is embedded in the aggregate
+	// struct which rebuilds its state through Apply methods.
+	//
+	// An Apply method is any method whose name starts with
+	// Apply and accepts a single event argument.
+	Aggregate struct {
+		id      string
+		version int
+		changes []any
+		self    any
+	}
+
+	// Root is implemented by types embedding an Aggregate.
+	Root interface {
+		aggregate() *Aggregate
+	}
+
+	// Streamed is implemented by messages that explicitly
+	// supply the id of the stream they target.
+	Streamed interface {
+		StreamId() string
+	}
+
+	// MissingApplyError reports an event the aggregate cannot apply.
+	MissingApplyError struct {
+		Aggregate reflect.Type
+		Event     reflect.Type
+	}
+)
+
+func (a *Aggregate) Id() string {
+	return a.id
+}
+
+// Version returns the version of the last committed event.
+// A zero version indicates a new aggregate.
+func (a *Aggregate) Version() int {
+	return a.version
+}
+
+func (a *Aggregate) New() bool {
+	return a.version == 0
+}
+
+// Changes returns the events raised but not yet committed.
+func (a *Aggregate) Changes() []any {
+	return a.changes
+}
+
+// Raise applies new events to the aggregate and records
+// them for commit when the current command completes.
+func (a *Aggregate) Raise(events ...any) error {
+	for _, event := range events {
+		if err := a.apply(event); err != nil {
+			return err
+		}
+		a.changes = append(a.changes, event)
+	}
+	return nil
+}
+
+func (a *Aggregate) aggregate() *Aggregate {
+	return a
+}
+
+func (a *Aggregate) apply(event any) error {
+	if a.self == nil {
+		panic("aggregate has not been initialized")
+	}
+	return applyEvent(a.self, event)
+}
+
+func (a *Aggregate) commit(version int) {
+	a.version = version
+	a.changes = nil
+}
+
+// New creates an aggregate T bound to the stream id.
+func New[T any](id string) (*T, error) {
+	t := new(T)
+	root, ok := any(t).(Root)
+	if !ok {
+		return nil, fmt.Errorf("eventsource: %T does not embed an Aggregate", t)
+	}
+	agg := root.aggregate()
+	agg.id   = id
+	agg.self = t
+	return t, nil
+}
+
+// StreamId extracts the stream id targeted by a message.
+// The id is obtained from the Streamed interface or from
+// a field tagged with `stream:"id"`.
+func StreamId(message any) (string, bool) {
+	if s, ok := message.(Streamed); ok {
+		if id := s.StreamId(); id != "" {
+			return id, true
+		}
+		return "", false
+	}
+	val := reflect.ValueOf(message)
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return "", false
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return "", false
+	}
+	if index, ok := streamField(val.Type()); ok {
+		if field := val.FieldByIndex(index); !field.IsZero() {
+			return fmt.Sprint(field.Interface()), true
+		}
+	}
+	return "", false
+}
+
+func (e *MissingApplyError) Error() string {
+	return fmt.Sprintf("eventsource: %v has no Apply method for %v", e.Aggregate, e.Event)
+}
+
+// applyEvent invokes the Apply method of the aggregate
+// accepting the event or its pointer/value counterpart.
+func applyEvent(aggregate any, event any) error {
+	target := reflect.ValueOf(aggregate)
+	methods := applyMethods(target.Type())
+	ev := reflect.ValueOf(event)
+	typ := ev.Type()
+	if method, ok := methods[typ]; ok {
+		target.Method(method).Call([]reflect.Value{ev})
+		return nil
+	}
+	if typ.Kind() == reflect.Ptr {
+		if method, ok := methods[typ.Elem()]; ok && !ev.IsNil() {
+			target.Method(method).Call([]reflect.Value{ev.Elem()})
+			return nil
+		}
+	} else if method, ok := methods[reflect.PointerTo(typ)]; ok {
+		ptr := reflect.New(typ)
+		ptr.Elem().Set(ev)
+		target.Method(method).Call([]reflect.Value{ptr})
+		return nil
+	}
+	return &MissingApplyError{target.Type(), typ}
+}
+
+// applyMethods maps event types to Apply method indices.
+func applyMethods(typ reflect.Type) map[reflect.Type]int {
+	if methods, ok := applyCache.Load(typ); ok {
+		return methods.(map[reflect.Type]int)
+	}
+	methods := make(map[reflect.Type]int)
+	for i := range typ.NumMethod() {
+		method := typ.Method(i)
+		if strings.HasPrefix(method.Name, "Apply") &&
+			method.Type.NumIn() == 2 && method.Type.NumOut() == 0 {
+			methods[method.Type.In(1)] = i
+		}
+	}
+	applyCache.Store(typ, methods)
+	return methods
+}
+
+// streamField finds the field tagged as the stream id.
+func streamField(typ reflect.Type) ([]int, bool) {
+	if cached, ok := fieldCache.Load(typ); ok {
+		index := cached.([]int)
+		return index, index != nil
+	}
+	var index []int
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		if tag, ok := field.Tag.Lookup("stream"); ok && tag == "id" && field.IsExported() {
+			index = field.Index
+			break
+		}
+	}
+	fieldCache.Store(typ, index)
+	return index, index != nil
+}
+
+var (
+	applyCache sync.Map
+	fieldCache sync.Map
+)
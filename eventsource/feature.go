@@ -0,0 +1,50 @@
+package eventsource
+
+import (
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/setup"
+)
+
+// Installer configures event sourcing support.
+type Installer struct {
+	store EventStore
+}
+
+func (i *Installer) SetStore(store EventStore) {
+	i.store = store
+}
+
+func (i *Installer) DependsOn() []setup.Feature {
+	return []setup.Feature{api.Feature()}
+}
+
+func (i *Installer) Install(b *setup.Builder) error {
+	if b.Tag(&featureTag) {
+		store := i.store
+		if store == nil {
+			store = NewMemoryStore()
+		}
+		b.With(store)
+	}
+	return nil
+}
+
+// UseStore configures the EventStore holding event streams.
+func UseStore(store EventStore) func(*Installer) {
+	return func(installer *Installer) {
+		installer.SetStore(store)
+	}
+}
+
+// Feature configures event sourcing support.
+func Feature(config ...func(*Installer)) setup.Feature {
+	installer := &Installer{}
+	for _, configure := range config {
+		if configure != nil {
+			configure(installer)
+		}
+	}
+	return installer
+}
+
+var featureTag byte
@@ -0,0 +1,141 @@
+package eventsource
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileStore is an EventStore that keeps each stream in an
+// append-only file of json lines.  Events are synced to disk
+// before Append returns so streams survive a process restart.
+type FileStore struct {
+	dir      string
+	versions map[string]int
+	lock     sync.Mutex
+}
+
+func (s *FileStore) Dir() string {
+	return s.dir
+}
+
+func (s *FileStore) Load(
+	streamId string,
+	after    int,
+) ([]Event, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	stream, err := s.read(streamId)
+	if err != nil || after >= len(stream) {
+		return nil, err
+	}
+	return stream[max(after, 0):], nil
+}
+
+func (s *FileStore) Append(
+	streamId string,
+	expected int,
+	events   ...Event,
+) ([]Event, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	actual, ok := s.versions[streamId]
+	if !ok {
+		stream, err := s.read(streamId)
+		if err != nil {
+			return nil, err
+		}
+		actual = len(stream)
+	}
+	if actual != expected {
+		return nil, &ConcurrencyError{streamId, expected, actual}
+	}
+	sequenced := sequence(streamId, expected, events)
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, event := range sequenced {
+		if err := enc.Encode(event); err != nil {
+			return nil, err
+		}
+	}
+	file, err := os.OpenFile(s.path(streamId), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = file.Write(buf.Bytes()); err == nil {
+		err = file.Sync()
+	}
+	if ce := file.Close(); err == nil {
+		err = ce
+	}
+	if err != nil {
+		delete(s.versions, streamId)
+		return nil, err
+	}
+	s.versions[streamId] = expected + len(sequenced)
+	return sequenced, nil
+}
+
+// read loads all events in the stream.  An incomplete
+// trailing line left by an interrupted write is truncated.
+func (s *FileStore) read(streamId string) ([]Event, error) {
+	file, err := os.Open(s.path(streamId))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			s.versions[streamId] = 0
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer func() {
+		_ = file.Close()
+	}()
+	var stream []Event
+	var offset int64
+	reader := bufio.NewReader(file)
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				return nil, err
+			}
+			if len(line) > 0 {
+				if err = os.Truncate(file.Name(), offset); err != nil {
+					return nil, err
+				}
+			}
+			break
+		}
+		var event Event
+		if err = json.Unmarshal(line, &event); err != nil {
+			return nil, err
+		}
+		stream = append(stream, event)
+		offset += int64(len(line))
+	}
+	s.versions[streamId] = len(stream)
+	return stream, nil
+}
+
+func (s *FileStore) path(streamId string) string {
+	return filepath.Join(s.dir, url.PathEscape(streamId)+".jsonl")
+}
+
+// NewFileStore creates a new FileStore in the supplied directory.
+// The directory is created if it does not exist.
+func NewFileStore(dir string) (*FileStore, error) {
+	if dir == "" {
+		panic("dir cannot be empty")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir, versions: make(map[string]int)}, nil
+}
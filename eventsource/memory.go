@@ -0,0 +1,44 @@
+package eventsource
+
+import "sync"
+
+// MemoryStore is an EventStore that keeps streams in memory.
+// Streams do not survive a process restart.
+type MemoryStore struct {
+	streams map[string][]Event
+	lock    sync.RWMutex
+}
+
+func (s *MemoryStore) Load(
+	streamId string,
+	after    int,
+) ([]Event, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	stream := s.streams[streamId]
+	if after >= len(stream) {
+		return nil, nil
+	}
+	return append([]Event(nil), stream[max(after, 0):]...), nil
+}
+
+func (s *MemoryStore) Append(
+	streamId string,
+	expected int,
+	events   ...Event,
+) ([]Event, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	stream := s.streams[streamId]
+	if actual := len(stream); actual != expected {
+		return nil, &ConcurrencyError{streamId, expected, actual}
+	}
+	sequenced := sequence(streamId, expected, events)
+	s.streams[streamId] = append(stream, sequenced...)
+	return sequenced, nil
+}
+
+// NewMemoryStore creates a new MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{streams: make(map[string][]Event)}
+}
@@ -0,0 +1,272 @@
+package eventsource
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/go-logr/logr"
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/handles"
+	"github.com/miruken-go/miruken/internal"
+	"github.com/miruken-go/miruken/maps"
+	"github.com/miruken-go/miruken/promise"
+	"github.com/miruken-go/miruken/provides"
+)
+
+type (
+	// Options customize event sourcing.
+	Options struct {
+		Format string
+	}
+
+	// Sources is a FilterProvider for handler methods that
+	// execute commands against an aggregate T.  The aggregate
+	// is loaded from the stream targeted by the command and any
+	// events it raises are appended and published on success.
+	Sources[T any] struct{}
+
+	// sourcesFilter loads and commits the aggregate around
+	// the execution of a handler method.
+	sourcesFilter[T any] struct{}
+
+	// stashKey identifies an aggregate cached in the api.Stash.
+	stashKey struct {
+		typ reflect.Type
+		id  string
+	}
+)
+
+const defaultFormat = "application/json"
+
+// Sources
+
+func (s *Sources[T]) Required() bool {
+	return true
+}
+
+func (s *Sources[T]) AppliesTo(
+	callback miruken.Callback,
+) bool {
+	_, ok := callback.(*handles.It)
+	return ok
+}
+
+func (s *Sources[T]) Filters(
+	binding  miruken.Binding,
+	callback any,
+	composer miruken.Handler,
+) ([]miruken.Filter, error) {
+	return []miruken.Filter{sourcesFilter[T]{}}, nil
+}
+
+// sourcesFilter
+
+func (f sourcesFilter[T]) Order() int {
+	return miruken.FilterStageValidation + 10
+}
+
+func (f sourcesFilter[T]) Next(
+	self     miruken.Filter,
+	next     miruken.Next,
+	ctx      miruken.HandleContext,
+	provider miruken.FilterProvider,
+) ([]any, *promise.Promise[[]any], error) {
+	id, ok := StreamId(ctx.Callback.Source())
+	if !ok {
+		return next.Fail(fmt.Errorf("%w: %T", ErrMissingStreamId, ctx.Callback.Source()))
+	}
+	composer := ctx.Composer
+	aggregate, err := f.load(composer, id)
+	if err != nil {
+		return next.Fail(err)
+	}
+	root := any(aggregate).(Root)
+	out, pout, err := next.Pipe(aggregate)
+	if err != nil {
+		discard(composer, root)
+		return out, pout, err
+	} else if pout == nil {
+		if err = Commit(composer, root); err != nil {
+			return nil, nil, err
+		}
+		return out, nil, nil
+	}
+	return nil, promise.Then(promise.Catch(pout, func(err error) error {
+		discard(composer, root)
+		return err
+	}), func(oo []any) []any {
+		if err := Commit(composer, root); err != nil {
+			panic(err)
+		}
+		return oo
+	}), nil
+}
+
+// load retrieves the aggregate from the api.Stash so it
+// is only rebuilt once for the duration of the request.
+func (f sourcesFilter[T]) load(
+	handler miruken.Handler,
+	id      string,
+) (*T, error) {
+	key := stashKey{reflect.TypeFor[T](), id}
+	if cached, ok := api.StashGetKey(handler, key); ok {
+		if aggregate, ok := cached.(*T); ok {
+			return aggregate, nil
+		}
+	}
+	aggregate, err := Load[T](handler, id)
+	if err != nil {
+		return nil, err
+	}
+	if err = api.StashPutKey(handler, key, aggregate); err != nil {
+		var nh *miruken.NotHandledError
+		if !errors.As(err, &nh) {
+			return nil, err
+		}
+	}
+	return aggregate, nil
+}
+
+// Load rebuilds the aggregate T from the events in the stream.
+// A new aggregate is returned if the stream has no events.
+func Load[T any](
+	handler miruken.Handler,
+	id      string,
+) (*T, error) {
+	if internal.IsNil(handler) {
+		panic("handler cannot be nil")
+	}
+	store, err := resolveStore(handler)
+	if err != nil {
+		return nil, err
+	}
+	aggregate, err := New[T](id)
+	if err != nil {
+		return nil, err
+	}
+	agg := any(aggregate).(Root).aggregate()
+	events, err := store.Load(id, 0)
+	if err != nil {
+		return nil, err
+	}
+	composer := miruken.BuildUp(handler, api.Polymorphic)
+	for _, event := range events {
+		from, err := api.ParseMediaType(event.ContentType, maps.DirectionFrom)
+		if err != nil {
+			return nil, err
+		}
+		msg, _, _, err := maps.Out[api.Message](composer, bytes.NewReader(event.Data), from)
+		if err != nil {
+			return nil, fmt.Errorf("eventsource: unable to decode event %d of stream %q: %w",
+				event.Version, id, err)
+		}
+		if err = agg.apply(msg.Payload); err != nil {
+			return nil, err
+		}
+		agg.version = event.Version
+	}
+	return aggregate, nil
+}
+
+// Commit appends the pending changes of the aggregate to its
+// stream and publishes them so projections can subscribe.
+// The aggregate is discarded from the api.Stash if the changes
+// cannot be appended.  Failures to publish are logged and not
+// returned since the changes are already in the stream and
+// retrying the command would raise them again.
+func Commit(
+	handler   miruken.Handler,
+	aggregate Root,
+) error {
+	if internal.IsNil(handler) {
+		panic("handler cannot be nil")
+	}
+	if internal.IsNil(aggregate) {
+		panic("aggregate cannot be nil")
+	}
+	agg := aggregate.aggregate()
+	changes := agg.changes
+	if len(changes) == 0 {
+		return nil
+	}
+	store, err := resolveStore(handler)
+	if err != nil {
+		discard(handler, aggregate)
+		return err
+	}
+	options, _ := miruken.GetOptions[Options](handler)
+	format := internal.DefaultValue(options.Format, defaultFormat)
+	to, err := api.ParseMediaType(format, maps.DirectionTo)
+	if err != nil {
+		discard(handler, aggregate)
+		return fmt.Errorf("eventsource: %w", err)
+	}
+	composer := miruken.BuildUp(handler, api.Polymorphic)
+	events := make([]Event, len(changes))
+	for i, change := range changes {
+		var b bytes.Buffer
+		out := io.Writer(&b)
+		if _, _, err = maps.Into(composer, api.Message{Payload: change}, &out, to); err != nil {
+			discard(handler, aggregate)
+			return fmt.Errorf("eventsource: unable to encode %T: %w", change, err)
+		}
+		events[i] = Event{ContentType: format, Data: b.Bytes()}
+	}
+	appended, err := store.Append(agg.id, agg.version, events...)
+	if err != nil {
+		discard(handler, aggregate)
+		return err
+	}
+	agg.commit(appended[len(appended)-1].Version)
+	publish(handler, agg.id, changes)
+	return nil
+}
+
+// publish delivers the committed changes to subscribers
+// logging any failures.
+func publish(
+	handler miruken.Handler,
+	id      string,
+	changes []any,
+) {
+	logger, _, ok, err := provides.Type[logr.Logger](handler)
+	if !ok || err != nil {
+		logger = logr.Discard()
+	}
+	var promises []*promise.Promise[any]
+	for _, change := range changes {
+		if pv, err := api.Publish(handler, change); err != nil {
+			logger.Error(err, "unable to publish event",
+				"stream", id, "event", fmt.Sprintf("%T", change))
+		} else if pv != nil {
+			promises = append(promises, pv)
+		}
+	}
+	if len(promises) > 0 {
+		if _, err := promise.All(nil, promises...).Await(); err != nil {
+			logger.Error(err, "unable to publish events", "stream", id)
+		}
+	}
+}
+
+// discard evicts an aggregate with uncommitted changes
+// so the next request reloads it from the stream.
+func discard(handler miruken.Handler, aggregate Root) {
+	agg := aggregate.aggregate()
+	key := stashKey{reflect.TypeOf(agg.self).Elem(), agg.id}
+	_ = api.StashDropKey(handler, key)
+}
+
+func resolveStore(handler miruken.Handler) (EventStore, error) {
+	store, _, ok, err := provides.Type[EventStore](handler)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, ErrMissingStore
+	}
+	return store, nil
+}
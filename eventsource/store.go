@@ -0,0 +1,67 @@
+package eventsource
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type (
+	// Event is an encoded event recorded in a stream.
+	Event struct {
+		StreamId    string
+		Version     int
+		ContentType string
+		Data        []byte
+		Recorded    time.Time
+	}
+
+	// EventStore persists streams of events.
+	// Implementations must be safe for concurrent use.
+	EventStore interface {
+		// Load returns the events of the stream
+		// with a version greater than after.
+		Load(streamId string, after int) ([]Event, error)
+
+		// Append adds events to the stream if its current
+		// version matches the expected version.  The events
+		// are assigned consecutive versions.
+		Append(streamId string, expected int, events ...Event) ([]Event, error)
+	}
+
+	// ConcurrencyError reports a stream modified concurrently.
+	ConcurrencyError struct {
+		StreamId string
+		Expected int
+		Actual   int
+	}
+)
+
+var (
+	ErrMissingStore    = errors.New("eventsource: no event store is available")
+	ErrMissingStreamId = errors.New("eventsource: message has no stream id")
+)
+
+func (e *ConcurrencyError) Error() string {
+	return fmt.Sprintf("eventsource: stream %q expected version %d but found %d",
+		e.StreamId, e.Expected, e.Actual)
+}
+
+// sequence assigns stream versions to new events.
+func sequence(
+	streamId string,
+	version  int,
+	events   []Event,
+) []Event {
+	now := time.Now().UTC()
+	sequenced := make([]Event, len(events))
+	for i, event := range events {
+		event.StreamId = streamId
+		event.Version  = version + i + 1
+		if event.Recorded.IsZero() {
+			event.Recorded = now
+		}
+		sequenced[i] = event
+	}
+	return sequenced
+}
@@ -0,0 +1,446 @@
+package test
+
+import (
+	"errors"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr/funcr"
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/api/json/stdjson"
+	"github.com/miruken-go/miruken/context"
+	"github.com/miruken-go/miruken/creates"
+	"github.com/miruken-go/miruken/eventsource"
+	"github.com/miruken-go/miruken/handles"
+	"github.com/miruken-go/miruken/logs"
+	"github.com/miruken-go/miruken/provides"
+	"github.com/miruken-go/miruken/setup"
+	"github.com/stretchr/testify/suite"
+)
+
+//go:generate $GOPATH/bin/miruken -tests
+
+type (
+	OpenAccount struct {
+		AccountId string `stream:"id"`
+		Owner     string
+	}
+
+	Deposit struct {
+		AccountId string `stream:"id"`
+		Amount    int
+	}
+
+	Withdraw struct {
+		AccountId string `stream:"id"`
+		Amount    int
+	}
+
+	Freeze struct {
+		AccountId string `stream:"id"`
+	}
+
+	AccountOpened struct {
+		AccountId string
+		Owner     string
+	}
+
+	Deposited struct {
+		AccountId string
+		Amount    int
+	}
+
+	Withdrawn struct {
+		AccountId string
+		Amount    int
+	}
+
+	Frozen struct {
+		AccountId string
+		Notify    chan struct{}
+	}
+
+	Closed struct{}
+
+	Account struct {
+		eventsource.Aggregate
+		Owner   string
+		Balance int
+	}
+
+	AccountHandler struct{}
+
+	BalanceProjection struct {
+		balances map[string]int
+		lock     sync.Mutex
+	}
+
+	AuditProjection struct{}
+)
+
+var (
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrAuditUnavailable  = errors.New("audit unavailable")
+)
+
+// Account
+
+func (a *Account) ApplyOpened(opened *AccountOpened) {
+	a.Owner = opened.Owner
+}
+
+func (a *Account) ApplyDeposited(deposited Deposited) {
+	a.Balance += deposited.Amount
+}
+
+func (a *Account) ApplyWithdrawn(withdrawn *Withdrawn) {
+	a.Balance -= withdrawn.Amount
+}
+
+func (a *Account) ApplyFrozen(Frozen) {
+}
+
+// AccountHandler
+
+func (h *AccountHandler) Open(
+	_ *struct {
+		handles.It
+		eventsource.Sources[Account]
+	  }, open OpenAccount,
+	account *Account,
+) error {
+	if !account.New() {
+		return errors.New("account already open")
+	}
+	return account.Raise(AccountOpened{open.AccountId, open.Owner})
+}
+
+func (h *AccountHandler) Deposit(
+	_ *struct {
+		handles.It
+		eventsource.Sources[Account]
+	  }, deposit Deposit,
+	account *Account,
+) error {
+	return account.Raise(Deposited{deposit.AccountId, deposit.Amount})
+}
+
+func (h *AccountHandler) Withdraw(
+	_ *struct {
+		handles.It
+		eventsource.Sources[Account]
+	  }, withdraw Withdraw,
+	account *Account,
+) error {
+	if err := account.Raise(Withdrawn{withdraw.AccountId, withdraw.Amount}); err != nil {
+		return err
+	}
+	if account.Balance < 0 {
+		return ErrInsufficientFunds
+	}
+	return nil
+}
+
+func (h *AccountHandler) Freeze(
+	_ *struct {
+		handles.It
+		eventsource.Sources[Account]
+	  }, freeze Freeze,
+	account *Account,
+) error {
+	// channels cannot be encoded
+	return account.Raise(Frozen{freeze.AccountId, make(chan struct{})})
+}
+
+func (h *AccountHandler) New(
+	_ *struct {
+		_ creates.It `key:"test.AccountOpened"`
+		_ creates.It `key:"test.Deposited"`
+		_ creates.It `key:"test.Withdrawn"`
+	}, create *creates.It,
+) any {
+	switch create.Key() {
+	case "test.AccountOpened":
+		return new(AccountOpened)
+	case "test.Deposited":
+		return new(Deposited)
+	case "test.Withdrawn":
+		return new(Withdrawn)
+	}
+	return nil
+}
+
+// BalanceProjection
+
+func (p *BalanceProjection) Constructor(
+	_ *struct {
+		provides.It
+		provides.Single
+	  },
+) {
+	p.balances = make(map[string]int)
+}
+
+func (p *BalanceProjection) Deposited(
+	_ *handles.It, deposited Deposited,
+) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.balances[deposited.AccountId] += deposited.Amount
+}
+
+func (p *BalanceProjection) Withdrawn(
+	_ *handles.It, withdrawn Withdrawn,
+) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.balances[withdrawn.AccountId] -= withdrawn.Amount
+}
+
+func (p *BalanceProjection) Balance(id string) int {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	return p.balances[id]
+}
+
+// AuditProjection
+
+func (p *AuditProjection) Deposited(
+	_ *handles.It, deposited Deposited,
+) error {
+	if deposited.Amount > 1000 {
+		return ErrAuditUnavailable
+	}
+	return nil
+}
+
+type EventSourceTestSuite struct {
+	suite.Suite
+}
+
+func (suite *EventSourceTestSuite) Setup(
+	store eventsource.EventStore,
+) *context.Context {
+	ctx, err := setup.New(
+		TestFeature,
+		stdjson.Feature(),
+		eventsource.Feature(eventsource.UseStore(store))).
+		Specs(&api.GoPolymorphism{}).
+		Context()
+	suite.Require().Nil(err)
+	return ctx
+}
+
+func (suite *EventSourceTestSuite) TestAggregate() {
+	suite.Run("Commands", func() {
+		store := eventsource.NewMemoryStore()
+		ctx := suite.Setup(store)
+		defer ctx.End(nil)
+
+		_, err := api.Post(ctx, OpenAccount{"A1", "Jane"})
+		suite.Nil(err)
+		_, err = api.Post(ctx, Deposit{"A1", 100})
+		suite.Nil(err)
+		_, err = api.Post(ctx, Withdraw{"A1", 30})
+		suite.Nil(err)
+
+		events, err := store.Load("A1", 0)
+		suite.Nil(err)
+		suite.Len(events, 3)
+		for i, event := range events {
+			suite.Equal("A1", event.StreamId)
+			suite.Equal(i+1, event.Version)
+			suite.Equal("application/json", event.ContentType)
+		}
+
+		account, err := eventsource.Load[Account](ctx, "A1")
+		suite.Nil(err)
+		suite.Equal("A1", account.Id())
+		suite.Equal(3, account.Version())
+		suite.Equal("Jane", account.Owner)
+		suite.Equal(70, account.Balance)
+		suite.Empty(account.Changes())
+	})
+
+	suite.Run("Rejected", func() {
+		store := eventsource.NewMemoryStore()
+		ctx := suite.Setup(store)
+		defer ctx.End(nil)
+
+		_, err := api.Post(ctx, OpenAccount{"A2", "John"})
+		suite.Nil(err)
+		_, err = api.Post(ctx, Withdraw{"A2", 10})
+		suite.ErrorIs(err, ErrInsufficientFunds)
+		_, err = api.Post(ctx, OpenAccount{"A2", "John"})
+		suite.ErrorContains(err, "account already open")
+
+		events, err := store.Load("A2", 0)
+		suite.Nil(err)
+		suite.Len(events, 1)
+	})
+
+	suite.Run("Publish", func() {
+		ctx := suite.Setup(eventsource.NewMemoryStore())
+		defer ctx.End(nil)
+
+		projection, _, ok, err := provides.Type[*BalanceProjection](ctx)
+		suite.True(ok)
+		suite.Nil(err)
+
+		_, err = api.Post(ctx, Deposit{"A3", 50})
+		suite.Nil(err)
+		_, err = api.Post(ctx, Withdraw{"A3", 20})
+		suite.Nil(err)
+		suite.Equal(30, projection.Balance("A3"))
+	})
+
+	suite.Run("Publish Failure", func() {
+		store := eventsource.NewMemoryStore()
+		var logged []string
+		logger := funcr.New(func(prefix, args string) {
+			logged = append(logged, args)
+		}, funcr.Options{})
+		ctx, err := setup.New(
+			TestFeature,
+			stdjson.Feature(),
+			logs.Feature(logger),
+			eventsource.Feature(eventsource.UseStore(store))).
+			Specs(&api.GoPolymorphism{}).
+			Context()
+		suite.Require().Nil(err)
+		defer ctx.End(nil)
+
+		_, err = api.Post(ctx, Deposit{"A8", 5000})
+		suite.Nil(err)
+		events, err := store.Load("A8", 0)
+		suite.Nil(err)
+		suite.Len(events, 1)
+		suite.Contains(logged,
+			`"msg"="unable to publish event" "error"="audit unavailable" "stream"="A8" "event"="test.Deposited"`)
+	})
+
+	suite.Run("Stash", func() {
+		store := eventsource.NewMemoryStore()
+		ctx := suite.Setup(store)
+		defer ctx.End(nil)
+
+		stash := miruken.AddHandlers(ctx, api.NewStash(false))
+		_, err := handles.Command(stash, Deposit{"A4", 5})
+		suite.Nil(err)
+
+		// modify the stream outside the request
+		_, err = api.Post(ctx, Deposit{"A4", 1})
+		suite.Nil(err)
+
+		_, err = handles.Command(stash, Deposit{"A4", 10})
+		var concurrency *eventsource.ConcurrencyError
+		suite.ErrorAs(err, &concurrency)
+		suite.Equal(1, concurrency.Expected)
+		suite.Equal(2, concurrency.Actual)
+
+		_, err = handles.Command(stash, Deposit{"A4", 10})
+		suite.Nil(err)
+		loaded, err := eventsource.Load[Account](ctx, "A4")
+		suite.Nil(err)
+		suite.Equal(3, loaded.Version())
+		suite.Equal(16, loaded.Balance)
+	})
+
+	suite.Run("Stash Encode Failure", func() {
+		store := eventsource.NewMemoryStore()
+		ctx := suite.Setup(store)
+		defer ctx.End(nil)
+
+		stash := miruken.AddHandlers(ctx, api.NewStash(false))
+		_, err := handles.Command(stash, Freeze{"A9"})
+		suite.ErrorContains(err, "unable to encode test.Frozen")
+
+		// the aggregate with the unencodable change is discarded
+		_, err = handles.Command(stash, Deposit{"A9", 10})
+		suite.Nil(err)
+		loaded, err := eventsource.Load[Account](ctx, "A9")
+		suite.Nil(err)
+		suite.Equal(1, loaded.Version())
+		suite.Equal(10, loaded.Balance)
+	})
+
+	suite.Run("Concurrency", func() {
+		store := eventsource.NewMemoryStore()
+		ctx := suite.Setup(store)
+		defer ctx.End(nil)
+
+		first, err := eventsource.Load[Account](ctx, "A5")
+		suite.Nil(err)
+		second, err := eventsource.Load[Account](ctx, "A5")
+		suite.Nil(err)
+		suite.Nil(first.Raise(Deposited{"A5", 1}))
+		suite.Nil(second.Raise(Deposited{"A5", 2}))
+		suite.Nil(eventsource.Commit(ctx, first))
+		err = eventsource.Commit(ctx, second)
+		var concurrency *eventsource.ConcurrencyError
+		suite.ErrorAs(err, &concurrency)
+		suite.Equal(0, concurrency.Expected)
+		suite.Equal(1, concurrency.Actual)
+	})
+
+	suite.Run("Missing Apply", func() {
+		account, err := eventsource.New[Account]("A6")
+		suite.Nil(err)
+		err = account.Raise(Closed{})
+		var missing *eventsource.MissingApplyError
+		suite.ErrorAs(err, &missing)
+		suite.Empty(account.Changes())
+	})
+
+	suite.Run("Missing Stream", func() {
+		ctx := suite.Setup(eventsource.NewMemoryStore())
+		defer ctx.End(nil)
+
+		_, err := api.Post(ctx, Deposit{Amount: 5})
+		suite.ErrorIs(err, eventsource.ErrMissingStreamId)
+	})
+}
+
+func (suite *EventSourceTestSuite) TestFileStore() {
+	dir := suite.T().TempDir()
+	store, err := eventsource.NewFileStore(dir)
+	suite.Nil(err)
+
+	ctx := suite.Setup(store)
+	_, err = api.Post(ctx, OpenAccount{"B1", "Ann"})
+	suite.Nil(err)
+	_, err = api.Post(ctx, Deposit{"B1", 25})
+	suite.Nil(err)
+	ctx.End(nil)
+
+	// simulate an interrupted write
+	file, err := os.OpenFile(dir+"/B1.jsonl", os.O_APPEND|os.O_WRONLY, 0)
+	suite.Nil(err)
+	_, err = file.WriteString(`{"StreamId":"B1","Vers`)
+	suite.Nil(err)
+	suite.Nil(file.Close())
+
+	store, err = eventsource.NewFileStore(dir)
+	suite.Nil(err)
+	ctx = suite.Setup(store)
+	defer ctx.End(nil)
+	_, err = api.Post(ctx, Deposit{"B1", 5})
+	suite.Nil(err)
+
+	account, err := eventsource.Load[Account](ctx, "B1")
+	suite.Nil(err)
+	suite.Equal(3, account.Version())
+	suite.Equal("Ann", account.Owner)
+	suite.Equal(30, account.Balance)
+
+	_, err = store.Append("B1", 1, eventsource.Event{})
+	var concurrency *eventsource.ConcurrencyError
+	suite.ErrorAs(err, &concurrency)
+	suite.Equal(3, concurrency.Actual)
+}
+
+func TestEventSourceTestSuite(t *testing.T) {
+	suite.Run(t, new(EventSourceTestSuite))
+}
@@ -0,0 +1,58 @@
+package health
+
+import (
+	"time"
+
+	"github.com/miruken-go/miruken/setup"
+)
+
+// Installer configures health check support.
+type Installer struct {
+	options *Options
+}
+
+func (i *Installer) Install(b *setup.Builder) error {
+	if b.Tag(&featureTag) {
+		b.Specs(&Service{})
+		if options := i.options; options != nil {
+			b.With(options)
+		}
+	}
+	return nil
+}
+
+func (i *Installer) serviceOptions() *Options {
+	if i.options == nil {
+		i.options = &Options{}
+	}
+	return i.options
+}
+
+// Timeout bounds the duration of each HealthCheck.
+func Timeout(timeout time.Duration) func(*Installer) {
+	return func(installer *Installer) {
+		installer.serviceOptions().Timeout = timeout
+	}
+}
+
+// CacheDuration sets how long a HealthCheck Result is reused.
+// A negative duration disables caching.
+func CacheDuration(duration time.Duration) func(*Installer) {
+	return func(installer *Installer) {
+		installer.serviceOptions().CacheDuration = duration
+	}
+}
+
+// Feature configures health check support.
+// Options are also loaded from the "health" configuration.
+func Feature(config ...func(*Installer)) setup.Feature {
+	installer := &Installer{}
+	for _, configure := range config {
+		if configure != nil {
+			configure(installer)
+		}
+	}
+	return installer
+}
+
+var featureTag byte
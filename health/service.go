@@ -0,0 +1,255 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/args"
+	"github.com/miruken-go/miruken/config"
+	"github.com/miruken-go/miruken/internal"
+	"github.com/miruken-go/miruken/provides"
+	"github.com/miruken-go/miruken/setup"
+)
+
+type (
+	// HealthCheck reports the health of a resource the application
+	// depends on.  All HealthCheck's are resolved using ResolveAll
+	// and affect readiness.  HealthCheck's that implement Liveness
+	// and return true also affect liveness.
+	HealthCheck interface {
+		Name() string
+		Check(ctx context.Context) error
+	}
+
+	// Liveness is implemented by HealthCheck's that determine if the
+	// application is alive and should not be restarted.
+	Liveness interface {
+		Liveness() bool
+	}
+
+	// Status is the outcome of a HealthCheck.
+	Status string
+
+	// Result is the outcome of a single HealthCheck.
+	Result struct {
+		Name   string `json:"name"`
+		Status Status `json:"status"`
+		Error  string `json:"error,omitempty"`
+	}
+
+	// Report is the aggregate outcome of the HealthCheck's.
+	// Stage is the setup.Stage of the application
+	// and is only reported for readiness.
+	Report struct {
+		Status Status   `json:"status"`
+		Stage  string   `json:"stage,omitempty"`
+		Checks []Result `json:"checks,omitempty"`
+	}
+
+	// Options configures the Service.
+	// Timeout bounds the duration of each HealthCheck.
+	// CacheDuration is how long a Result is reused and
+	// disables caching when negative.
+	Options struct {
+		Timeout       time.Duration
+		CacheDuration time.Duration
+	}
+
+	// Service aggregates the HealthCheck's into a Report.
+	Service struct {
+		options Options
+		cache   map[string]cachedResult
+		lock    sync.Mutex
+	}
+
+	// cachedResult is a Result reused until it expires.
+	cachedResult struct {
+		result  Result
+		expires time.Time
+	}
+)
+
+const (
+	StatusUp   Status = "up"
+	StatusDown Status = "down"
+)
+
+const (
+	DefaultTimeout       = 5 * time.Second
+	DefaultCacheDuration = time.Second
+)
+
+func (s *Service) Constructor(
+	_ *struct {
+		provides.It
+		provides.Single
+	  },
+	_ *struct {
+		args.Optional
+		config.Load `path:"health"`
+	  }, cfg Options,
+	_ *struct{ args.Optional }, options *Options,
+) {
+	if options != nil {
+		cfg.Timeout       = internal.DefaultValue(options.Timeout, cfg.Timeout)
+		cfg.CacheDuration = internal.DefaultValue(options.CacheDuration, cfg.CacheDuration)
+	}
+	s.init(cfg)
+}
+
+// Live reports if the application is alive.
+// Liveness is independent of the setup.Stage
+// so an application starting or stopping is alive.
+func (s *Service) Live(
+	ctx     context.Context,
+	handler miruken.Handler,
+) Report {
+	checks, err := s.checks(handler)
+	if err != nil {
+		return Report{Status: StatusDown, Checks: []Result{failed("resolve", err)}}
+	}
+	var live []HealthCheck
+	for _, check := range checks {
+		if l, ok := check.(Liveness); ok && l.Liveness() {
+			live = append(live, check)
+		}
+	}
+	return s.run(ctx, live)
+}
+
+// Ready reports if the application is ready to accept requests.
+// The application is not ready until all setup.Bootstrap's have
+// started and stops being ready when shutdown begins.
+func (s *Service) Ready(
+	ctx     context.Context,
+	handler miruken.Handler,
+) Report {
+	checks, err := s.checks(handler)
+	if err != nil {
+		return Report{Status: StatusDown, Checks: []Result{failed("resolve", err)}}
+	}
+	report := s.run(ctx, checks)
+	if lifecycle, _, ok, err := provides.Type[*setup.Lifecycle](handler); ok && err == nil {
+		report.Stage = lifecycle.Stage().String()
+		if !lifecycle.Started() {
+			report.Status = StatusDown
+		}
+	}
+	return report
+}
+
+func (s *Service) init(options Options) {
+	options.Timeout       = internal.DefaultValue(options.Timeout, DefaultTimeout)
+	options.CacheDuration = internal.DefaultValue(options.CacheDuration, DefaultCacheDuration)
+	s.options = options
+	s.cache   = make(map[string]cachedResult)
+}
+
+func (s *Service) checks(handler miruken.Handler) ([]HealthCheck, error) {
+	checks, pc, err := miruken.ResolveAll[HealthCheck](handler)
+	if err == nil && pc != nil {
+		checks, err = pc.Await()
+	}
+	return checks, err
+}
+
+// run performs the HealthCheck's concurrently reusing unexpired Result's.
+func (s *Service) run(
+	ctx    context.Context,
+	checks []HealthCheck,
+) Report {
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, check := range checks {
+		if result, ok := s.cached(check.Name()); ok {
+			results[i] = result
+			continue
+		}
+		wg.Add(1)
+		go func(i int, check HealthCheck) {
+			defer wg.Done()
+			result := s.check(ctx, check)
+			if ctx.Err() == nil {
+				// failures of abandoned requests are not cached
+				s.store(result)
+			}
+			results[i] = result
+		}(i, check)
+	}
+	wg.Wait()
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].Name < results[j].Name
+	})
+	report := Report{Status: StatusUp, Checks: results}
+	for _, result := range results {
+		if result.Status != StatusUp {
+			report.Status = StatusDown
+			break
+		}
+	}
+	return report
+}
+
+// check performs the HealthCheck failing it if the timeout
+// expires even if the HealthCheck ignores the context.
+func (s *Service) check(
+	ctx   context.Context,
+	check HealthCheck,
+) Result {
+	ctx, cancel := context.WithTimeout(ctx, s.options.Timeout)
+	defer cancel()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				done <- fmt.Errorf("health: check panicked: %v", r)
+			}
+		}()
+		done <- check.Check(ctx)
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return failed(check.Name(), err)
+		}
+		return Result{Name: check.Name(), Status: StatusUp}
+	case <-ctx.Done():
+		return failed(check.Name(), ctx.Err())
+	}
+}
+
+func (s *Service) cached(name string) (Result, bool) {
+	if s.options.CacheDuration < 0 {
+		return Result{}, false
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if entry, ok := s.cache[name]; ok && time.Now().Before(entry.expires) {
+		return entry.result, true
+	}
+	return Result{}, false
+}
+
+func (s *Service) store(result Result) {
+	if s.options.CacheDuration < 0 {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.cache[result.Name] = cachedResult{result, time.Now().Add(s.options.CacheDuration)}
+}
+
+// NewService creates a Service from the options.
+func NewService(options Options) *Service {
+	s := &Service{}
+	s.init(options)
+	return s
+}
+
+func failed(name string, err error) Result {
+	return Result{Name: name, Status: StatusDown, Error: err.Error()}
+}
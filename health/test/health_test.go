@@ -0,0 +1,223 @@
+package test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miruken-go/miruken"
+	context2 "github.com/miruken-go/miruken/context"
+	"github.com/miruken-go/miruken/health"
+	"github.com/miruken-go/miruken/promise"
+	"github.com/miruken-go/miruken/provides"
+	"github.com/miruken-go/miruken/setup"
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	DatabaseCheck struct {
+		calls int32
+		down  atomic.Bool
+	}
+
+	ProcessCheck struct{}
+
+	SlowCheck struct{}
+
+	ProbeBootstrap struct {
+		starting health.Report
+		live     health.Report
+	}
+)
+
+var errDatabaseDown = errors.New("database unreachable")
+
+// DatabaseCheck
+
+func (c *DatabaseCheck) Constructor(
+	_ *struct {
+		provides.It
+		provides.Single
+	  },
+) {
+}
+
+func (c *DatabaseCheck) Name() string {
+	return "database"
+}
+
+func (c *DatabaseCheck) Check(context.Context) error {
+	atomic.AddInt32(&c.calls, 1)
+	if c.down.Load() {
+		return errDatabaseDown
+	}
+	return nil
+}
+
+// ProcessCheck
+
+func (c *ProcessCheck) Name() string {
+	return "process"
+}
+
+func (c *ProcessCheck) Check(context.Context) error {
+	return nil
+}
+
+func (c *ProcessCheck) Liveness() bool {
+	return true
+}
+
+// SlowCheck
+
+func (c *SlowCheck) Name() string {
+	return "slow"
+}
+
+func (c *SlowCheck) Check(context.Context) error {
+	time.Sleep(200 * time.Millisecond)
+	return nil
+}
+
+// ProbeBootstrap
+
+func (b *ProbeBootstrap) Constructor(
+	_ *struct {
+		provides.It
+		provides.Single
+	  },
+) {
+}
+
+func (b *ProbeBootstrap) Startup(
+	ctx context.Context,
+	h   miruken.Handler,
+) *promise.Promise[struct{}] {
+	svc, _, _, err := miruken.Resolve[*health.Service](h)
+	if err != nil {
+		return promise.Reject[struct{}](err)
+	}
+	b.starting = svc.Ready(ctx, h)
+	b.live     = svc.Live(ctx, h)
+	return promise.Empty()
+}
+
+func (b *ProbeBootstrap) Shutdown(
+	ctx context.Context,
+) *promise.Promise[struct{}] {
+	return promise.Empty()
+}
+
+type HealthTestSuite struct {
+	suite.Suite
+}
+
+func (suite *HealthTestSuite) Setup(
+	config []func(*health.Installer),
+	specs  ...any,
+) (*context2.Context, *health.Service) {
+	ctx, err := setup.New(health.Feature(config...)).
+		Specs(specs...).
+		Context()
+	suite.Require().Nil(err)
+	suite.T().Cleanup(func() { ctx.End(nil) })
+	svc, _, ok, err := miruken.Resolve[*health.Service](ctx)
+	suite.Require().True(ok)
+	suite.Require().Nil(err)
+	return ctx, svc
+}
+
+func (suite *HealthTestSuite) database(h miruken.Handler) *DatabaseCheck {
+	db, _, ok, err := miruken.Resolve[*DatabaseCheck](h)
+	suite.Require().True(ok)
+	suite.Require().Nil(err)
+	return db
+}
+
+func (suite *HealthTestSuite) TestHealth() {
+	noCache := []func(*health.Installer){health.CacheDuration(-1)}
+
+	suite.Run("Ready", func() {
+		h, svc := suite.Setup(noCache, &DatabaseCheck{}, &ProcessCheck{})
+		report := svc.Ready(context.Background(), h)
+		suite.Equal(health.StatusUp, report.Status)
+		suite.Equal("started", report.Stage)
+		suite.Equal([]health.Result{
+			{Name: "database", Status: health.StatusUp},
+			{Name: "process", Status: health.StatusUp},
+		}, report.Checks)
+	})
+
+	suite.Run("NotReady", func() {
+		h, svc := suite.Setup(noCache, &DatabaseCheck{}, &ProcessCheck{})
+		suite.database(h).down.Store(true)
+		ready := svc.Ready(context.Background(), h)
+		suite.Equal(health.StatusDown, ready.Status)
+		suite.Equal(health.Result{
+			Name:   "database",
+			Status: health.StatusDown,
+			Error:  errDatabaseDown.Error(),
+		}, ready.Checks[0])
+
+		live := svc.Live(context.Background(), h)
+		suite.Equal(health.StatusUp, live.Status)
+		suite.Empty(live.Stage)
+		suite.Equal([]health.Result{{Name: "process", Status: health.StatusUp}}, live.Checks)
+	})
+
+	suite.Run("Timeout", func() {
+		h, svc := suite.Setup(
+			[]func(*health.Installer){health.Timeout(20 * time.Millisecond)},
+			&SlowCheck{}, &ProcessCheck{})
+		start := time.Now()
+		report := svc.Ready(context.Background(), h)
+		suite.Less(time.Since(start), 150*time.Millisecond)
+		suite.Equal(health.StatusDown, report.Status)
+		suite.Equal(health.StatusDown, report.Checks[1].Status)
+		suite.Equal(context.DeadlineExceeded.Error(), report.Checks[1].Error)
+	})
+
+	suite.Run("Cache", func() {
+		h, svc := suite.Setup(
+			[]func(*health.Installer){health.CacheDuration(time.Hour)}, &DatabaseCheck{})
+		db := suite.database(h)
+		suite.Equal(health.StatusUp, svc.Ready(context.Background(), h).Status)
+		db.down.Store(true)
+		suite.Equal(health.StatusUp, svc.Ready(context.Background(), h).Status)
+		suite.Equal(int32(1), atomic.LoadInt32(&db.calls))
+	})
+
+	suite.Run("Lifecycle", func() {
+		ctx, err := setup.New(health.Feature(noCache...)).
+			Specs(&ProcessCheck{}, &ProbeBootstrap{}).
+			Context()
+		suite.Require().Nil(err)
+		probe, _, _, err := miruken.Resolve[*ProbeBootstrap](ctx)
+		suite.Require().Nil(err)
+
+		suite.Equal(health.StatusDown, probe.starting.Status)
+		suite.Equal("starting", probe.starting.Stage)
+		suite.Equal(health.StatusUp, probe.live.Status)
+
+		svc, _, _, _ := miruken.Resolve[*health.Service](ctx)
+		suite.Equal(health.StatusUp, svc.Ready(context.Background(), ctx).Status)
+
+		lifecycle, _, ok, err := miruken.Resolve[*setup.Lifecycle](ctx)
+		suite.True(ok)
+		suite.Nil(err)
+		var stopping health.Report
+		ctx.Observe(context2.EndingObserverFunc(func(*context2.Context, any) {
+			stopping = svc.Ready(context.Background(), ctx)
+		}))
+		ctx.End(nil)
+		suite.Equal(health.StatusDown, stopping.Status)
+		suite.Equal("stopping", stopping.Stage)
+		suite.Equal(setup.StageStopped, lifecycle.Stage())
+	})
+}
+
+func TestHealthTestSuite(t *testing.T) {
+	suite.Run(t, new(HealthTestSuite))
+}
@@ -54,6 +54,16 @@ func (m *It) SetMatched(format *Format) {
 	m.match = format
 }
 
+// Format returns the format requested by the mapping, if any.
+func (m *It) Format() *Format {
+	for _, constraint := range m.Constraints() {
+		if format, ok := constraint.(*Format); ok {
+			return format
+		}
+	}
+	return nil
+}
+
 func (m *It) Dispatch(
 	handler  any,
 	greedy   bool,
@@ -0,0 +1,50 @@
+package outbox
+
+import (
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/setup"
+)
+
+// Installer configures transactional outbox support.
+type Installer struct {
+	store Store
+}
+
+func (i *Installer) SetStore(store Store) {
+	i.store = store
+}
+
+func (i *Installer) DependsOn() []setup.Feature {
+	return []setup.Feature{api.Feature()}
+}
+
+func (i *Installer) Install(b *setup.Builder) error {
+	if b.Tag(&featureTag) {
+		store := i.store
+		if store == nil {
+			store = NewMemoryStore()
+		}
+		b.Specs(&Relay{}).With(store)
+	}
+	return nil
+}
+
+// UseStore configures the Store holding outbox entries.
+func UseStore(store Store) func(*Installer) {
+	return func(installer *Installer) {
+		installer.SetStore(store)
+	}
+}
+
+// Feature configures transactional outbox support.
+func Feature(config ...func(*Installer)) setup.Feature {
+	installer := &Installer{}
+	for _, configure := range config {
+		if configure != nil {
+			configure(installer)
+		}
+	}
+	return installer
+}
+
+var featureTag byte
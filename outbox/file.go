@@ -0,0 +1,208 @@
+package outbox
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileStore is a Store that keeps each entry in a json
+// file within a directory.  Files are replaced atomically
+// so entries survive a process crash or restart.
+type FileStore struct {
+	dir  string
+	lock sync.Mutex
+}
+
+const fileExt = ".json"
+
+func (s *FileStore) Dir() string {
+	return s.dir
+}
+
+func (s *FileStore) Append(entries ...Entry) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	// stage every entry before renaming any so the
+	// entries are appended together or not at all
+	tmps := make([]string, 0, len(entries))
+	defer func() {
+		for _, tmp := range tmps {
+			_ = os.Remove(tmp)
+		}
+	}()
+	for i := range entries {
+		tmp, err := s.stage(&entries[i])
+		if err != nil {
+			return err
+		}
+		tmps = append(tmps, tmp)
+	}
+	for i, tmp := range tmps {
+		if err := os.Rename(tmp, s.path(entries[i].Id)); err != nil {
+			for _, entry := range entries[:i] {
+				_ = os.Remove(s.path(entry.Id))
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *FileStore) Pending(
+	now   time.Time,
+	limit int,
+) ([]Entry, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.collect(func(e *Entry) bool {
+		return e.due(now)
+	}, limit)
+}
+
+func (s *FileStore) Complete(id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := os.Remove(s.path(id)); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return &EntryNotFoundError{id}
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *FileStore) Fail(
+	id      string,
+	cause   error,
+	retryAt time.Time,
+) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	entry, err := s.read(s.path(id))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return &EntryNotFoundError{id}
+		}
+		return err
+	}
+	entry.fail(cause, retryAt)
+	return s.write(entry)
+}
+
+// Dead returns the entries that exhausted all delivery attempts.
+func (s *FileStore) Dead() ([]Entry, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.collect(func(e *Entry) bool {
+		return e.Status == StatusDead
+	}, 0)
+}
+
+func (s *FileStore) collect(
+	include func(*Entry) bool,
+	limit   int,
+) ([]Entry, error) {
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var matches []*Entry
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), fileExt) {
+			continue
+		}
+		entry, err := s.read(filepath.Join(s.dir, file.Name()))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		if include(entry) {
+			matches = append(matches, entry)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].before(matches[j])
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	entries := make([]Entry, len(matches))
+	for i, entry := range matches {
+		entries[i] = *entry
+	}
+	return entries, nil
+}
+
+func (s *FileStore) read(path string) (*Entry, error) {
+	byt, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var entry Entry
+	if err = json.Unmarshal(byt, &entry); err != nil {
+		return nil, err
+	}
+	return &entry, nil
+}
+
+// write replaces the entry file atomically by renaming
+// a fully written temporary file over it.
+func (s *FileStore) write(entry *Entry) error {
+	tmp, err := s.stage(entry)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp)
+	}()
+	return os.Rename(tmp, s.path(entry.Id))
+}
+
+// stage writes the entry to a temporary file ignored by
+// collect and returns its name.
+func (s *FileStore) stage(entry *Entry) (string, error) {
+	byt, err := json.Marshal(entry)
+	if err != nil {
+		return "", err
+	}
+	tmp, err := os.CreateTemp(s.dir, entry.Id+".*.tmp")
+	if err != nil {
+		return "", err
+	}
+	if _, err = tmp.Write(byt); err == nil {
+		err = tmp.Sync()
+	}
+	if ce := tmp.Close(); err == nil {
+		err = ce
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return "", err
+	}
+	return tmp.Name(), nil
+}
+
+func (s *FileStore) path(id string) string {
+	return filepath.Join(s.dir, id+fileExt)
+}
+
+// NewFileStore creates a new FileStore in the supplied directory.
+// The directory is created if it does not exist.
+func NewFileStore(dir string) (*FileStore, error) {
+	if dir == "" {
+		panic("dir cannot be empty")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
@@ -0,0 +1,104 @@
+package outbox
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a Store that keeps entries in memory.
+// Entries do not survive a process restart so it is
+// mostly suitable for testing.
+type MemoryStore struct {
+	entries map[string]*Entry
+	lock    sync.Mutex
+}
+
+func (s *MemoryStore) Append(entries ...Entry) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, entry := range entries {
+		e := entry
+		s.entries[e.Id] = &e
+	}
+	return nil
+}
+
+func (s *MemoryStore) Pending(
+	now   time.Time,
+	limit int,
+) ([]Entry, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.collect(func(e *Entry) bool {
+		return e.due(now)
+	}, limit), nil
+}
+
+func (s *MemoryStore) Complete(id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.entries[id]; !ok {
+		return &EntryNotFoundError{id}
+	}
+	delete(s.entries, id)
+	return nil
+}
+
+func (s *MemoryStore) Fail(
+	id      string,
+	cause   error,
+	retryAt time.Time,
+) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if entry, ok := s.entries[id]; ok {
+		entry.fail(cause, retryAt)
+		return nil
+	}
+	return &EntryNotFoundError{id}
+}
+
+// Dead returns the entries that exhausted all delivery attempts.
+func (s *MemoryStore) Dead() ([]Entry, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.collect(func(e *Entry) bool {
+		return e.Status == StatusDead
+	}, 0), nil
+}
+
+// Len returns the number of undelivered entries.
+func (s *MemoryStore) Len() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.entries)
+}
+
+func (s *MemoryStore) collect(
+	include func(*Entry) bool,
+	limit   int,
+) []Entry {
+	var matches []*Entry
+	for _, entry := range s.entries {
+		if include(entry) {
+			matches = append(matches, entry)
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		return matches[i].before(matches[j])
+	})
+	if limit > 0 && len(matches) > limit {
+		matches = matches[:limit]
+	}
+	entries := make([]Entry, len(matches))
+	for i, entry := range matches {
+		entries[i] = *entry
+	}
+	return entries
+}
+
+// NewMemoryStore creates a new MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]*Entry)}
+}
@@ -0,0 +1,119 @@
+package outbox
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/internal"
+	"github.com/miruken-go/miruken/maps"
+	"github.com/miruken-go/miruken/promise"
+	"github.com/miruken-go/miruken/provides"
+)
+
+// Messages is a miruken.Effect for storing api messages
+// in the outbox.  The messages are delivered by the Relay
+// after they have been safely persisted.
+type Messages struct {
+	messages []any
+	store    Store
+	publish  bool
+}
+
+func (m *Messages) WithStore(
+	store Store,
+) *Messages {
+	m.store = store
+	return m
+}
+
+func (m *Messages) Apply(
+	ctx miruken.HandleContext,
+) (promise.Reflect, error) {
+	messages := m.messages
+	if len(messages) == 0 {
+		return nil, nil
+	}
+	composer := ctx.Composer
+	store := m.store
+	if internal.IsNil(store) {
+		s, _, ok, err := provides.Type[Store](composer)
+		if err != nil {
+			return nil, err
+		} else if !ok {
+			return nil, ErrMissingStore
+		}
+		store = s
+	}
+	if err := Enqueue(composer, store, m.publish, messages...); err != nil {
+		return nil, err
+	}
+	if relay, _, ok, err := provides.Type[*Relay](composer); ok && err == nil {
+		relay.Notify()
+	}
+	return nil, nil
+}
+
+// Enqueue encodes the messages and appends them to the Store.
+// All messages are appended together or not at all.
+func Enqueue(
+	handler  miruken.Handler,
+	store    Store,
+	publish  bool,
+	messages ...any,
+) error {
+	if internal.IsNil(handler) {
+		panic("handler cannot be nil")
+	}
+	if internal.IsNil(store) {
+		panic("store cannot be nil")
+	}
+	if len(messages) == 0 {
+		return nil
+	}
+	options, _ := miruken.GetOptions[Options](handler)
+	format := internal.DefaultValue(options.Format, defaultFormat)
+	to, err := api.ParseMediaType(format, maps.DirectionTo)
+	if err != nil {
+		return fmt.Errorf("outbox: %w", err)
+	}
+	composer := miruken.BuildUp(handler, api.Polymorphic)
+	now := time.Now().UTC()
+	entries := make([]Entry, len(messages))
+	for i, message := range messages {
+		if internal.IsNil(message) {
+			panic("message cannot be nil")
+		}
+		var b bytes.Buffer
+		out := io.Writer(&b)
+		msg := api.Message{Payload: message}
+		if _, _, err = maps.Into(composer, msg, &out, to); err != nil {
+			return fmt.Errorf("outbox: unable to encode %T: %w", message, err)
+		}
+		// offset creation to preserve the order of the batch
+		created := now.Add(time.Duration(i))
+		entries[i] = Entry{
+			Id:          uuid.NewString(),
+			ContentType: format,
+			Body:        b.Bytes(),
+			Publish:     publish,
+			Created:     created,
+			NextAttempt: created,
+		}
+	}
+	return store.Append(entries...)
+}
+
+// Post is a fluent builder for storing Messages to post.
+func Post(messages ...any) *Messages {
+	return &Messages{messages: messages}
+}
+
+// Publish is a fluent builder for storing Messages to publish.
+func Publish(messages ...any) *Messages {
+	return &Messages{messages: messages, publish: true}
+}
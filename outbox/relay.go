@@ -0,0 +1,223 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/args"
+	"github.com/miruken-go/miruken/internal"
+	"github.com/miruken-go/miruken/maps"
+	"github.com/miruken-go/miruken/promise"
+	"github.com/miruken-go/miruken/provides"
+)
+
+type (
+	// Options customize the outbox.
+	Options struct {
+		Format        string
+		PollInterval  time.Duration
+		BatchSize     int
+		MaxAttempts   int
+		RetryDelay    time.Duration
+		MaxRetryDelay time.Duration
+	}
+
+	// Relay is a setup.Bootstrap that delivers pending outbox
+	// entries in the background.  Failed deliveries are retried
+	// with exponential backoff until MaxAttempts is reached and
+	// the entry is marked dead.
+	Relay struct {
+		store   Store
+		options Options
+		logger  logr.Logger
+		handler miruken.Handler
+		wake    chan struct{}
+		stop    chan struct{}
+		done    chan struct{}
+		once    sync.Once
+	}
+)
+
+const (
+	defaultFormat        = "application/json"
+	defaultPollInterval  = time.Second
+	defaultBatchSize     = 100
+	defaultMaxAttempts   = 10
+	defaultRetryDelay    = time.Second
+	defaultMaxRetryDelay = 5 * time.Minute
+)
+
+func (r *Relay) Constructor(
+	_ *struct {
+		provides.It
+		provides.Single
+	  }, store Store,
+	_ *struct {
+		args.Optional
+		args.FromOptions
+	  }, options Options,
+	_ *struct{ args.Optional }, logger logr.Logger,
+) {
+	if logger == (logr.Logger{}) {
+		logger = logr.Discard()
+	}
+	options.Format        = internal.DefaultValue(options.Format, defaultFormat)
+	options.PollInterval  = internal.DefaultValue(options.PollInterval, defaultPollInterval)
+	options.BatchSize     = internal.DefaultValue(options.BatchSize, defaultBatchSize)
+	options.MaxAttempts   = internal.DefaultValue(options.MaxAttempts, defaultMaxAttempts)
+	options.RetryDelay    = internal.DefaultValue(options.RetryDelay, defaultRetryDelay)
+	options.MaxRetryDelay = internal.DefaultValue(options.MaxRetryDelay, defaultMaxRetryDelay)
+
+	r.store   = store
+	r.options = options
+	r.logger  = logger
+	r.wake    = make(chan struct{}, 1)
+	r.stop    = make(chan struct{})
+	r.done    = make(chan struct{})
+}
+
+func (r *Relay) Store() Store {
+	return r.store
+}
+
+func (r *Relay) Startup(
+	ctx context.Context,
+	h   miruken.Handler,
+) *promise.Promise[struct{}] {
+	r.handler = h
+	go r.run()
+	return promise.Empty()
+}
+
+func (r *Relay) Shutdown(
+	ctx context.Context,
+) *promise.Promise[struct{}] {
+	r.once.Do(func() { close(r.stop) })
+	return promise.New(ctx, func(
+		resolve func(struct{}), reject func(error), onCancel func(func()),
+	) {
+		select {
+		case <-r.done:
+			resolve(struct{}{})
+		case <-ctx.Done():
+			reject(ctx.Err())
+		}
+	})
+}
+
+// Notify signals the Relay to deliver pending entries
+// without waiting for the next poll.
+func (r *Relay) Notify() {
+	select {
+	case r.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (r *Relay) run() {
+	defer close(r.done)
+	ticker := time.NewTicker(r.options.PollInterval)
+	defer ticker.Stop()
+	for {
+		r.deliver()
+		select {
+		case <-r.stop:
+			return
+		case <-ticker.C:
+		case <-r.wake:
+		}
+	}
+}
+
+// deliver drains all entries currently due for delivery.
+func (r *Relay) deliver() {
+	for {
+		entries, err := r.store.Pending(time.Now().UTC(), r.options.BatchSize)
+		if err != nil {
+			r.logger.Error(err, "unable to read pending outbox entries")
+			return
+		}
+		if len(entries) == 0 {
+			return
+		}
+		for i := range entries {
+			select {
+			case <-r.stop:
+				return
+			default:
+			}
+			// unrecorded entries are still pending, so wait
+			// for the next poll rather than send them again
+			if err = r.send(&entries[i]); err != nil {
+				return
+			}
+		}
+		if len(entries) < r.options.BatchSize {
+			return
+		}
+	}
+}
+
+// send dispatches the entry and records the outcome in the
+// Store, returning the error if it could not be recorded.
+func (r *Relay) send(entry *Entry) error {
+	err := r.dispatch(entry)
+	if err == nil {
+		if err = r.store.Complete(entry.Id); err != nil {
+			r.logger.Error(err, "unable to complete outbox entry", "id", entry.Id)
+		}
+		return err
+	}
+	var retryAt time.Time
+	if attempts := entry.Attempts + 1; attempts < r.options.MaxAttempts {
+		retryAt = time.Now().UTC().Add(r.backoff(attempts))
+	} else {
+		r.logger.Error(err, "outbox entry is dead", "id", entry.Id, "attempts", attempts)
+	}
+	if err = r.store.Fail(entry.Id, err, retryAt); err != nil {
+		r.logger.Error(err, "unable to fail outbox entry", "id", entry.Id)
+	}
+	return err
+}
+
+func (r *Relay) dispatch(entry *Entry) (err error) {
+	defer func() {
+		if rc := recover(); rc != nil {
+			err = fmt.Errorf("outbox: panic delivering entry %q: %v", entry.Id, rc)
+		}
+	}()
+	from, err := api.ParseMediaType(entry.ContentType, maps.DirectionFrom)
+	if err != nil {
+		return err
+	}
+	composer := miruken.BuildUp(r.handler, api.Polymorphic)
+	msg, _, _, err := maps.Out[api.Message](composer, bytes.NewReader(entry.Body), from)
+	if err != nil {
+		return err
+	}
+	var pv *promise.Promise[any]
+	if entry.Publish {
+		pv, err = api.Publish(r.handler, msg.Payload)
+	} else {
+		pv, err = api.Post(r.handler, msg.Payload)
+	}
+	if err == nil && pv != nil {
+		_, err = pv.Await()
+	}
+	return err
+}
+
+// backoff computes the exponential delay before the next attempt.
+func (r *Relay) backoff(attempts int) time.Duration {
+	delay := r.options.RetryDelay
+	for i := 1; i < attempts && delay < r.options.MaxRetryDelay; i++ {
+		delay *= 2
+	}
+	return min(delay, r.options.MaxRetryDelay)
+}
@@ -0,0 +1,89 @@
+package outbox
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type (
+	// Status enumerates the delivery states of an Entry.
+	Status uint8
+
+	// Entry is an encoded message awaiting delivery.
+	Entry struct {
+		Id          string
+		ContentType string
+		Body        []byte
+		Publish     bool
+		Status      Status
+		Attempts    int
+		Created     time.Time
+		NextAttempt time.Time
+		LastError   string
+	}
+
+	// Store persists outbox entries until they are delivered.
+	// Implementations must be safe for concurrent use.
+	Store interface {
+		// Append persists new entries.
+		Append(entries ...Entry) error
+
+		// Pending returns up to limit entries due for
+		// delivery at the supplied time ordered by creation.
+		Pending(now time.Time, limit int) ([]Entry, error)
+
+		// Complete marks an entry as delivered.
+		Complete(id string) error
+
+		// Fail records an unsuccessful delivery attempt.
+		// The entry is scheduled for another attempt at retryAt
+		// or marked dead if retryAt is the zero time.
+		Fail(id string, cause error, retryAt time.Time) error
+
+		// Dead returns the entries that exhausted all delivery attempts.
+		Dead() ([]Entry, error)
+	}
+
+	// EntryNotFoundError reports a missing outbox entry.
+	EntryNotFoundError struct {
+		Id string
+	}
+)
+
+const (
+	StatusPending = Status(iota)
+	StatusDead
+)
+
+var ErrMissingStore = errors.New("outbox: no store is available")
+
+func (e *EntryNotFoundError) Error() string {
+	return fmt.Sprintf("outbox: entry %q not found", e.Id)
+}
+
+// due returns true if the entry is pending and ready for delivery.
+func (e *Entry) due(now time.Time) bool {
+	return e.Status == StatusPending && !e.NextAttempt.After(now)
+}
+
+// fail updates the entry after an unsuccessful delivery attempt.
+func (e *Entry) fail(cause error, retryAt time.Time) {
+	e.Attempts++
+	if cause != nil {
+		e.LastError = cause.Error()
+	}
+	if retryAt.IsZero() {
+		e.Status = StatusDead
+	} else {
+		e.NextAttempt = retryAt
+	}
+}
+
+// before orders entries by creation time then id.
+func (e *Entry) before(other *Entry) bool {
+	if e.Created.Equal(other.Created) {
+		return e.Id < other.Id
+	}
+	return e.Created.Before(other.Created)
+}
@@ -0,0 +1,363 @@
+package test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/api/json/stdjson"
+	"github.com/miruken-go/miruken/context"
+	"github.com/miruken-go/miruken/creates"
+	"github.com/miruken-go/miruken/handles"
+	"github.com/miruken-go/miruken/outbox"
+	"github.com/miruken-go/miruken/provides"
+	"github.com/miruken-go/miruken/setup"
+	"github.com/stretchr/testify/suite"
+)
+
+//go:generate $GOPATH/bin/miruken -tests
+
+type (
+	PlaceOrder struct {
+		Id   int
+		Item string
+	}
+
+	OrderPlaced struct {
+		Id   int
+		Item string
+	}
+
+	ShipOrder struct {
+		Id int
+	}
+
+	OrderHandler struct{}
+
+	OrderConsumer struct {
+		failures int
+		placed   []OrderPlaced
+		shipped  []int
+		lock     sync.Mutex
+	}
+
+	// FailingStore fails to record delivered entries.
+	FailingStore struct {
+		*outbox.MemoryStore
+		completes int32
+	}
+)
+
+// OrderHandler
+
+func (h *OrderHandler) Place(
+	_ *handles.It, place PlaceOrder,
+) (int, *outbox.Messages) {
+	return place.Id, outbox.Publish(&OrderPlaced{place.Id, place.Item})
+}
+
+func (h *OrderHandler) New(
+	_ *struct {
+		_ creates.It `key:"test.OrderPlaced"`
+		_ creates.It `key:"test.ShipOrder"`
+	}, create *creates.It,
+) any {
+	switch create.Key() {
+	case "test.OrderPlaced":
+		return new(OrderPlaced)
+	case "test.ShipOrder":
+		return new(ShipOrder)
+	}
+	return nil
+}
+
+// OrderConsumer
+
+func (c *OrderConsumer) Constructor(
+	_ *struct {
+		provides.It
+		provides.Single
+	  },
+) {
+}
+
+func (c *OrderConsumer) Fail(failures int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.failures = failures
+}
+
+func (c *OrderConsumer) Placed(
+	_ *handles.It, placed *OrderPlaced,
+) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.failures > 0 {
+		c.failures--
+		return errors.New("consumer unavailable")
+	}
+	c.placed = append(c.placed, *placed)
+	return nil
+}
+
+func (c *OrderConsumer) Ship(
+	_ *handles.It, ship *ShipOrder,
+) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.shipped = append(c.shipped, ship.Id)
+}
+
+func (c *OrderConsumer) Placements() []OrderPlaced {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]OrderPlaced(nil), c.placed...)
+}
+
+func (c *OrderConsumer) Shipments() []int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]int(nil), c.shipped...)
+}
+
+// FailingStore
+
+func (s *FailingStore) Complete(string) error {
+	atomic.AddInt32(&s.completes, 1)
+	return errors.New("store unavailable")
+}
+
+func (s *FailingStore) Completes() int {
+	return int(atomic.LoadInt32(&s.completes))
+}
+
+type OutboxTestSuite struct {
+	suite.Suite
+}
+
+func (suite *OutboxTestSuite) Setup(
+	store   outbox.Store,
+	options outbox.Options,
+) (*context.Context, *OrderConsumer) {
+	ctx, err := setup.New(
+		TestFeature,
+		stdjson.Feature(),
+		outbox.Feature(outbox.UseStore(store))).
+		Specs(&api.GoPolymorphism{}).
+		Options(options).
+		Context()
+	suite.Require().Nil(err)
+	consumer, _, ok, err := provides.Type[*OrderConsumer](ctx)
+	suite.Require().True(ok)
+	suite.Require().Nil(err)
+	return ctx, consumer
+}
+
+func (suite *OutboxTestSuite) TestOutbox() {
+	options := outbox.Options{
+		PollInterval: 5 * time.Millisecond,
+		RetryDelay:   time.Millisecond,
+	}
+
+	suite.Run("Effect", func() {
+		suite.Run("Publish", func() {
+			store := outbox.NewMemoryStore()
+			ctx, consumer := suite.Setup(store, options)
+			defer ctx.End(nil)
+
+			id, _, err := api.Send[int](ctx, PlaceOrder{1, "Book"})
+			suite.Nil(err)
+			suite.Equal(1, id)
+			suite.Eventually(func() bool {
+				return len(consumer.Placements()) == 1
+			}, time.Second, time.Millisecond)
+			suite.Equal([]OrderPlaced{{1, "Book"}}, consumer.Placements())
+			suite.Eventually(func() bool {
+				return store.Len() == 0
+			}, time.Second, time.Millisecond)
+		})
+
+		suite.Run("Missing Store", func() {
+			ctx, err := setup.New(TestFeature, stdjson.Feature()).
+				Specs(&api.GoPolymorphism{}).
+				Context()
+			suite.Nil(err)
+			defer ctx.End(nil)
+
+			_, _, err = api.Send[int](ctx, PlaceOrder{1, "Book"})
+			suite.ErrorIs(err, outbox.ErrMissingStore)
+		})
+	})
+
+	suite.Run("Relay", func() {
+		suite.Run("Post", func() {
+			store := outbox.NewMemoryStore()
+			ctx, consumer := suite.Setup(store, options)
+			defer ctx.End(nil)
+
+			err := outbox.Enqueue(ctx, store, false, &ShipOrder{2}, &ShipOrder{3})
+			suite.Nil(err)
+			suite.Eventually(func() bool {
+				return len(consumer.Shipments()) == 2
+			}, time.Second, time.Millisecond)
+			suite.Equal([]int{2, 3}, consumer.Shipments())
+		})
+
+		suite.Run("Retry", func() {
+			store := outbox.NewMemoryStore()
+			ctx, consumer := suite.Setup(store, options)
+			consumer.Fail(2)
+			defer ctx.End(nil)
+
+			_, _, err := api.Send[int](ctx, PlaceOrder{3, "Pen"})
+			suite.Nil(err)
+			suite.Eventually(func() bool {
+				return len(consumer.Placements()) == 1
+			}, time.Second, time.Millisecond)
+			dead, err := store.Dead()
+			suite.Nil(err)
+			suite.Empty(dead)
+		})
+
+		suite.Run("Dead", func() {
+			store := outbox.NewMemoryStore()
+			opts := options
+			opts.MaxAttempts = 3
+			ctx, consumer := suite.Setup(store, opts)
+			consumer.Fail(100)
+			defer ctx.End(nil)
+
+			_, _, err := api.Send[int](ctx, PlaceOrder{4, "Ink"})
+			suite.Nil(err)
+			suite.Eventually(func() bool {
+				dead, err := store.Dead()
+				return err == nil && len(dead) == 1
+			}, time.Second, time.Millisecond)
+			dead, err := store.Dead()
+			suite.Nil(err)
+			suite.Equal(3, dead[0].Attempts)
+			suite.Equal("consumer unavailable", dead[0].LastError)
+			suite.Empty(consumer.Placements())
+		})
+
+		suite.Run("Store Failure", func() {
+			store := &FailingStore{MemoryStore: outbox.NewMemoryStore()}
+			ctx, err := setup.New(TestFeature, stdjson.Feature()).
+				Specs(&api.GoPolymorphism{}).
+				Context()
+			suite.Nil(err)
+			err = outbox.Enqueue(ctx, store, false, &ShipOrder{6})
+			suite.Nil(err)
+			ctx.End(nil)
+
+			ctx, consumer := suite.Setup(store, outbox.Options{
+				PollInterval: time.Hour,
+				BatchSize:    1,
+			})
+			defer ctx.End(nil)
+			suite.Eventually(func() bool {
+				return store.Completes() > 0
+			}, time.Second, time.Millisecond)
+			time.Sleep(20 * time.Millisecond)
+			suite.Equal(1, store.Completes())
+			suite.Equal([]int{6}, consumer.Shipments())
+		})
+
+		suite.Run("Restart", func() {
+			store, err := outbox.NewFileStore(suite.T().TempDir())
+			suite.Nil(err)
+
+			ctx, err := setup.New(TestFeature, stdjson.Feature()).
+				Specs(&api.GoPolymorphism{}).
+				Context()
+			suite.Nil(err)
+			err = outbox.Enqueue(ctx, store, true, &OrderPlaced{5, "Cup"})
+			suite.Nil(err)
+			ctx.End(nil)
+
+			pending, err := store.Pending(time.Now(), 0)
+			suite.Nil(err)
+			suite.Len(pending, 1)
+
+			ctx, consumer := suite.Setup(store, options)
+			defer ctx.End(nil)
+			suite.Eventually(func() bool {
+				return len(consumer.Placements()) == 1
+			}, time.Second, time.Millisecond)
+			suite.Eventually(func() bool {
+				pending, err = store.Pending(time.Now(), 0)
+				return err == nil && len(pending) == 0
+			}, time.Second, time.Millisecond)
+		})
+	})
+
+	suite.Run("FileStore", func() {
+		dir := suite.T().TempDir()
+		store, err := outbox.NewFileStore(dir)
+		suite.Nil(err)
+		suite.Equal(dir, store.Dir())
+
+		now := time.Now().UTC()
+		err = store.Append(
+			outbox.Entry{Id: "a", Body: []byte("{}"), Created: now, NextAttempt: now},
+			outbox.Entry{Id: "b", Body: []byte("{}"), Created: now.Add(time.Second), NextAttempt: now})
+		suite.Nil(err)
+
+		err = store.Fail("a", errors.New("boom"), now.Add(time.Minute))
+		suite.Nil(err)
+		pending, err := store.Pending(now, 10)
+		suite.Nil(err)
+		suite.Len(pending, 1)
+		suite.Equal("b", pending[0].Id)
+
+		pending, err = store.Pending(now.Add(time.Hour), 10)
+		suite.Nil(err)
+		suite.Len(pending, 2)
+		suite.Equal("a", pending[0].Id)
+		suite.Equal(1, pending[0].Attempts)
+		suite.Equal("boom", pending[0].LastError)
+
+		suite.Nil(store.Fail("b", errors.New("dead"), time.Time{}))
+		dead, err := store.Dead()
+		suite.Nil(err)
+		suite.Len(dead, 1)
+		suite.Equal(outbox.StatusDead, dead[0].Status)
+
+		suite.Nil(store.Complete("a"))
+		var notFound *outbox.EntryNotFoundError
+		suite.ErrorAs(store.Complete("a"), &notFound)
+		suite.Equal("a", notFound.Id)
+	})
+
+	suite.Run("FileStore Partial Append", func() {
+		dir := suite.T().TempDir()
+		store, err := outbox.NewFileStore(dir)
+		suite.Nil(err)
+
+		// a directory in place of the second entry fails it
+		suite.Nil(os.Mkdir(filepath.Join(dir, "b.json"), 0o755))
+		now := time.Now().UTC()
+		err = store.Append(
+			outbox.Entry{Id: "a", Body: []byte("{}"), Created: now, NextAttempt: now},
+			outbox.Entry{Id: "b", Body: []byte("{}"), Created: now, NextAttempt: now},
+			outbox.Entry{Id: "c", Body: []byte("{}"), Created: now, NextAttempt: now})
+		suite.NotNil(err)
+
+		pending, err := store.Pending(now, 10)
+		suite.Nil(err)
+		suite.Empty(pending)
+		files, err := os.ReadDir(dir)
+		suite.Nil(err)
+		suite.Len(files, 1)
+		suite.Equal("b.json", files[0].Name())
+	})
+}
+
+func TestOutboxTestSuite(t *testing.T) {
+	suite.Run(t, new(OutboxTestSuite))
+}
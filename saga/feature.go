@@ -0,0 +1,50 @@
+package saga
+
+import (
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/setup"
+)
+
+// Installer configures saga support.
+type Installer struct {
+	repository Repository
+}
+
+func (i *Installer) SetRepository(repository Repository) {
+	i.repository = repository
+}
+
+func (i *Installer) DependsOn() []setup.Feature {
+	return []setup.Feature{api.Feature()}
+}
+
+func (i *Installer) Install(b *setup.Builder) error {
+	if b.Tag(&featureTag) {
+		repository := i.repository
+		if repository == nil {
+			repository = NewMemoryRepository()
+		}
+		b.Specs(&Timeouts{}).With(repository)
+	}
+	return nil
+}
+
+// UseRepository configures the Repository holding saga state.
+func UseRepository(repository Repository) func(*Installer) {
+	return func(installer *Installer) {
+		installer.SetRepository(repository)
+	}
+}
+
+// Feature configures saga support.
+func Feature(config ...func(*Installer)) setup.Feature {
+	installer := &Installer{}
+	for _, configure := range config {
+		if configure != nil {
+			configure(installer)
+		}
+	}
+	return installer
+}
+
+var featureTag byte
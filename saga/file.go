@@ -0,0 +1,134 @@
+package saga
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// FileRepository is a Repository that keeps each saga in
+// a json file grouped by saga type.  Files are replaced
+// atomically so state survives a process crash or restart.
+type FileRepository struct {
+	dir  string
+	lock sync.Mutex
+}
+
+func (r *FileRepository) Dir() string {
+	return r.dir
+}
+
+func (r *FileRepository) Load(
+	typ, id string,
+) (Record, bool, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return r.read(typ, id)
+}
+
+func (r *FileRepository) Save(
+	record   Record,
+	expected int,
+) (Record, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	current, _, err := r.read(record.Type, record.Id)
+	if err != nil {
+		return record, err
+	}
+	if actual := current.Version; actual != expected {
+		return record, &ConcurrencyError{record.Type, record.Id, expected, actual}
+	}
+	record.Version = expected + 1
+	if err = r.write(record); err != nil {
+		return record, err
+	}
+	return record, nil
+}
+
+func (r *FileRepository) Delete(
+	typ, id  string,
+	expected int,
+) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	current, _, err := r.read(typ, id)
+	if err != nil {
+		return err
+	}
+	if actual := current.Version; actual != expected {
+		return &ConcurrencyError{typ, id, expected, actual}
+	}
+	if err = os.Remove(r.path(typ, id)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+func (r *FileRepository) read(
+	typ, id string,
+) (Record, bool, error) {
+	var record Record
+	byt, err := os.ReadFile(r.path(typ, id))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return record, false, nil
+		}
+		return record, false, err
+	}
+	if err = json.Unmarshal(byt, &record); err != nil {
+		return record, false, err
+	}
+	return record, true, nil
+}
+
+// write replaces the saga file atomically by renaming
+// a fully written temporary file over it.
+func (r *FileRepository) write(record Record) error {
+	byt, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	path := r.path(record.Type, record.Id)
+	dir  := filepath.Dir(path)
+	if err = os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err = tmp.Write(byt); err == nil {
+		err = tmp.Sync()
+	}
+	if ce := tmp.Close(); err == nil {
+		err = ce
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+func (r *FileRepository) path(typ, id string) string {
+	return filepath.Join(r.dir, url.PathEscape(typ), url.PathEscape(id)+".json")
+}
+
+// NewFileRepository creates a new FileRepository in the supplied
+// directory.  The directory is created if it does not exist.
+func NewFileRepository(dir string) (*FileRepository, error) {
+	if dir == "" {
+		panic("dir cannot be empty")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileRepository{dir: dir}, nil
+}
@@ -0,0 +1,101 @@
+package saga
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+type (
+	// Correlated is implemented by messages that
+	// explicitly supply the key of the saga they belong to.
+	Correlated interface {
+		CorrelationId() string
+	}
+
+	// Instance is the correlated state of a running saga.
+	// Instances are loaded from the Repository before a step
+	// executes and saved when it completes successfully.
+	Instance[T any] struct {
+		id        string
+		version   int
+		state     T
+		completed bool
+	}
+)
+
+func (i *Instance[T]) Id() string {
+	return i.id
+}
+
+// Version returns the persisted version of the instance.
+// A zero version indicates a new saga.
+func (i *Instance[T]) Version() int {
+	return i.version
+}
+
+func (i *Instance[T]) New() bool {
+	return i.version == 0
+}
+
+func (i *Instance[T]) State() *T {
+	return &i.state
+}
+
+// Complete ends the saga and removes its state
+// once the current step finishes.
+func (i *Instance[T]) Complete() {
+	i.completed = true
+}
+
+func (i *Instance[T]) Completed() bool {
+	return i.completed
+}
+
+// CorrelationId extracts the saga key from a message.
+// The key is obtained from the Correlated interface or
+// from a field tagged with `saga:"id"`.
+func CorrelationId(message any) (string, bool) {
+	if c, ok := message.(Correlated); ok {
+		if id := c.CorrelationId(); id != "" {
+			return id, true
+		}
+		return "", false
+	}
+	val := reflect.ValueOf(message)
+	for val.Kind() == reflect.Ptr || val.Kind() == reflect.Interface {
+		if val.IsNil() {
+			return "", false
+		}
+		val = val.Elem()
+	}
+	if val.Kind() != reflect.Struct {
+		return "", false
+	}
+	if index, ok := correlationField(val.Type()); ok {
+		if field := val.FieldByIndex(index); !field.IsZero() {
+			return fmt.Sprint(field.Interface()), true
+		}
+	}
+	return "", false
+}
+
+// correlationField finds the field tagged as the saga key.
+func correlationField(typ reflect.Type) ([]int, bool) {
+	if cached, ok := fieldCache.Load(typ); ok {
+		index := cached.([]int)
+		return index, index != nil
+	}
+	var index []int
+	for i := range typ.NumField() {
+		field := typ.Field(i)
+		if tag, ok := field.Tag.Lookup("saga"); ok && tag == "id" && field.IsExported() {
+			index = field.Index
+			break
+		}
+	}
+	fieldCache.Store(typ, index)
+	return index, index != nil
+}
+
+var fieldCache sync.Map
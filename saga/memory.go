@@ -0,0 +1,65 @@
+package saga
+
+import "sync"
+
+// MemoryRepository is a Repository that keeps saga state
+// in memory.  State does not survive a process restart.
+type MemoryRepository struct {
+	records map[string]Record
+	lock    sync.RWMutex
+}
+
+func (r *MemoryRepository) Load(
+	typ, id string,
+) (Record, bool, error) {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	record, ok := r.records[key(typ, id)]
+	if ok {
+		record.Data = append([]byte(nil), record.Data...)
+	}
+	return record, ok, nil
+}
+
+func (r *MemoryRepository) Save(
+	record   Record,
+	expected int,
+) (Record, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	k := key(record.Type, record.Id)
+	actual := r.records[k].Version
+	if actual != expected {
+		return record, &ConcurrencyError{record.Type, record.Id, expected, actual}
+	}
+	record.Version = expected + 1
+	record.Data    = append([]byte(nil), record.Data...)
+	r.records[k]   = record
+	return record, nil
+}
+
+func (r *MemoryRepository) Delete(
+	typ, id  string,
+	expected int,
+) error {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	k := key(typ, id)
+	if actual := r.records[k].Version; actual != expected {
+		return &ConcurrencyError{typ, id, expected, actual}
+	}
+	delete(r.records, k)
+	return nil
+}
+
+// Len returns the number of running sagas.
+func (r *MemoryRepository) Len() int {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return len(r.records)
+}
+
+// NewMemoryRepository creates a new MemoryRepository.
+func NewMemoryRepository() *MemoryRepository {
+	return &MemoryRepository{records: make(map[string]Record)}
+}
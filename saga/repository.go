@@ -0,0 +1,67 @@
+package saga
+
+import (
+	"errors"
+	"fmt"
+)
+
+type (
+	// Record is the persisted form of a saga Instance.
+	Record struct {
+		Type    string
+		Id      string
+		Version int
+		Data    []byte
+	}
+
+	// Repository persists saga state.
+	// Writes are guarded by optimistic concurrency so
+	// implementations must reject stale versions.
+	Repository interface {
+		// Load returns the record of the saga or false
+		// if no such saga is running.
+		Load(typ, id string) (Record, bool, error)
+
+		// Save stores the record if the current version
+		// matches the expected version and increments it.
+		// An expected version of zero creates the record.
+		Save(record Record, expected int) (Record, error)
+
+		// Delete removes the record if the current version
+		// matches the expected version.
+		Delete(typ, id string, expected int) error
+	}
+
+	// ConcurrencyError reports a saga modified concurrently.
+	ConcurrencyError struct {
+		Type     string
+		Id       string
+		Expected int
+		Actual   int
+	}
+
+	// NotFoundError reports a message for a saga not running.
+	NotFoundError struct {
+		Type string
+		Id   string
+	}
+)
+
+var (
+	ErrMissingRepository  = errors.New("saga: no repository is available")
+	ErrMissingCorrelation = errors.New("saga: message has no correlation id")
+)
+
+func (e *ConcurrencyError) Error() string {
+	return fmt.Sprintf("saga: %s %q expected version %d but found %d",
+		e.Type, e.Id, e.Expected, e.Actual)
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("saga: %s %q not found", e.Type, e.Id)
+}
+
+// key uniquely identifies a record within a repository.
+func key(typ, id string) string {
+	return typ + "/" + id
+}
@@ -0,0 +1,169 @@
+package saga
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/handles"
+	"github.com/miruken-go/miruken/promise"
+	"github.com/miruken-go/miruken/provides"
+)
+
+type (
+	// Starts is a FilterProvider for handler methods that
+	// begin a saga with state T.  If the saga is already
+	// running the existing Instance is continued.
+	Starts[T any] struct{}
+
+	// Continues is a FilterProvider for handler methods that
+	// advance a running saga with state T.  A NotFoundError
+	// is returned if the saga is not running.
+	Continues[T any] struct{}
+
+	// step is implemented by saga FilterProviders.
+	step interface {
+		starts() bool
+	}
+
+	// stepFilter loads and saves the saga Instance around
+	// the execution of a handler method.
+	stepFilter[T any] struct{}
+)
+
+// Starts
+
+func (s *Starts[T]) Required() bool {
+	return true
+}
+
+func (s *Starts[T]) AppliesTo(
+	callback miruken.Callback,
+) bool {
+	_, ok := callback.(*handles.It)
+	return ok
+}
+
+func (s *Starts[T]) Filters(
+	binding  miruken.Binding,
+	callback any,
+	composer miruken.Handler,
+) ([]miruken.Filter, error) {
+	return []miruken.Filter{stepFilter[T]{}}, nil
+}
+
+func (s *Starts[T]) starts() bool {
+	return true
+}
+
+// Continues
+
+func (c *Continues[T]) Required() bool {
+	return true
+}
+
+func (c *Continues[T]) AppliesTo(
+	callback miruken.Callback,
+) bool {
+	_, ok := callback.(*handles.It)
+	return ok
+}
+
+func (c *Continues[T]) Filters(
+	binding  miruken.Binding,
+	callback any,
+	composer miruken.Handler,
+) ([]miruken.Filter, error) {
+	return []miruken.Filter{stepFilter[T]{}}, nil
+}
+
+func (c *Continues[T]) starts() bool {
+	return false
+}
+
+// stepFilter
+
+func (f stepFilter[T]) Order() int {
+	return miruken.FilterStageValidation + 10
+}
+
+func (f stepFilter[T]) Next(
+	self     miruken.Filter,
+	next     miruken.Next,
+	ctx      miruken.HandleContext,
+	provider miruken.FilterProvider,
+) ([]any, *promise.Promise[[]any], error) {
+	step, ok := provider.(step)
+	if !ok {
+		return next.Abort()
+	}
+	typ := TypeName[T]()
+	id, ok := CorrelationId(ctx.Callback.Source())
+	if !ok {
+		return next.Fail(fmt.Errorf("%w: %T", ErrMissingCorrelation, ctx.Callback.Source()))
+	}
+	repository, _, ok, err := provides.Type[Repository](ctx)
+	if err != nil {
+		return next.Fail(err)
+	} else if !ok {
+		return next.Fail(ErrMissingRepository)
+	}
+	record, ok, err := repository.Load(typ, id)
+	if err != nil {
+		return next.Fail(err)
+	}
+	instance := &Instance[T]{id: id}
+	if ok {
+		if err = json.Unmarshal(record.Data, &instance.state); err != nil {
+			return next.Fail(fmt.Errorf("saga: unable to decode %s %q: %w", typ, id, err))
+		}
+		instance.version = record.Version
+	} else if !step.starts() {
+		return next.Fail(&NotFoundError{typ, id})
+	}
+	out, pout, err := next.Pipe(instance, instance.State())
+	if err != nil {
+		return out, pout, err
+	} else if pout == nil {
+		return out, nil, f.save(repository, typ, instance)
+	}
+	return nil, promise.Then(pout, func(oo []any) []any {
+		if err := f.save(repository, typ, instance); err != nil {
+			panic(err)
+		}
+		return oo
+	}), nil
+}
+
+// save persists the saga Instance or removes it if completed.
+func (f stepFilter[T]) save(
+	repository Repository,
+	typ        string,
+	instance   *Instance[T],
+) error {
+	if instance.completed {
+		if instance.New() {
+			return nil
+		}
+		return repository.Delete(typ, instance.id, instance.version)
+	}
+	data, err := json.Marshal(instance.state)
+	if err != nil {
+		return fmt.Errorf("saga: unable to encode %s %q: %w", typ, instance.id, err)
+	}
+	record, err := repository.Save(Record{
+		Type: typ,
+		Id:   instance.id,
+		Data: data,
+	}, instance.version)
+	if err == nil {
+		instance.version = record.Version
+	}
+	return err
+}
+
+// TypeName returns the name used to persist saga state T.
+func TypeName[T any]() string {
+	return reflect.TypeFor[T]().String()
+}
@@ -0,0 +1,319 @@
+package test
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/cascade"
+	"github.com/miruken-go/miruken/context"
+	"github.com/miruken-go/miruken/handles"
+	"github.com/miruken-go/miruken/provides"
+	"github.com/miruken-go/miruken/saga"
+	"github.com/miruken-go/miruken/setup"
+	"github.com/stretchr/testify/suite"
+)
+
+//go:generate $GOPATH/bin/miruken -tests
+
+type (
+	SubmitOrder struct {
+		OrderId string `saga:"id"`
+		Amount  float64
+		Timeout time.Duration
+	}
+
+	PaymentReceived struct {
+		Order string
+	}
+
+	OrderShipped struct {
+		OrderId string `saga:"id"`
+	}
+
+	PaymentTimedOut struct {
+		OrderId string `saga:"id"`
+	}
+
+	ProcessPayment struct {
+		OrderId string
+		Amount  float64
+	}
+
+	ShipOrder struct {
+		OrderId string
+	}
+
+	OrderState struct {
+		Amount    float64
+		Paid      bool
+		Cancelled bool
+	}
+
+	OrderSagaManager struct{}
+
+	FulfillmentService struct {
+		commands []any
+		lock     sync.Mutex
+	}
+)
+
+// PaymentReceived
+
+func (p PaymentReceived) CorrelationId() string {
+	return p.Order
+}
+
+// OrderSagaManager
+
+func (m *OrderSagaManager) Submit(
+	_ *struct {
+		handles.It
+		saga.Starts[OrderState]
+	  }, submit SubmitOrder,
+	state *OrderState,
+) (*cascade.Messages, *saga.Timeout) {
+	state.Amount = submit.Amount
+	var timeout *saga.Timeout
+	if submit.Timeout > 0 {
+		timeout = saga.RequestTimeout(submit.Timeout,
+			PaymentTimedOut{OrderId: submit.OrderId})
+	}
+	return cascade.Post(ProcessPayment{submit.OrderId, submit.Amount}), timeout
+}
+
+func (m *OrderSagaManager) Paid(
+	_ *struct {
+		handles.It
+		saga.Continues[OrderState]
+	  }, paid PaymentReceived,
+	instance *saga.Instance[OrderState],
+) *cascade.Messages {
+	instance.State().Paid = true
+	return cascade.Post(ShipOrder{instance.Id()})
+}
+
+func (m *OrderSagaManager) Shipped(
+	_ *struct {
+		handles.It
+		saga.Continues[OrderState]
+	  }, shipped OrderShipped,
+	instance *saga.Instance[OrderState],
+) {
+	instance.Complete()
+}
+
+func (m *OrderSagaManager) TimedOut(
+	_ *struct {
+		handles.It
+		saga.Continues[OrderState]
+	  }, timedOut PaymentTimedOut,
+	instance *saga.Instance[OrderState],
+	fulfillment *FulfillmentService,
+) {
+	if state := instance.State(); !state.Paid {
+		state.Cancelled = true
+		fulfillment.record(timedOut)
+		instance.Complete()
+	}
+}
+
+// FulfillmentService
+
+func (f *FulfillmentService) Constructor(
+	_ *struct {
+		provides.It
+		provides.Single
+	  },
+) {
+}
+
+func (f *FulfillmentService) Pay(
+	_ *handles.It, pay ProcessPayment,
+) {
+	f.record(pay)
+}
+
+func (f *FulfillmentService) Ship(
+	_ *handles.It, ship ShipOrder,
+) {
+	f.record(ship)
+}
+
+func (f *FulfillmentService) Commands() []any {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return append([]any(nil), f.commands...)
+}
+
+func (f *FulfillmentService) record(command any) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.commands = append(f.commands, command)
+}
+
+type SagaTestSuite struct {
+	suite.Suite
+	repository *saga.MemoryRepository
+}
+
+func (suite *SagaTestSuite) Setup() (*context.Context, *FulfillmentService) {
+	suite.repository = saga.NewMemoryRepository()
+	ctx, err := setup.New(
+		TestFeature,
+		saga.Feature(saga.UseRepository(suite.repository))).
+		Context()
+	suite.Require().Nil(err)
+	service, _, ok, err := provides.Type[*FulfillmentService](ctx)
+	suite.Require().True(ok)
+	suite.Require().Nil(err)
+	return ctx, service
+}
+
+func (suite *SagaTestSuite) load(id string) (OrderState, int, bool) {
+	var state OrderState
+	record, ok, err := suite.repository.Load(saga.TypeName[OrderState](), id)
+	suite.Require().Nil(err)
+	if ok {
+		suite.Require().Nil(json.Unmarshal(record.Data, &state))
+	}
+	return state, record.Version, ok
+}
+
+func (suite *SagaTestSuite) TestSaga() {
+	suite.Run("Workflow", func() {
+		ctx, service := suite.Setup()
+		defer ctx.End(nil)
+
+		_, err := api.Post(ctx, SubmitOrder{OrderId: "1", Amount: 20})
+		suite.Nil(err)
+		suite.Equal([]any{ProcessPayment{"1", 20}}, service.Commands())
+		state, version, ok := suite.load("1")
+		suite.True(ok)
+		suite.Equal(1, version)
+		suite.Equal(OrderState{Amount: 20}, state)
+
+		_, err = api.Post(ctx, PaymentReceived{"1"})
+		suite.Nil(err)
+		suite.Equal([]any{ProcessPayment{"1", 20}, ShipOrder{"1"}}, service.Commands())
+		state, version, ok = suite.load("1")
+		suite.True(ok)
+		suite.Equal(2, version)
+		suite.Equal(OrderState{Amount: 20, Paid: true}, state)
+
+		_, err = api.Post(ctx, OrderShipped{"1"})
+		suite.Nil(err)
+		_, _, ok = suite.load("1")
+		suite.False(ok)
+		suite.Equal(0, suite.repository.Len())
+	})
+
+	suite.Run("Not Running", func() {
+		ctx, _ := suite.Setup()
+		defer ctx.End(nil)
+
+		_, err := api.Post(ctx, PaymentReceived{"2"})
+		var notFound *saga.NotFoundError
+		suite.ErrorAs(err, &notFound)
+		suite.Equal("2", notFound.Id)
+		suite.Equal("test.OrderState", notFound.Type)
+	})
+
+	suite.Run("Missing Correlation", func() {
+		ctx, _ := suite.Setup()
+		defer ctx.End(nil)
+
+		_, err := api.Post(ctx, OrderShipped{})
+		suite.ErrorIs(err, saga.ErrMissingCorrelation)
+	})
+
+	suite.Run("Timeout", func() {
+		ctx, service := suite.Setup()
+		defer ctx.End(nil)
+
+		_, err := api.Post(ctx, SubmitOrder{
+			OrderId: "3",
+			Amount:  5,
+			Timeout: 5 * time.Millisecond,
+		})
+		suite.Nil(err)
+		suite.Eventually(func() bool {
+			return len(service.Commands()) == 2
+		}, time.Second, time.Millisecond)
+		suite.Equal(PaymentTimedOut{"3"}, service.Commands()[1])
+		suite.Equal(0, suite.repository.Len())
+	})
+
+	suite.Run("Timeout Completed", func() {
+		ctx, service := suite.Setup()
+		defer ctx.End(nil)
+
+		_, err := api.Post(ctx, SubmitOrder{
+			OrderId: "4",
+			Amount:  5,
+			Timeout: 5 * time.Millisecond,
+		})
+		suite.Nil(err)
+		_, err = api.Post(ctx, PaymentReceived{"4"})
+		suite.Nil(err)
+		_, err = api.Post(ctx, OrderShipped{"4"})
+		suite.Nil(err)
+		timeouts, _, _, _ := provides.Type[*saga.Timeouts](ctx)
+		suite.Eventually(func() bool {
+			return timeouts.Pending() == 0
+		}, time.Second, time.Millisecond)
+		suite.Len(service.Commands(), 2)
+	})
+}
+
+func (suite *SagaTestSuite) TestRepository() {
+	repositories := map[string]func() saga.Repository{
+		"Memory": func() saga.Repository {
+			return saga.NewMemoryRepository()
+		},
+		"File": func() saga.Repository {
+			repository, err := saga.NewFileRepository(suite.T().TempDir())
+			suite.Require().Nil(err)
+			return repository
+		},
+	}
+	for name, create := range repositories {
+		suite.Run(name, func() {
+			repository := create()
+			record, err := repository.Save(saga.Record{
+				Type: "test.OrderState",
+				Id:   "a/b",
+				Data: []byte(`{"Amount":1}`),
+			}, 0)
+			suite.Nil(err)
+			suite.Equal(1, record.Version)
+
+			loaded, ok, err := repository.Load("test.OrderState", "a/b")
+			suite.Nil(err)
+			suite.True(ok)
+			suite.Equal(record, loaded)
+
+			_, err = repository.Save(record, 0)
+			var concurrency *saga.ConcurrencyError
+			suite.ErrorAs(err, &concurrency)
+			suite.Equal(0, concurrency.Expected)
+			suite.Equal(1, concurrency.Actual)
+
+			record, err = repository.Save(record, 1)
+			suite.Nil(err)
+			suite.Equal(2, record.Version)
+
+			suite.ErrorAs(repository.Delete("test.OrderState", "a/b", 1), &concurrency)
+			suite.Nil(repository.Delete("test.OrderState", "a/b", 2))
+			_, ok, err = repository.Load("test.OrderState", "a/b")
+			suite.Nil(err)
+			suite.False(ok)
+		})
+	}
+}
+
+func TestSagaTestSuite(t *testing.T) {
+	suite.Run(t, new(SagaTestSuite))
+}
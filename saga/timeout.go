@@ -0,0 +1,149 @@
+package saga
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/args"
+	"github.com/miruken-go/miruken/internal"
+	"github.com/miruken-go/miruken/promise"
+	"github.com/miruken-go/miruken/provides"
+)
+
+type (
+	// Timeout is a miruken.Effect that delivers a message
+	// to a saga after a delay.  The message should carry the
+	// saga correlation id so the saga can react to it.
+	Timeout struct {
+		after   time.Duration
+		message any
+	}
+
+	// Timeouts is a setup.Bootstrap that tracks pending
+	// Timeout messages and cancels them on shutdown.
+	Timeouts struct {
+		handler miruken.Handler
+		logger  logr.Logger
+		timers  map[*time.Timer]struct{}
+		closed  bool
+		lock    sync.Mutex
+	}
+)
+
+var ErrMissingTimeouts = errors.New("saga: timeouts are not available")
+
+// Timeout
+
+func (t *Timeout) Apply(
+	ctx miruken.HandleContext,
+) (promise.Reflect, error) {
+	timeouts, _, ok, err := provides.Type[*Timeouts](ctx.Composer)
+	if err != nil {
+		return nil, err
+	} else if !ok {
+		return nil, ErrMissingTimeouts
+	}
+	timeouts.Schedule(t.after, t.message)
+	return nil, nil
+}
+
+// Timeouts
+
+func (t *Timeouts) Constructor(
+	_ *struct {
+		provides.It
+		provides.Single
+	  },
+	_ *struct{ args.Optional }, logger logr.Logger,
+) {
+	if logger == (logr.Logger{}) {
+		logger = logr.Discard()
+	}
+	t.logger = logger
+	t.timers = make(map[*time.Timer]struct{})
+}
+
+func (t *Timeouts) Startup(
+	ctx context.Context,
+	h   miruken.Handler,
+) *promise.Promise[struct{}] {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.handler = h
+	return promise.Empty()
+}
+
+func (t *Timeouts) Shutdown(
+	ctx context.Context,
+) *promise.Promise[struct{}] {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.closed = true
+	for timer := range t.timers {
+		timer.Stop()
+	}
+	clear(t.timers)
+	return promise.Empty()
+}
+
+// Schedule posts the message after the delay.
+// Messages pending at shutdown are discarded.
+func (t *Timeouts) Schedule(
+	after   time.Duration,
+	message any,
+) {
+	if internal.IsNil(message) {
+		panic("message cannot be nil")
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.closed || t.handler == nil {
+		return
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(after, func() {
+		t.lock.Lock()
+		delete(t.timers, timer)
+		handler := t.handler
+		t.lock.Unlock()
+		t.deliver(handler, message)
+	})
+	t.timers[timer] = struct{}{}
+}
+
+// Pending returns the number of undelivered timeouts.
+func (t *Timeouts) Pending() int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return len(t.timers)
+}
+
+func (t *Timeouts) deliver(
+	handler miruken.Handler,
+	message any,
+) {
+	p, err := api.Post(handler, message)
+	if err == nil && p != nil {
+		_, err = p.Await()
+	}
+	if err != nil {
+		var notFound *NotFoundError
+		if errors.As(err, &notFound) {
+			return // saga already completed
+		}
+		t.logger.Error(err, "unable to deliver saga timeout", "message", message)
+	}
+}
+
+// RequestTimeout is a fluent builder for a Timeout.
+func RequestTimeout(after time.Duration, message any) *Timeout {
+	if internal.IsNil(message) {
+		panic("message cannot be nil")
+	}
+	return &Timeout{after, message}
+}
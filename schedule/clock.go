@@ -0,0 +1,55 @@
+package schedule
+
+import (
+	"sync"
+	"time"
+)
+
+type (
+	// Clock provides the current time to the Service.
+	Clock interface {
+		Now() time.Time
+	}
+
+	// SystemClock is a Clock reporting the system time.
+	SystemClock struct{}
+
+	// ManualClock is a Clock that only moves when told to.
+	// It is used to deliver timers deterministically in tests.
+	ManualClock struct {
+		now  time.Time
+		lock sync.RWMutex
+	}
+)
+
+// SystemClock
+
+func (c SystemClock) Now() time.Time {
+	return time.Now().UTC()
+}
+
+// ManualClock
+
+func (c *ManualClock) Now() time.Time {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.now
+}
+
+func (c *ManualClock) Set(now time.Time) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = now
+}
+
+func (c *ManualClock) Advance(d time.Duration) time.Time {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.now = c.now.Add(d)
+	return c.now
+}
+
+// NewManualClock creates a new ManualClock starting at now.
+func NewManualClock(now time.Time) *ManualClock {
+	return &ManualClock{now: now}
+}
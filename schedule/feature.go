@@ -0,0 +1,65 @@
+package schedule
+
+import (
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/setup"
+)
+
+// Installer configures scheduled message delivery.
+type Installer struct {
+	store Store
+	clock Clock
+}
+
+func (i *Installer) SetStore(store Store) {
+	i.store = store
+}
+
+func (i *Installer) SetClock(clock Clock) {
+	i.clock = clock
+}
+
+func (i *Installer) DependsOn() []setup.Feature {
+	return []setup.Feature{api.Feature()}
+}
+
+func (i *Installer) Install(b *setup.Builder) error {
+	if b.Tag(&featureTag) {
+		store := i.store
+		if store == nil {
+			store = NewMemoryStore()
+		}
+		b.Specs(&Service{}).With(store)
+		if clock := i.clock; clock != nil {
+			b.With(clock)
+		}
+	}
+	return nil
+}
+
+// UseStore configures the Store holding pending timers.
+func UseStore(store Store) func(*Installer) {
+	return func(installer *Installer) {
+		installer.SetStore(store)
+	}
+}
+
+// UseClock configures the Clock used to determine due timers.
+func UseClock(clock Clock) func(*Installer) {
+	return func(installer *Installer) {
+		installer.SetClock(clock)
+	}
+}
+
+// Feature configures scheduled message delivery.
+func Feature(config ...func(*Installer)) setup.Feature {
+	installer := &Installer{}
+	for _, configure := range config {
+		if configure != nil {
+			configure(installer)
+		}
+	}
+	return installer
+}
+
+var featureTag byte
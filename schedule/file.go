@@ -0,0 +1,138 @@
+package schedule
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FileStore is a Store that keeps each entry in a json
+// file within a directory.  Files are replaced atomically
+// so timers survive a process crash or restart.
+type FileStore struct {
+	dir  string
+	lock sync.Mutex
+}
+
+const fileExt = ".json"
+
+func (s *FileStore) Dir() string {
+	return s.dir
+}
+
+func (s *FileStore) Save(entry Entry) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.write(entry)
+}
+
+func (s *FileStore) Update(entry Entry) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, err := os.Stat(s.path(entry.Id)); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return &EntryNotFoundError{entry.Id}
+		}
+		return err
+	}
+	return s.write(entry)
+}
+
+// write atomically replaces the file of the entry.
+func (s *FileStore) write(entry Entry) error {
+	byt, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(s.dir, "*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = os.Remove(tmp.Name())
+	}()
+	if _, err = tmp.Write(byt); err == nil {
+		err = tmp.Sync()
+	}
+	if ce := tmp.Close(); err == nil {
+		err = ce
+	}
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path(entry.Id))
+}
+
+func (s *FileStore) Remove(id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := os.Remove(s.path(id)); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return &EntryNotFoundError{id}
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *FileStore) Due(
+	now   time.Time,
+	limit int,
+) ([]Entry, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	files, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, err
+	}
+	var due []Entry
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), fileExt) {
+			continue
+		}
+		byt, err := os.ReadFile(filepath.Join(s.dir, file.Name()))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, err
+		}
+		var entry Entry
+		if err = json.Unmarshal(byt, &entry); err != nil {
+			return nil, err
+		}
+		if !entry.Due.After(now) {
+			due = append(due, entry)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].before(&due[j])
+	})
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+func (s *FileStore) path(id string) string {
+	return filepath.Join(s.dir, url.PathEscape(id)+fileExt)
+}
+
+// NewFileStore creates a new FileStore in the supplied directory.
+// The directory is created if it does not exist.
+func NewFileStore(dir string) (*FileStore, error) {
+	if dir == "" {
+		panic("dir cannot be empty")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &FileStore{dir: dir}, nil
+}
@@ -0,0 +1,75 @@
+package schedule
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStore is a Store that keeps entries in memory.
+// Entries do not survive a process restart so it is
+// mostly suitable for testing.
+type MemoryStore struct {
+	entries map[string]Entry
+	lock    sync.Mutex
+}
+
+func (s *MemoryStore) Save(entry Entry) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.entries[entry.Id] = entry
+	return nil
+}
+
+func (s *MemoryStore) Update(entry Entry) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.entries[entry.Id]; !ok {
+		return &EntryNotFoundError{entry.Id}
+	}
+	s.entries[entry.Id] = entry
+	return nil
+}
+
+func (s *MemoryStore) Remove(id string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.entries[id]; !ok {
+		return &EntryNotFoundError{id}
+	}
+	delete(s.entries, id)
+	return nil
+}
+
+func (s *MemoryStore) Due(
+	now   time.Time,
+	limit int,
+) ([]Entry, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	var due []Entry
+	for _, entry := range s.entries {
+		if !entry.Due.After(now) {
+			due = append(due, entry)
+		}
+	}
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].before(&due[j])
+	})
+	if limit > 0 && len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+// Len returns the number of pending entries.
+func (s *MemoryStore) Len() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return len(s.entries)
+}
+
+// NewMemoryStore creates a new MemoryStore.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{entries: make(map[string]Entry)}
+}
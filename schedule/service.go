@@ -0,0 +1,304 @@
+package schedule
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"github.com/google/uuid"
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/args"
+	"github.com/miruken-go/miruken/handles"
+	"github.com/miruken-go/miruken/internal"
+	"github.com/miruken-go/miruken/maps"
+	"github.com/miruken-go/miruken/promise"
+	"github.com/miruken-go/miruken/provides"
+	"github.com/robfig/cron/v3"
+)
+
+type (
+	// Options customize the scheduler.
+	Options struct {
+		Format       string
+		PollInterval time.Duration
+		BatchSize    int
+		MaxAttempts  int
+		RetryDelay   time.Duration
+	}
+
+	// Service is a setup.Bootstrap that handles api.Timer
+	// requests and delivers the messages when they are due.
+	Service struct {
+		store   Store
+		clock   Clock
+		options Options
+		logger  logr.Logger
+		handler miruken.Handler
+		wake    chan struct{}
+		stop    chan struct{}
+		done    chan struct{}
+		once    sync.Once
+		lock    sync.Mutex
+	}
+)
+
+const (
+	defaultFormat       = "application/json"
+	defaultPollInterval = time.Second
+	defaultBatchSize    = 100
+	defaultMaxAttempts  = 5
+	defaultRetryDelay   = 30 * time.Second
+)
+
+func (s *Service) Constructor(
+	_ *struct {
+		provides.It
+		provides.Single
+	  }, store Store,
+	_ *struct {
+		args.Optional
+		args.FromOptions
+	  }, options Options,
+	_ *struct{ args.Optional }, clock Clock,
+	_ *struct{ args.Optional }, logger logr.Logger,
+) {
+	if internal.IsNil(clock) {
+		clock = SystemClock{}
+	}
+	if logger == (logr.Logger{}) {
+		logger = logr.Discard()
+	}
+	options.Format       = internal.DefaultValue(options.Format, defaultFormat)
+	options.PollInterval = internal.DefaultValue(options.PollInterval, defaultPollInterval)
+	options.BatchSize    = internal.DefaultValue(options.BatchSize, defaultBatchSize)
+	options.MaxAttempts  = internal.DefaultValue(options.MaxAttempts, defaultMaxAttempts)
+	options.RetryDelay   = internal.DefaultValue(options.RetryDelay, defaultRetryDelay)
+
+	s.store   = store
+	s.clock   = clock
+	s.options = options
+	s.logger  = logger
+	s.wake    = make(chan struct{}, 1)
+	s.stop    = make(chan struct{})
+	s.done    = make(chan struct{})
+}
+
+func (s *Service) Schedule(
+	_ *handles.It, timer api.Timer,
+	composer miruken.Handler,
+) (string, error) {
+	if internal.IsNil(timer.Message) {
+		return "", fmt.Errorf("schedule: timer has no message")
+	}
+	now := s.clock.Now()
+	due := timer.At
+	if timer.Cron != "" {
+		schedule, err := cron.ParseStandard(timer.Cron)
+		if err != nil {
+			return "", fmt.Errorf("schedule: invalid cron %q: %w", timer.Cron, err)
+		}
+		due = schedule.Next(now)
+	} else if due.IsZero() {
+		return "", ErrMissingTime
+	}
+	to, err := api.ParseMediaType(s.options.Format, maps.DirectionTo)
+	if err != nil {
+		return "", fmt.Errorf("schedule: %w", err)
+	}
+	var b bytes.Buffer
+	out := io.Writer(&b)
+	msg := api.Message{Payload: timer.Message}
+	if _, _, err = maps.Into(miruken.BuildUp(composer, api.Polymorphic), msg, &out, to); err != nil {
+		return "", fmt.Errorf("schedule: unable to encode %T: %w", timer.Message, err)
+	}
+	entry := Entry{
+		Id:          uuid.NewString(),
+		ContentType: s.options.Format,
+		Body:        b.Bytes(),
+		Publish:     timer.Publish,
+		Cron:        timer.Cron,
+		Due:         due.UTC(),
+		Created:     now,
+	}
+	if err = s.store.Save(entry); err != nil {
+		return "", err
+	}
+	s.Notify()
+	return entry.Id, nil
+}
+
+func (s *Service) Cancel(
+	_ *handles.It, cancel api.CancelTimer,
+) error {
+	return s.store.Remove(cancel.Id)
+}
+
+func (s *Service) Startup(
+	ctx context.Context,
+	h   miruken.Handler,
+) *promise.Promise[struct{}] {
+	s.lock.Lock()
+	s.handler = h
+	s.lock.Unlock()
+	go s.run()
+	return promise.Empty()
+}
+
+func (s *Service) Shutdown(
+	ctx context.Context,
+) *promise.Promise[struct{}] {
+	s.once.Do(func() { close(s.stop) })
+	return promise.New(ctx, func(
+		resolve func(struct{}), reject func(error), onCancel func(func()),
+	) {
+		select {
+		case <-s.done:
+			resolve(struct{}{})
+		case <-ctx.Done():
+			reject(ctx.Err())
+		}
+	})
+}
+
+// Notify signals the Service to check for due timers
+// without waiting for the next poll.
+func (s *Service) Notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Dispatch delivers all timers due at the current Clock time
+// and returns the number of messages delivered.
+func (s *Service) Dispatch() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.handler == nil {
+		return 0
+	}
+	delivered := 0
+	for {
+		entries, err := s.store.Due(s.clock.Now(), s.options.BatchSize)
+		if err != nil {
+			s.logger.Error(err, "unable to read due timers")
+			return delivered
+		}
+		// entries that could not be written back are still due,
+		// so stop rather than read the same batch again
+		stalled := false
+		for i := range entries {
+			ok, err := s.deliver(&entries[i])
+			if ok {
+				delivered++
+			}
+			if err != nil {
+				stalled = true
+			}
+		}
+		if stalled || len(entries) < s.options.BatchSize {
+			return delivered
+		}
+	}
+}
+
+func (s *Service) run() {
+	defer close(s.done)
+	ticker := time.NewTicker(s.options.PollInterval)
+	defer ticker.Stop()
+	for {
+		s.Dispatch()
+		select {
+		case <-s.stop:
+			return
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+// deliver dispatches the entry and writes the outcome back to
+// the Store.  It reports if the message was delivered and the
+// error if the Store could not be updated.
+func (s *Service) deliver(entry *Entry) (bool, error) {
+	now := s.clock.Now()
+	err := s.dispatch(entry)
+	if err == nil {
+		entry.Attempts  = 0
+		entry.LastError = ""
+		if entry.Cron == "" {
+			err = s.store.Remove(entry.Id)
+		} else {
+			err = s.reschedule(entry, now)
+		}
+		return true, s.written(entry, err)
+	}
+	entry.Attempts++
+	entry.LastError = err.Error()
+	s.logger.Error(err, "unable to deliver timer", "id", entry.Id, "attempts", entry.Attempts)
+	if entry.Attempts < s.options.MaxAttempts {
+		entry.Due = now.Add(s.options.RetryDelay)
+		err = s.store.Update(*entry)
+	} else if entry.Cron != "" {
+		entry.Attempts  = 0
+		err = s.reschedule(entry, now)
+	} else {
+		err = s.store.Remove(entry.Id)
+	}
+	return false, s.written(entry, err)
+}
+
+// written logs the error writing the entry back to the Store.
+// A missing entry was canceled during delivery and is ignored.
+func (s *Service) written(entry *Entry, err error) error {
+	var notFound *EntryNotFoundError
+	if err == nil || errors.As(err, &notFound) {
+		return nil
+	}
+	s.logger.Error(err, "unable to update timer", "id", entry.Id)
+	return err
+}
+
+func (s *Service) dispatch(entry *Entry) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("schedule: panic delivering timer %q: %v", entry.Id, r)
+		}
+	}()
+	from, err := api.ParseMediaType(entry.ContentType, maps.DirectionFrom)
+	if err != nil {
+		return err
+	}
+	composer := miruken.BuildUp(s.handler, api.Polymorphic)
+	msg, _, _, err := maps.Out[api.Message](composer, bytes.NewReader(entry.Body), from)
+	if err != nil {
+		return err
+	}
+	var pv *promise.Promise[any]
+	if entry.Publish {
+		pv, err = api.Publish(s.handler, msg.Payload)
+	} else {
+		pv, err = api.Post(s.handler, msg.Payload)
+	}
+	if err == nil && pv != nil {
+		_, err = pv.Await()
+	}
+	return err
+}
+
+// reschedule moves a recurring entry to its next occurrence.
+func (s *Service) reschedule(entry *Entry, now time.Time) error {
+	schedule, err := cron.ParseStandard(entry.Cron)
+	if err != nil {
+		s.logger.Error(err, "invalid timer schedule", "id", entry.Id)
+		return s.store.Remove(entry.Id)
+	}
+	entry.Due = schedule.Next(now).UTC()
+	return s.store.Update(*entry)
+}
@@ -0,0 +1,64 @@
+package schedule
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+type (
+	// Entry is an encoded message awaiting delivery at Due.
+	// Recurring entries have a Cron expression and are
+	// rescheduled after each delivery.
+	Entry struct {
+		Id          string
+		ContentType string
+		Body        []byte
+		Publish     bool
+		Cron        string
+		Due         time.Time
+		Created     time.Time
+		Attempts    int
+		LastError   string
+	}
+
+	// Store persists scheduled entries until they are delivered
+	// or canceled.  Implementations must be safe for concurrent use.
+	Store interface {
+		// Save adds or replaces an entry.
+		Save(entry Entry) error
+
+		// Update replaces an existing entry and fails with
+		// EntryNotFoundError if it was removed.
+		Update(entry Entry) error
+
+		// Remove deletes an entry.
+		Remove(id string) error
+
+		// Due returns up to limit entries due for delivery
+		// at the supplied time ordered by due time.
+		Due(now time.Time, limit int) ([]Entry, error)
+	}
+
+	// EntryNotFoundError reports a missing schedule entry.
+	EntryNotFoundError struct {
+		Id string
+	}
+)
+
+var (
+	ErrMissingStore = errors.New("schedule: no store is available")
+	ErrMissingTime  = errors.New("schedule: timer requires a time or cron expression")
+)
+
+func (e *EntryNotFoundError) Error() string {
+	return fmt.Sprintf("schedule: entry %q not found", e.Id)
+}
+
+// before orders entries by due time then id.
+func (e *Entry) before(other *Entry) bool {
+	if e.Due.Equal(other.Due) {
+		return e.Id < other.Id
+	}
+	return e.Due.Before(other.Due)
+}
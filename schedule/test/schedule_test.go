@@ -0,0 +1,322 @@
+package test
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/api"
+	"github.com/miruken-go/miruken/api/json/stdjson"
+	"github.com/miruken-go/miruken/context"
+	"github.com/miruken-go/miruken/creates"
+	"github.com/miruken-go/miruken/handles"
+	"github.com/miruken-go/miruken/provides"
+	"github.com/miruken-go/miruken/schedule"
+	"github.com/miruken-go/miruken/setup"
+	"github.com/stretchr/testify/suite"
+)
+
+//go:generate $GOPATH/bin/miruken -tests
+
+type (
+	Reminder struct {
+		Text string
+	}
+
+	ReminderConsumer struct {
+		failures  int
+		cancel    string
+		reminders []string
+		lock      sync.Mutex
+	}
+
+	// FailingStore fails to write entries back after delivery.
+	FailingStore struct {
+		*schedule.MemoryStore
+		writes int
+	}
+)
+
+// ReminderConsumer
+
+func (c *ReminderConsumer) Constructor(
+	_ *struct {
+		provides.It
+		provides.Single
+	  },
+) {
+}
+
+func (c *ReminderConsumer) Remind(
+	_ *handles.It, reminder *Reminder,
+	ctx miruken.HandleContext,
+) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.failures > 0 {
+		c.failures--
+		return errors.New("reminder unavailable")
+	}
+	c.reminders = append(c.reminders, reminder.Text)
+	if id := c.cancel; id != "" {
+		c.cancel = ""
+		_, err := api.Post(ctx, api.CancelTimer{Id: id})
+		return err
+	}
+	return nil
+}
+
+func (c *ReminderConsumer) New(
+	_ *struct {
+		_ creates.It `key:"test.Reminder"`
+	}, create *creates.It,
+) any {
+	switch create.Key() {
+	case "test.Reminder":
+		return new(Reminder)
+	}
+	return nil
+}
+
+func (c *ReminderConsumer) Fail(failures int) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.failures = failures
+}
+
+func (c *ReminderConsumer) CancelOnDelivery(id string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.cancel = id
+}
+
+func (c *ReminderConsumer) Reminders() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]string(nil), c.reminders...)
+}
+
+// FailingStore
+
+func (s *FailingStore) Update(schedule.Entry) error {
+	s.writes++
+	return errors.New("store unavailable")
+}
+
+func (s *FailingStore) Remove(string) error {
+	s.writes++
+	return errors.New("store unavailable")
+}
+
+type ScheduleTestSuite struct {
+	suite.Suite
+	clock *schedule.ManualClock
+}
+
+func (suite *ScheduleTestSuite) SetupSubTest() {
+	suite.clock = schedule.NewManualClock(
+		time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC))
+}
+
+func (suite *ScheduleTestSuite) Setup(
+	store   schedule.Store,
+	options ...schedule.Options,
+) (*context.Context, *schedule.Service, *ReminderConsumer) {
+	opts := schedule.Options{
+		PollInterval: time.Hour,
+		RetryDelay:   time.Minute,
+	}
+	if len(options) > 0 {
+		opts = options[0]
+	}
+	ctx, err := setup.New(
+		TestFeature,
+		stdjson.Feature(),
+		schedule.Feature(
+			schedule.UseStore(store),
+			schedule.UseClock(suite.clock))).
+		Specs(&api.GoPolymorphism{}).
+		Options(opts).
+		Context()
+	suite.Require().Nil(err)
+	service, _, ok, err := provides.Type[*schedule.Service](ctx)
+	suite.Require().True(ok)
+	suite.Require().Nil(err)
+	consumer, _, ok, err := provides.Type[*ReminderConsumer](ctx)
+	suite.Require().True(ok)
+	suite.Require().Nil(err)
+	return ctx, service, consumer
+}
+
+func (suite *ScheduleTestSuite) TestSchedule() {
+	suite.Run("At", func() {
+		store := schedule.NewMemoryStore()
+		ctx, service, consumer := suite.Setup(store)
+		defer ctx.End(nil)
+
+		at := suite.clock.Now().Add(time.Minute)
+		id, _, err := api.Send[string](ctx, api.Schedule(&Reminder{"call"}, at))
+		suite.Nil(err)
+		suite.NotEmpty(id)
+		suite.Equal(0, service.Dispatch())
+		suite.Empty(consumer.Reminders())
+
+		suite.clock.Advance(time.Minute)
+		suite.Equal(1, service.Dispatch())
+		suite.Equal([]string{"call"}, consumer.Reminders())
+		suite.Equal(0, store.Len())
+	})
+
+	suite.Run("Publish", func() {
+		ctx, service, consumer := suite.Setup(schedule.NewMemoryStore())
+		defer ctx.End(nil)
+
+		timer := api.Schedule(&Reminder{"party"}, suite.clock.Now())
+		timer.Publish = true
+		_, _, err := api.Send[string](ctx, timer)
+		suite.Nil(err)
+		suite.Equal(1, service.Dispatch())
+		suite.Equal([]string{"party"}, consumer.Reminders())
+	})
+
+	suite.Run("Recur", func() {
+		store := schedule.NewMemoryStore()
+		ctx, service, consumer := suite.Setup(store)
+		defer ctx.End(nil)
+
+		_, _, err := api.Send[string](ctx, api.Recur(&Reminder{"stretch"}, "*/5 * * * *"))
+		suite.Nil(err)
+		suite.clock.Advance(4 * time.Minute)
+		suite.Equal(0, service.Dispatch())
+		suite.clock.Advance(time.Minute)
+		suite.Equal(1, service.Dispatch())
+		suite.Equal(0, service.Dispatch())
+		suite.clock.Advance(5 * time.Minute)
+		suite.Equal(1, service.Dispatch())
+		suite.Equal([]string{"stretch", "stretch"}, consumer.Reminders())
+		suite.Equal(1, store.Len())
+	})
+
+	suite.Run("Cancel", func() {
+		store := schedule.NewMemoryStore()
+		ctx, service, consumer := suite.Setup(store)
+		defer ctx.End(nil)
+
+		id, _, err := api.Send[string](ctx, api.Recur(&Reminder{"nag"}, "@hourly"))
+		suite.Nil(err)
+		_, err = api.Post(ctx, api.CancelTimer{Id: id})
+		suite.Nil(err)
+		suite.clock.Advance(time.Hour)
+		suite.Equal(0, service.Dispatch())
+		suite.Empty(consumer.Reminders())
+
+		_, err = api.Post(ctx, api.CancelTimer{Id: id})
+		var notFound *schedule.EntryNotFoundError
+		suite.ErrorAs(err, &notFound)
+		suite.Equal(id, notFound.Id)
+	})
+
+	suite.Run("CancelDuringDelivery", func() {
+		store := schedule.NewMemoryStore()
+		ctx, service, consumer := suite.Setup(store)
+		defer ctx.End(nil)
+
+		id, _, err := api.Send[string](ctx, api.Recur(&Reminder{"once"}, "@hourly"))
+		suite.Nil(err)
+		consumer.CancelOnDelivery(id)
+		suite.clock.Advance(time.Hour)
+		suite.Equal(1, service.Dispatch())
+		suite.Equal(0, store.Len())
+		suite.clock.Advance(time.Hour)
+		suite.Equal(0, service.Dispatch())
+		suite.Equal([]string{"once"}, consumer.Reminders())
+	})
+
+	suite.Run("StoreFailure", func() {
+		store := &FailingStore{MemoryStore: schedule.NewMemoryStore()}
+		ctx, service, consumer := suite.Setup(store, schedule.Options{
+			PollInterval: time.Hour,
+			BatchSize:    1,
+		})
+		defer ctx.End(nil)
+
+		_, _, err := api.Send[string](ctx, api.Schedule(&Reminder{"stuck"}, suite.clock.Now()))
+		suite.Nil(err)
+		suite.Equal(1, service.Dispatch())
+		suite.Equal(1, store.writes)
+		suite.Equal(1, store.Len())
+		suite.Equal([]string{"stuck"}, consumer.Reminders())
+	})
+
+	suite.Run("Retry", func() {
+		store := schedule.NewMemoryStore()
+		ctx, service, consumer := suite.Setup(store)
+		defer ctx.End(nil)
+
+		consumer.Fail(1)
+		_, _, err := api.Send[string](ctx, api.Schedule(&Reminder{"retry"}, suite.clock.Now()))
+		suite.Nil(err)
+		suite.Equal(0, service.Dispatch())
+		suite.Equal(1, store.Len())
+		suite.clock.Advance(time.Minute)
+		suite.Equal(1, service.Dispatch())
+		suite.Equal([]string{"retry"}, consumer.Reminders())
+		suite.Equal(0, store.Len())
+	})
+
+	suite.Run("Invalid", func() {
+		ctx, _, _ := suite.Setup(schedule.NewMemoryStore())
+		defer ctx.End(nil)
+
+		_, _, err := api.Send[string](ctx, api.Recur(&Reminder{}, "every day"))
+		suite.ErrorContains(err, `invalid cron "every day"`)
+		_, _, err = api.Send[string](ctx, api.Timer{Message: &Reminder{}})
+		suite.ErrorIs(err, schedule.ErrMissingTime)
+	})
+
+	suite.Run("Restart", func() {
+		store, err := schedule.NewFileStore(suite.T().TempDir())
+		suite.Nil(err)
+
+		ctx, _, _ := suite.Setup(store)
+		at := suite.clock.Now().Add(time.Hour)
+		_, _, err = api.Send[string](ctx, api.Schedule(&Reminder{"wake"}, at))
+		suite.Nil(err)
+		ctx.End(nil)
+
+		ctx, service, consumer := suite.Setup(store)
+		defer ctx.End(nil)
+		suite.clock.Advance(time.Hour)
+		suite.Equal(1, service.Dispatch())
+		suite.Equal([]string{"wake"}, consumer.Reminders())
+		due, err := store.Due(suite.clock.Now(), 0)
+		suite.Nil(err)
+		suite.Empty(due)
+	})
+
+	suite.Run("Background", func() {
+		ctx, err := setup.New(
+			TestFeature,
+			stdjson.Feature(),
+			schedule.Feature()).
+			Specs(&api.GoPolymorphism{}).
+			Options(schedule.Options{PollInterval: 5 * time.Millisecond}).
+			Context()
+		suite.Nil(err)
+		defer ctx.End(nil)
+		consumer, _, _, _ := provides.Type[*ReminderConsumer](ctx)
+
+		_, _, err = api.Send[string](ctx, api.Schedule(&Reminder{"soon"},
+			time.Now().Add(10*time.Millisecond)))
+		suite.Nil(err)
+		suite.Eventually(func() bool {
+			return len(consumer.Reminders()) == 1
+		}, time.Second, time.Millisecond)
+	})
+}
+
+func TestScheduleTestSuite(t *testing.T) {
+	suite.Run(t, new(ScheduleTestSuite))
+}
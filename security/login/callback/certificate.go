@@ -0,0 +1,45 @@
+package callback
+
+import (
+	"crypto/x509"
+
+	"github.com/miruken-go/miruken"
+)
+
+type (
+	// Certificate requests a verified client certificate.
+	Certificate struct {
+		certificate *x509.Certificate
+	}
+
+	// CertificateHandler responds to Certificate callbacks with given certificate.
+	CertificateHandler struct {
+		Certificate *x509.Certificate
+	}
+)
+
+func (c *Certificate) Certificate() *x509.Certificate {
+	return c.certificate
+}
+
+func (c *Certificate) SetCertificate(certificate *x509.Certificate) {
+	c.certificate = certificate
+}
+
+// CertificateHandler
+
+func (h CertificateHandler) Handle(
+	c any,
+	greedy bool,
+	composer miruken.Handler,
+) miruken.HandleResult {
+	if cert, ok := c.(*Certificate); ok && h.Certificate != nil {
+		cert.SetCertificate(h.Certificate)
+		return miruken.Handled
+	}
+	return miruken.NotHandled
+}
+
+func NewCertificate() *Certificate {
+	return &Certificate{}
+}
@@ -0,0 +1,27 @@
+package x509
+
+import (
+	"github.com/miruken-go/miruken/setup"
+)
+
+// Installer enables client certificate authentication.
+type Installer struct{}
+
+func (i *Installer) Install(b *setup.Builder) error {
+	if b.Tag(&featureTag) {
+		b.Specs(&LoginModule{})
+	}
+	return nil
+}
+
+func Feature(config ...func(*Installer)) setup.Feature {
+	installer := &Installer{}
+	for _, configure := range config {
+		if configure != nil {
+			configure(installer)
+		}
+	}
+	return installer
+}
+
+var featureTag byte
@@ -0,0 +1,122 @@
+package x509
+
+import (
+	x5092 "crypto/x509"
+	"errors"
+	"strings"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/creates"
+	"github.com/miruken-go/miruken/internal/slices"
+	"github.com/miruken-go/miruken/security"
+	"github.com/miruken-go/miruken/security/login/callback"
+	"github.com/miruken-go/miruken/security/principal"
+)
+
+// LoginModule authenticates a subject from a verified client certificate.
+// The subject common name maps to principal.User, the subject alternative
+// names to principal.Id and the organizational units to principal.Group.
+type LoginModule struct {
+	units       []string
+	certificate *x5092.Certificate
+	principals  []security.Principal
+}
+
+var (
+	ErrInvalidUnitsOption = errors.New("invalid organizationalUnits option")
+	ErrMissingCertificate = errors.New("missing client certificate")
+	ErrUntrustedUnit      = errors.New("untrusted organizational unit")
+)
+
+func (l *LoginModule) Constructor(
+	_ *struct {
+		creates.It `key:"login.x509"`
+	},
+) {
+}
+
+func (l *LoginModule) Init(opts map[string]any) error {
+	for k, opt := range opts {
+		switch strings.ToLower(k) {
+		case "organizationalunits":
+			switch o := opt.(type) {
+			case string:
+				l.units = []string{o}
+			case []string:
+				l.units = o
+			case []any:
+				for _, unit := range o {
+					if u, ok := unit.(string); !ok {
+						return ErrInvalidUnitsOption
+					} else {
+						l.units = append(l.units, u)
+					}
+				}
+			default:
+				return ErrInvalidUnitsOption
+			}
+		}
+	}
+	return nil
+}
+
+func (l *LoginModule) Login(
+	subject security.Subject,
+	handler miruken.Handler,
+) error {
+	cert := callback.NewCertificate()
+	if !handler.Handle(cert, false, nil).Handled() || cert.Certificate() == nil {
+		return ErrMissingCertificate
+	}
+	certificate := cert.Certificate()
+	units := certificate.Subject.OrganizationalUnit
+
+	if !l.trusts(units) {
+		return ErrUntrustedUnit
+	}
+
+	if cn := certificate.Subject.CommonName; cn != "" {
+		l.principals = append(l.principals, principal.User(cn))
+	}
+	for _, uri := range certificate.URIs {
+		l.principals = append(l.principals, principal.Id(uri.String()))
+	}
+	for _, dns := range certificate.DNSNames {
+		l.principals = append(l.principals, principal.Id(dns))
+	}
+	for _, email := range certificate.EmailAddresses {
+		l.principals = append(l.principals, principal.Id(email))
+	}
+	for _, unit := range units {
+		l.principals = append(l.principals, principal.Group(unit))
+	}
+
+	l.certificate = certificate
+	subject.AddPrincipals(l.principals...)
+	subject.AddCredentials(l.certificate)
+	return nil
+}
+
+func (l *LoginModule) Logout(
+	subject security.Subject,
+	handler miruken.Handler,
+) error {
+	subject.RemovePrincipals(l.principals...)
+	subject.RemoveCredentials(l.certificate)
+	l.principals  = nil
+	l.certificate = nil
+	return nil
+}
+
+// trusts determines if any organizational unit is allowed.
+func (l *LoginModule) trusts(units []string) bool {
+	if len(l.units) == 0 {
+		return true
+	}
+	for _, unit := range units {
+		if slices.Contains(l.units, unit) {
+			return true
+		}
+	}
+	return false
+}
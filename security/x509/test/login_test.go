@@ -0,0 +1,101 @@
+package test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	x5092 "crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/security/login"
+	"github.com/miruken-go/miruken/security/login/callback"
+	"github.com/miruken-go/miruken/security/principal"
+	"github.com/miruken-go/miruken/security/x509"
+	"github.com/miruken-go/miruken/setup"
+	"github.com/stretchr/testify/suite"
+)
+
+type LoginTestSuite struct {
+	suite.Suite
+	cert *x5092.Certificate
+}
+
+func (suite *LoginTestSuite) SetupTest() {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	suite.Require().Nil(err)
+	spiffe, _ := url.Parse("spiffe://example.org/orders")
+	template := &x5092.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			CommonName:         "orders-service",
+			OrganizationalUnit: []string{"payments", "shipping"},
+		},
+		URIs:      []*url.URL{spiffe},
+		DNSNames:  []string{"orders.example.org"},
+		NotBefore: time.Now().Add(-time.Minute),
+		NotAfter:  time.Now().Add(time.Hour),
+	}
+	der, err := x5092.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	suite.Require().Nil(err)
+	suite.cert, err = x5092.ParseCertificate(der)
+	suite.Require().Nil(err)
+}
+
+func (suite *LoginTestSuite) TestLogin() {
+	handler, _ := setup.New(x509.Feature()).Context()
+
+	suite.Run("Succeed", func() {
+		ctx := login.NewFlow(login.Flow{{Module: "login.x509"}})
+		ch := callback.CertificateHandler{Certificate: suite.cert}
+		sub, err := ctx.Login(miruken.AddHandlers(handler, ch)).Await()
+		suite.Nil(err)
+		suite.True(principal.All(sub,
+			principal.User("orders-service"),
+			principal.Id("spiffe://example.org/orders"),
+			principal.Id("orders.example.org"),
+			principal.Group("payments"),
+			principal.Group("shipping")))
+		suite.Equal([]any{suite.cert}, sub.Credentials())
+
+		sub, err = ctx.Logout(handler).Await()
+		suite.Nil(err)
+		suite.Empty(sub.Principals())
+		suite.Empty(sub.Credentials())
+	})
+
+	suite.Run("TrustedUnit", func() {
+		ctx := login.NewFlow(login.Flow{{
+			Module:  "login.x509",
+			Options: map[string]any{"organizationalUnits": []any{"shipping"}},
+		}})
+		ch := callback.CertificateHandler{Certificate: suite.cert}
+		sub, err := ctx.Login(miruken.AddHandlers(handler, ch)).Await()
+		suite.Nil(err)
+		suite.True(principal.All(sub, principal.User("orders-service")))
+	})
+
+	suite.Run("UntrustedUnit", func() {
+		ctx := login.NewFlow(login.Flow{{
+			Module:  "login.x509",
+			Options: map[string]any{"organizationalUnits": "billing"},
+		}})
+		ch := callback.CertificateHandler{Certificate: suite.cert}
+		_, err := ctx.Login(miruken.AddHandlers(handler, ch)).Await()
+		suite.ErrorIs(err, x509.ErrUntrustedUnit)
+	})
+
+	suite.Run("MissingCertificate", func() {
+		ctx := login.NewFlow(login.Flow{{Module: "login.x509"}})
+		_, err := ctx.Login(handler).Await()
+		suite.ErrorIs(err, x509.ErrMissingCertificate)
+	})
+}
+
+func TestLoginTestSuite(t *testing.T) {
+	suite.Run(t, new(LoginTestSuite))
+}
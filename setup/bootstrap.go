@@ -3,6 +3,7 @@ package setup
 import (
 	"context"
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/miruken-go/miruken"
@@ -33,12 +34,85 @@ type (
 		) *promise.Promise[struct{}]
 	}
 
+	// Stage is the phase of the application lifecycle.
+	Stage int32
+
+	// Lifecycle tracks the Stage of the application as the
+	// Bootstrap instances are started and shutdown.
+	Lifecycle struct {
+		stage atomic.Int32
+	}
+
 	bootstrapper struct {
 		options    Options
 		bootstraps []Bootstrap
+		lifecycle  *Lifecycle
 	}
 )
 
+const (
+	StageStarting Stage = iota
+	StageStarted
+	StageFailed
+	StageStopping
+	StageStopped
+)
+
+
+func (s Stage) String() string {
+	switch s {
+	case StageStarting:
+		return "starting"
+	case StageStarted:
+		return "started"
+	case StageFailed:
+		return "failed"
+	case StageStopping:
+		return "stopping"
+	case StageStopped:
+		return "stopped"
+	default:
+		return "unknown"
+	}
+}
+
+
+func (l *Lifecycle) Constructor(
+	_ *struct {
+		provides.It
+		provides.Single
+	  },
+) {
+}
+
+// Stage returns the current Stage of the application.
+func (l *Lifecycle) Stage() Stage {
+	return Stage(l.stage.Load())
+}
+
+// Started determines if all Bootstrap instances started
+// and the application is not shutting down.
+func (l *Lifecycle) Started() bool {
+	return l.Stage() == StageStarted
+}
+
+// started completes startup unless already stopping.
+func (l *Lifecycle) started(stage Stage) {
+	l.stage.CompareAndSwap(int32(StageStarting), int32(stage))
+}
+
+// stop marks the application as stopping unless
+// it has already stopped.
+func (l *Lifecycle) stop() {
+	for {
+		stage := l.stage.Load()
+		if Stage(stage) == StageStopped ||
+			l.stage.CompareAndSwap(stage, int32(StageStopping)) {
+			return
+		}
+	}
+}
+
 
 func (b *bootstrapper) Constructor(
 	_ *struct {
@@ -50,14 +124,23 @@ func (b *bootstrapper) Constructor(
 		args.FromOptions
 	  }, options Options,
 	bootstraps []Bootstrap,
+	lifecycle  *Lifecycle,
 ) {
 	b.options    = options
 	b.bootstraps = bootstraps
+	b.lifecycle  = lifecycle
 }
 
 func (b *bootstrapper) bootstrap(
 	h miruken.Handler,
 ) *promise.Promise[struct{}] {
+	lifecycle := b.lifecycle
+	if ctx, ok := h.(*context2.Context); ok {
+		// not ready once the context starts ending
+		ctx.Observe(context2.EndingObserverFunc(func(*context2.Context, any) {
+			lifecycle.stop()
+		}))
+	}
 	if bootstraps := b.bootstraps; len(bootstraps) > 0 {
 		ctx := context.Background()
 		var cancel context.CancelFunc
@@ -68,13 +151,23 @@ func (b *bootstrapper) bootstrap(
 		for i, bootstrap := range bootstraps {
 			promises[i] = bootstrap.Startup(ctx, h)
 		}
-		return promise.Erase(promise.All(ctx, promises...)).OnCancel(cancel)
+		started := promise.Erase(promise.All(ctx, promises...)).OnCancel(cancel)
+		return promise.Catch(promise.Then(started, func(s struct{}) struct{} {
+			lifecycle.started(StageStarted)
+			return s
+		}), func(err error) error {
+			lifecycle.started(StageFailed)
+			return err
+		})
 	}
+	lifecycle.started(StageStarted)
 	return promise.Empty()
 }
 
 
 func (b *bootstrapper) Dispose() {
+	b.lifecycle.stop()
+	defer b.lifecycle.stage.Store(int32(StageStopped))
 	if bootstraps := b.bootstraps; len(bootstraps) > 0 {
 		ctx := context.Background()
 		if timeout := b.options.ShutdownTimeout; timeout > 0 {
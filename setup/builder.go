@@ -167,7 +167,7 @@ func (s *Builder) build() (*context.Context, error) {
 
 	var handler miruken.Handler = &miruken.CurrentHandlerInfoFactoryProvider{Factory: factory}
 
-	specs := append(s.specs, &bootstrapper{})
+	specs := append(s.specs, &bootstrapper{}, &Lifecycle{})
 	hs := make([]miruken.HandlerSpec, 0, len(specs))
 	exclude, noInfer := s.exclude, s.noInfer
 	for _, spec := range specs {
@@ -222,6 +222,19 @@ func (suite *SetupTestSuite) TestSetup() {
 	})
 
 	suite.Run("Bootstrap", func() {
+		suite.Run("Lifecycle", func() {
+			ctx, err := setup.New(TestFeature).Context()
+			suite.Nil(err)
+			lifecycle, _, ok, err := miruken.Resolve[*setup.Lifecycle](ctx)
+			suite.True(ok)
+			suite.Nil(err)
+			suite.True(lifecycle.Started())
+			suite.Equal(setup.StageStarted, lifecycle.Stage())
+			ctx.End(nil)
+			suite.False(lifecycle.Started())
+			suite.Equal(setup.StageStopped, lifecycle.Stage())
+		})
+
 		suite.Run("Startup Timeout", func() {
 			ctx, err := setup.New(TestFeature).
 				Options(setup.Options{StartupTimeout: time.Millisecond}).
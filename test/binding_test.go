@@ -0,0 +1,126 @@
+package test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/miruken-go/miruken"
+	"github.com/miruken-go/miruken/handles"
+	"github.com/miruken-go/miruken/promise"
+	"github.com/miruken-go/miruken/setup"
+	"github.com/stretchr/testify/suite"
+)
+
+type (
+	// OutputHandler declares the supported response positions
+	OutputHandler struct{}
+
+	// bindingObserver collects the handles bindings by key
+	bindingObserver map[any]miruken.Binding
+)
+
+var handlesPolicy = (&handles.It{}).Policy()
+
+func (h *OutputHandler) HandleFoo(
+	_ *handles.It, foo *Foo,
+) *Bar {
+	return &Bar{}
+}
+
+func (h *OutputHandler) HandleBar(
+	_ *handles.It, bar *Bar,
+) (*promise.Promise[*Baz], error) {
+	return promise.Resolve(&Baz{}), nil
+}
+
+func (h *OutputHandler) HandleBaz(
+	_ *handles.It, baz *Baz,
+) (SendMail, *Bam) {
+	return SendMail{}, &Bam{}
+}
+
+func (h *OutputHandler) HandleBam(
+	_ *handles.It, bam *Bam,
+) {
+}
+
+func (h *OutputHandler) HandleBoo(
+	_ *handles.It, boo *Boo,
+) miruken.HandleResult {
+	return miruken.Handled
+}
+
+// bindingObserver
+
+func (o bindingObserver) BindingCreated(
+	policy      miruken.Policy,
+	handlerInfo *miruken.HandlerInfo,
+	binding     miruken.Binding,
+) {
+	if policy == handlesPolicy {
+		o[binding.Key()] = binding
+	}
+}
+
+func (o bindingObserver) HandlerInfoCreated(
+	*miruken.HandlerInfo,
+) {
+}
+
+type BindingTestSuite struct {
+	suite.Suite
+}
+
+func (suite *BindingTestSuite) Setup() bindingObserver {
+	observer := bindingObserver{}
+	_, err := setup.New().
+		Specs(&OutputHandler{}).
+		Observers(observer).
+		Context()
+	suite.Require().Nil(err)
+	return observer
+}
+
+func (suite *BindingTestSuite) TestBinding() {
+	suite.Run("LogicalOutputType", func() {
+		suite.Run("First", func() {
+			binding := suite.Setup()[reflect.TypeFor[*Foo]()]
+			suite.Require().NotNil(binding)
+			suite.Equal(reflect.TypeFor[*Bar](), binding.LogicalOutputType())
+			suite.False(binding.Async())
+		})
+
+		suite.Run("Promise", func() {
+			binding := suite.Setup()[reflect.TypeFor[*Bar]()]
+			suite.Require().NotNil(binding)
+			suite.Equal(reflect.TypeFor[*Baz](), binding.LogicalOutputType())
+			suite.True(binding.Async())
+		})
+
+		suite.Run("NotFirst", func() {
+			// returns after an effect are cascaded
+			binding := suite.Setup()[reflect.TypeFor[*Baz]()]
+			suite.Require().NotNil(binding)
+			suite.Nil(binding.LogicalOutputType())
+			suite.False(binding.Async())
+		})
+
+		suite.Run("None", func() {
+			binding := suite.Setup()[reflect.TypeFor[*Bam]()]
+			suite.Require().NotNil(binding)
+			suite.Nil(binding.LogicalOutputType())
+			suite.False(binding.Async())
+		})
+
+		suite.Run("HandleResult", func() {
+			binding := suite.Setup()[reflect.TypeFor[*Boo]()]
+			suite.Require().NotNil(binding)
+			suite.Nil(binding.LogicalOutputType())
+			suite.False(binding.Async())
+		})
+	})
+}
+
+func TestBindingTestSuite(t *testing.T) {
+	suite.Run(t, new(BindingTestSuite))
+}